@@ -0,0 +1,215 @@
+// Animated GIF channel textures.
+//
+// Inputs with a `.gif` src (also when their type is "video") are decoded with
+// image/gif when the channel textures are set up: frames are composited into
+// full RGBA images (GIF disposal methods applied) and kept in memory, and the
+// channel texture is updated in place whenever iChannelTime reaches another
+// frame. Playback loops like in a browser (GIF loop count, 0.1 s for frames
+// without a delay). Decoded frames are limited to ANIMATED_TEXTURE_MAX_BYTES per
+// channel; longer animations are cut short. Relative src paths are resolved
+// against the shader file's directory. Frames are padded or cropped to the
+// sampler's aspect (see texture_fit.go). Other video formats (mp4, webm) are not
+// decoded, their channel stays black.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"log"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+const (
+	ANIMATED_TEXTURE_MAX_BYTES = 32 << 20 // Decoded RGBA frames per channel
+	ANIMATED_TEXTURE_MAX_FILE  = 16 << 20
+	GIF_DEFAULT_DELAY          = 0.1 // Seconds, for frames with delay 0 or 1 (like browsers)
+)
+
+// channelAnimation holds decoded frames of an animated channel texture
+type channelAnimation struct {
+	frames  []*image.RGBA
+	ends    []float64 // End time of each frame within one loop, seconds
+	plays   int       // Times the sequence is shown (0 = forever)
+	current int       // Frame currently in the texture
+	vflip   bool
+	mipmap  bool
+}
+
+// isAnimatedInput reports whether input should get an animated texture
+func isAnimatedInput(input ShaderInput) bool {
+	return input.Type == "video" || strings.EqualFold(filepath.Ext(input.Src), ".gif")
+}
+
+// loadChannelAnimation reads and decodes an animated GIF file
+func loadChannelAnimation(path string) (*channelAnimation, error) {
+	data, err := readChannelFile(path, ANIMATED_TEXTURE_MAX_FILE)
+	if err != nil {
+		return nil, err
+	}
+	return decodeGIFAnimation(bytes.NewReader(data), ANIMATED_TEXTURE_MAX_BYTES)
+}
+
+// decodeGIFAnimation decodes GIF frames into composited RGBA images, keeping
+// as many as fit in maxBytes
+func decodeGIFAnimation(r io.Reader, maxBytes int) (*channelAnimation, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decoding GIF: %v", err)
+	}
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() && len(g.Image) > 0 {
+		bounds = g.Image[0].Bounds()
+	}
+	frameBytes := bounds.Dx() * bounds.Dy() * 4
+	if len(g.Image) == 0 || bounds.Empty() {
+		return nil, fmt.Errorf("GIF has no frames")
+	}
+	if frameBytes > maxBytes {
+		return nil, fmt.Errorf("GIF frame %dx%d too large", bounds.Dx(), bounds.Dy())
+	}
+
+	anim := &channelAnimation{plays: g.LoopCount + 1}
+	if g.LoopCount < 0 {
+		anim.plays = 1
+	} else if g.LoopCount == 0 {
+		anim.plays = 0
+	}
+	canvas := image.NewRGBA(bounds)
+	end := 0.0
+	for i, frame := range g.Image {
+		if (len(anim.frames)+1)*frameBytes > maxBytes {
+			log.Printf("Warning: GIF has %d frames, only the first %d fit in texture memory", len(g.Image), len(anim.frames))
+			break
+		}
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		anim.frames = append(anim.frames, cloneRGBA(canvas))
+
+		delay := GIF_DEFAULT_DELAY
+		if i < len(g.Delay) && g.Delay[i] > 1 {
+			delay = float64(g.Delay[i]) / 100
+		}
+		end += delay
+		anim.ends = append(anim.ends, end)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return anim, nil
+}
+
+// cloneRGBA returns a copy of img
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	clone := image.NewRGBA(img.Bounds())
+	copy(clone.Pix, img.Pix)
+	return clone
+}
+
+// frameAt returns the frame shown t seconds into playback
+func (a *channelAnimation) frameAt(t float64) int {
+	duration := a.ends[len(a.ends)-1]
+	t = max(t, 0)
+	if a.plays > 0 && t >= duration*float64(a.plays) {
+		return len(a.frames) - 1 // Finished, hold the last frame
+	}
+	t = math.Mod(t, duration)
+	return sort.Search(len(a.ends), func(i int) bool { return a.ends[i] > t })
+}
+
+// fit pads or crops all frames to aspect (see fitAspect); padded frames that
+// no longer fit in maxBytes are dropped from the end
+func (a *channelAnimation) fit(fit string, aspect float64, maxBytes int) {
+	if len(a.frames) == 0 {
+		return
+	}
+	first := fitAspect(a.frames[0], fit, aspect)
+	if first == a.frames[0] {
+		return
+	}
+	keep := maxBytes / len(first.Pix)
+	if keep == 0 {
+		log.Printf("Warning: GIF frame too large to pad to aspect %g, stretching it", aspect)
+		return
+	}
+	if keep < len(a.frames) {
+		log.Printf("Warning: only the first %d of %d GIF frames fit in texture memory after padding", keep, len(a.frames))
+		a.frames, a.ends = a.frames[:keep], a.ends[:keep]
+	}
+	a.frames[0] = first
+	for i := 1; i < len(a.frames); i++ {
+		a.frames[i] = fitAspect(a.frames[i], fit, aspect)
+	}
+}
+
+// setupAnimatedChannel decodes input and uploads its first frame; false leaves
+// the channel black
+func setupAnimatedChannel(channel int, input ShaderInput, sampler ShaderSampler) (channelTexture, bool) {
+	if !strings.EqualFold(filepath.Ext(input.Src), ".gif") {
+		log.Printf("Warning: iChannel%d video %q not supported (only animated GIF), channel stays black", channel, input.Src)
+		return channelTexture{}, false
+	}
+	anim, err := loadChannelAnimation(input.Src)
+	if err != nil {
+		log.Printf("Warning: iChannel%d: %v, channel stays black", channel, err)
+		return channelTexture{}, false
+	}
+	anim.fit(sampler.Fit, sampler.Aspect, ANIMATED_TEXTURE_MAX_BYTES)
+	_, _, _, anim.mipmap = sampler.glParams()
+	anim.vflip = bool(sampler.VFlip)
+	first := anim.frames[0]
+	if DEBUG_MODE {
+		log.Printf("iChannel%d: %s, %d frames of %dx%d, %.2f s loop", channel, input.Src, len(anim.frames), first.Bounds().Dx(), first.Bounds().Dy(), anim.ends[len(anim.ends)-1])
+	}
+	return channelTexture{
+		texture:   uploadTexture(first, sampler),
+		width:     first.Bounds().Dx(),
+		height:    first.Bounds().Dy(),
+		animation: anim,
+	}, true
+}
+
+// advanceChannelTextures uploads the current frame of animated channels for
+// their iChannelTime (only when it changed)
+func advanceChannelTextures(textures [CHANNEL_COUNT]channelTexture, times [CHANNEL_COUNT]float32) {
+	for channel, texture := range textures {
+		anim := texture.animation
+		if anim == nil {
+			continue
+		}
+		frame := anim.frameAt(float64(times[channel]))
+		if frame == anim.current {
+			continue
+		}
+		anim.current = frame
+		img := anim.frames[frame]
+		if anim.vflip {
+			img = flipVertical(img)
+		}
+		gl.BindTexture(gl.TEXTURE_2D, texture.texture)
+		gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, int32(texture.width), int32(texture.height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+		if anim.mipmap {
+			gl.GenerateMipmap(gl.TEXTURE_2D)
+		}
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+// TestAnimatedGIF checks GIF frame compositing, timing, looping and the memory limit
+func TestAnimatedGIF(t *testing.T) {
+	colors := color.Palette{color.Transparent, color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}}
+	full := image.NewPaletted(image.Rect(0, 0, 2, 2), colors)
+	for i := range full.Pix {
+		full.Pix[i] = 1
+	}
+	corner := image.NewPaletted(image.Rect(0, 0, 1, 1), colors)
+	corner.Pix[0] = 2
+	hole := image.NewPaletted(image.Rect(1, 1, 2, 2), colors) // Transparent pixel keeps the canvas
+	var data bytes.Buffer
+	if err := gif.EncodeAll(&data, &gif.GIF{
+		Image:     []*image.Paletted{full, corner, hole},
+		Delay:     []int{10, 0, 20},
+		Disposal:  []byte{gif.DisposalNone, gif.DisposalNone, gif.DisposalNone},
+		LoopCount: -1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := decodeGIFAnimation(bytes.NewReader(data.Bytes()), ANIMATED_TEXTURE_MAX_BYTES)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.frames) != 3 {
+		t.Fatalf("%d frames decoded, expected 3", len(anim.frames))
+	}
+	if got := anim.frames[2].RGBAAt(0, 0); got != (color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("composited pixel %v, expected green", got)
+	}
+	if got := anim.frames[2].RGBAAt(1, 1); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("pixel under transparent frame %v, expected red", got)
+	}
+	// Delays 0.1 s, 0.1 s (zero delay), 0.2 s; played once, then the last frame is held
+	for seconds, want := range map[float64]int{0: 0, 0.05: 0, 0.15: 1, 0.35: 2, 10: 2} {
+		if got := anim.frameAt(seconds); got != want {
+			t.Errorf("frame %d at %g s, expected %d", got, seconds, want)
+		}
+	}
+	anim.plays = 0
+	if got := anim.frameAt(0.45); got != 0 {
+		t.Errorf("looping frame %d at 0.45 s, expected 0", got)
+	}
+	// Frames beyond the limit are dropped
+	if anim, err = decodeGIFAnimation(bytes.NewReader(data.Bytes()), 2*2*2*4); err != nil || len(anim.frames) != 2 {
+		t.Errorf("limited decode kept %v frames, %v (expected 2)", anim, err)
+	}
+}
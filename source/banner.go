@@ -0,0 +1,166 @@
+// Deployment banner overlay.
+//
+// Corporate and kiosk installs can show a fixed message ("Property of ACME
+// Corp", a maintenance notice) over the aurora: set "banner" in the settings
+// file or pass `-banner "text"`. The text is word-wrapped to the screen width
+// (at most BANNER_MAX_LINES lines, the last one cut with an ellipsis) and
+// centered at the top, middle or bottom of the fullscreen window. It is drawn
+// after the shader and before the debug overlay, with a shadow like the clock,
+// and fades with the shader. Off (empty text) by default.
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	BANNER_POS_TOP         = "top"
+	BANNER_POS_CENTER      = "center"
+	BANNER_POS_BOTTOM      = "bottom"
+	BANNER_SIZE_MIN        = 1.0
+	BANNER_SIZE_MAX        = 4.0
+	BANNER_SIZE_DEFAULT    = 2.0
+	BANNER_COLOR           = "#FFFFFF"
+	BANNER_OPACITY_DEFAULT = 0.8
+	BANNER_MARGIN          = 32 // Distance from screen edges in pixels (before scaling)
+	BANNER_LINE_HEIGHT     = 15 // Line step in pixels at size 1
+	BANNER_MAX_LINES       = 4
+)
+
+// BannerSettings configures the banner overlay (saved in settings, copied to Config)
+type BannerSettings struct {
+	Text     string  `json:"text"`     // Message, newlines start new lines; empty = no banner
+	Position string  `json:"position"` // "top", "center" or "bottom"
+	Size     float64 `json:"size"`     // Text scale (1 = 7x13 pixel font)
+	Color    string  `json:"color"`    // Hex "#RRGGBB"
+	Opacity  float64 `json:"opacity"`  // 0-1
+}
+
+// defaultBannerSettings returns banner settings used when nothing was saved
+func defaultBannerSettings() BannerSettings {
+	return BannerSettings{
+		Position: BANNER_POS_BOTTOM,
+		Size:     BANNER_SIZE_DEFAULT,
+		Color:    BANNER_COLOR,
+		Opacity:  BANNER_OPACITY_DEFAULT,
+	}
+}
+
+// validateBannerPosition reports an error for unknown banner positions
+func validateBannerPosition(position string) error {
+	switch position {
+	case BANNER_POS_TOP, BANNER_POS_CENTER, BANNER_POS_BOTTOM:
+		return nil
+	}
+	return fmt.Errorf("unknown banner position %q (expected top, center or bottom)", position)
+}
+
+// normalize replaces invalid values (hand-edited file) with defaults
+func (b *BannerSettings) normalize() {
+	defaults := defaultBannerSettings()
+	if validateBannerPosition(b.Position) != nil {
+		b.Position = defaults.Position
+	}
+	if b.Size <= 0 {
+		b.Size = defaults.Size
+	}
+	b.Size = clampFloat(b.Size, BANNER_SIZE_MIN, BANNER_SIZE_MAX)
+	if !clockColorPattern.MatchString(b.Color) {
+		b.Color = defaults.Color
+	}
+	b.Opacity = clampFloat(b.Opacity, 0, 1)
+}
+
+// enabled reports whether there is a message to show
+func (b BannerSettings) enabled() bool {
+	return strings.TrimSpace(b.Text) != "" && b.Opacity > 0
+}
+
+// bannerLines word-wraps text to lines at most maxWidth font pixels wide (and never wider
+// than the text texture). Words too long for a line are split; more than BANNER_MAX_LINES
+// lines are cut, with an ellipsis on the last one.
+func bannerLines(text string, maxWidth int) []string {
+	face := basicfont.Face7x13
+	maxWidth = max(min(maxWidth, TEXT_IMAGE_WIDTH), face.Advance*len(TEXT_ELLIPSIS)+face.Advance)
+	fits := func(s string) bool { return font.MeasureString(face, s).Ceil() <= maxWidth }
+
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if fits(candidate) {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// Split words longer than a line
+			runes := []rune(word)
+			for !fits(string(runes)) {
+				n := len(runes) - 1
+				for n > 1 && !fits(string(runes[:n])) {
+					n--
+				}
+				lines = append(lines, string(runes[:n]))
+				runes = runes[n:]
+			}
+			line = string(runes)
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) > BANNER_MAX_LINES {
+		lines = lines[:BANNER_MAX_LINES]
+		last := []rune(lines[len(lines)-1])
+		for len(last) > 0 && !fits(string(last)+TEXT_ELLIPSIS) {
+			last = last[:len(last)-1]
+		}
+		lines[len(lines)-1] = strings.TrimRight(string(last), " ") + TEXT_ELLIPSIS
+	}
+	return lines
+}
+
+// drawBanner renders the banner centered at its position.
+// alpha fades the banner with the shader; hiDPI is framebuffer/window ratio.
+func drawBanner(tr *TextRenderer, b BannerSettings, alpha, hiDPI float32) {
+	if !b.enabled() || alpha <= 0 {
+		return
+	}
+	scale := float32(b.Size) * hiDPI
+	margin := BANNER_MARGIN * hiDPI
+	lineHeight := BANNER_LINE_HEIGHT * scale
+	lines := bannerLines(b.Text, int((float32(tr.width)-2*margin)/scale))
+	height := lineHeight * float32(len(lines))
+
+	y := margin
+	switch b.Position {
+	case BANNER_POS_CENTER:
+		y = (float32(tr.height) - height) / 2
+	case BANNER_POS_BOTTOM:
+		y = float32(tr.height) - margin - height
+	}
+
+	rgb := clockRGB(b.Color)
+	opacity := float32(b.Opacity) * alpha
+	for _, line := range lines {
+		x := (float32(tr.width) - tr.TextWidth(line, scale)) / 2
+		// Shadow offset by one font pixel keeps text readable over bright aurora
+		tr.SetColor(0.0, 0.0, 0.0, CLOCK_SHADOW_ALPHA*opacity)
+		tr.Render(line, x+scale, y+scale, scale)
+		tr.SetColor(rgb[0], rgb[1], rgb[2], opacity)
+		tr.Render(line, x, y, scale)
+		y += lineHeight
+	}
+	tr.SetColor(1.0, 1.0, 1.0, 1.0)
+}
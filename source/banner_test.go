@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+// TestBannerLines checks banner wrapping: explicit and word breaks, long words, line limit
+func TestBannerLines(t *testing.T) {
+	face := basicfont.Face7x13
+	lines := bannerLines("Property of ACME Corp\nMaintenance tonight", 14*face.Advance)
+	want := []string{"Property of", "ACME Corp", "Maintenance", "tonight"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("wrapped to %q, expected %q", lines, want)
+	}
+	lines = bannerLines(strings.Repeat("x", 30), 10*face.Advance)
+	if !reflect.DeepEqual(lines, []string{strings.Repeat("x", 10), strings.Repeat("x", 10), strings.Repeat("x", 10)}) {
+		t.Errorf("long word split to %q", lines)
+	}
+	lines = bannerLines(strings.Repeat("word ", 40), 12*face.Advance)
+	if len(lines) != BANNER_MAX_LINES || !strings.HasSuffix(lines[len(lines)-1], TEXT_ELLIPSIS) {
+		t.Errorf("long message not cut to %d lines with ellipsis: %q", BANNER_MAX_LINES, lines)
+	}
+	for _, line := range bannerLines(strings.Repeat("wide ", 200), 10000) {
+		if width := font.MeasureString(face, line).Ceil(); width > TEXT_IMAGE_WIDTH {
+			t.Errorf("banner line is %d px wide (limit %d)", width, TEXT_IMAGE_WIDTH)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Shader repair benchmark (`-bench-fix`, `-bench-fix-dir <dir>`).
+//
+// Loading a shader runs preprocessJSON over the file and fixShaderCode over
+// every pass, and the repair passes are line-based heuristics whose cost
+// grows quickly with shader size. The benchmark runs these steps
+// -bench-runs times over the selected shader (embedded or -shader) and,
+// with -bench-fix-dir, over each .json/.glsl/.frag/.fs file in a directory,
+// then prints time per run and throughput (of the step's input) of every
+// step; "total" is preprocessJSON plus fixShaderCode. No GL context is
+// needed. Passes skipped by -skip-fixes or the shader's skip_fixes are not
+// timed, -no-fix is ignored. The pattern cache (cachedPattern) is cleared
+// before each run, so timings match loading a shader once.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const BENCH_FIX_DEFAULT_RUNS = 100
+
+// benchFixStep is the accumulated time of one step over all runs
+type benchFixStep struct {
+	name    string
+	bytes   int // Input size of one run
+	elapsed time.Duration
+	skipped bool
+}
+
+// runBenchFix benchmarks shader parsing and repair, printing results to stdout
+func runBenchFix(cfg *Config, dir string, runs int) error {
+	if runs <= 0 {
+		return fmt.Errorf("run count must be positive, got %d", runs)
+	}
+	data, name := shaderJSONData, "embedded shader.json"
+	if cfg.ShaderPath != "" && !isShaderURL(cfg.ShaderPath) {
+		fileData, err := os.ReadFile(cfg.ShaderPath)
+		if err != nil {
+			return fmt.Errorf("error reading shader file: %v", err)
+		}
+		data, name = fileData, cfg.ShaderPath
+	}
+	if err := benchFixShader(cfg, data, name, runs); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(stripGzipExt(entry.Name()))) {
+		case ".json", ".glsl", ".frag", ".fs":
+		default:
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		fileData, err := os.ReadFile(path)
+		if err == nil {
+			err = benchFixShader(cfg, fileData, path, runs)
+		}
+		if err != nil {
+			fmt.Printf("%s: skipped: %v\n", path, err)
+		}
+	}
+	return nil
+}
+
+// benchFixShader times preprocessJSON (JSON files only), comment removal and
+// each repair pass over the passes of one shader file, then fixShaderCode as a whole
+func benchFixShader(cfg *Config, data []byte, name string, runs int) error {
+	shaderData, err := parseShaderFile(data, name)
+	if err != nil {
+		return err
+	}
+	var codes []string
+	codeBytes := 0
+	for _, pass := range shaderData.Passes {
+		code := normalizeLineEndings(pass.Code)
+		codes = append(codes, code)
+		codeBytes += len(code)
+	}
+	skip := shaderSkipFixes(shaderData.Metadata, cfg)
+
+	var preprocess *benchFixStep
+	text, err := decompressShaderData(data)
+	if err == nil {
+		text, err = decodeShaderText(text)
+	}
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(stripGzipExt(name)), ".json") {
+		preprocess = &benchFixStep{name: "preprocess", bytes: len(text)}
+	}
+	steps := []*benchFixStep{{name: "comments", bytes: codeBytes}}
+	for _, fix := range shaderFixes {
+		steps = append(steps, &benchFixStep{name: fix.name, bytes: codeBytes, skipped: skip[fix.name]})
+	}
+	total := &benchFixStep{name: "total", bytes: codeBytes}
+
+	for run := 0; run < runs; run++ {
+		clearPatternCache()
+		if preprocess != nil {
+			start := time.Now()
+			if _, err := preprocessJSON(text); err != nil {
+				return err
+			}
+			preprocess.elapsed += time.Since(start)
+		}
+		for _, code := range codes {
+			start := time.Now()
+			code = removeComments(code)
+			steps[0].elapsed += time.Since(start)
+			uninitializedVars := make(map[string]string)
+			for i, fix := range shaderFixes {
+				if skip[fix.name] {
+					continue
+				}
+				start := time.Now()
+				code = runShaderFix(fix.name, code, uninitializedVars)
+				steps[i+1].elapsed += time.Since(start)
+			}
+		}
+
+		clearPatternCache()
+		start := time.Now()
+		if preprocess != nil {
+			if _, err := preprocessJSON(text); err != nil {
+				return err
+			}
+		}
+		for _, code := range codes {
+			fixShaderCode(code, skip)
+		}
+		total.elapsed += time.Since(start)
+	}
+	if preprocess != nil {
+		steps = append([]*benchFixStep{preprocess}, steps...)
+	}
+
+	fmt.Printf("%s: %d passes, %d bytes of code, %d runs\n", name, len(codes), codeBytes, runs)
+	for _, step := range append(steps, total) {
+		if step.skipped {
+			fmt.Printf("  %-10s skipped\n", step.name)
+			continue
+		}
+		perRun := step.elapsed.Seconds() / float64(runs)
+		throughput := 0.0
+		if perRun > 0 {
+			throughput = float64(step.bytes) / perRun / 1e6
+		}
+		fmt.Printf("  %-10s %9.3f ms/run %9.2f MB/s\n", step.name, perRun*1000, throughput)
+	}
+	return nil
+}
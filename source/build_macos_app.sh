@@ -9,8 +9,14 @@ RESOURCES_DIR="${CONTENTS_DIR}/Resources"
 LEGACY_BIN="myapp"
 TMP_BIN="${APP_NAME}"
 
-echo "Building macOS binary..."
-go build -o "${TMP_BIN}" .
+# Version info embedded via ldflags (falls back to "dev" outside git checkouts).
+VERSION="$(git describe --tags --always 2>/dev/null || echo dev)"
+COMMIT="$(git rev-parse --short HEAD 2>/dev/null || true)"
+BUILD_DATE="$(date -u +%Y-%m-%d)"
+VERSION_LDFLAGS="-X main.version=${VERSION} -X main.commit=${COMMIT} -X main.buildDate=${BUILD_DATE}"
+
+echo "Building macOS binary (${VERSION})..."
+go build -ldflags "${VERSION_LDFLAGS}" -o "${TMP_BIN}" .
 
 echo "Creating app bundle..."
 rm -rf "${APP_BUNDLE}"
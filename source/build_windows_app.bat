@@ -10,8 +10,17 @@ set OUT_DIR=%APP_NAME%-windows-app
 set SCR_FILE=%APP_NAME%.scr
 set LAUNCHER=%APP_NAME%.cmd
 
-echo Building Windows screensaver binary...
-go build -v -ldflags "-H windowsgui" -o "%SCR_FILE%" .
+rem Version info embedded via ldflags (falls back to "dev" outside git checkouts).
+set VERSION=dev
+set COMMIT=
+set BUILD_DATE=
+for /f "delims=" %%i in ('git describe --tags --always 2^>nul') do set VERSION=%%i
+for /f "delims=" %%i in ('git rev-parse --short HEAD 2^>nul') do set COMMIT=%%i
+for /f "delims=" %%i in ('powershell -NoProfile -Command "Get-Date -Format yyyy-MM-dd" 2^>nul') do set BUILD_DATE=%%i
+set VERSION_LDFLAGS=-X main.version=%VERSION% -X main.commit=%COMMIT% -X main.buildDate=%BUILD_DATE%
+
+echo Building Windows screensaver binary (%VERSION%)...
+go build -v -ldflags "-H windowsgui %VERSION_LDFLAGS%" -o "%SCR_FILE%" .
 if errorlevel 1 (
   echo Build failed.
   popd
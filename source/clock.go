@@ -0,0 +1,145 @@
+// Digital clock overlay.
+//
+// Settings -> Clock (or `-clock`) draws the current time, optionally with
+// seconds and the date, in a screen corner of the fullscreen window. It is
+// redrawn every frame from time.Now(); RenderLines only re-rasterizes the
+// text when it changed, i.e. once per second or minute. A soft dark shadow
+// keeps it readable over bright parts of the aurora. Off by default.
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+	"time"
+)
+
+const (
+	CLOCK_SIZE_MIN     = 1.0
+	CLOCK_SIZE_MAX     = 4.0
+	CLOCK_SIZE_DEFAULT = 2.0
+	CLOCK_COLOR        = "#E6FFF2" // Default: slightly green-tinted white
+	CLOCK_MARGIN       = 24        // Distance from screen edges in pixels (before scaling)
+	CLOCK_LINE_HEIGHT  = 15        // Line step in pixels at size 1
+	CLOCK_ALPHA        = 0.85
+	CLOCK_SHADOW_ALPHA = 0.6
+)
+
+var clockColorPattern = regexp.MustCompile(`^#?[0-9A-Fa-f]{6}$`)
+
+// ClockSettings configures the clock overlay (saved in settings, copied to Config)
+type ClockSettings struct {
+	Enabled  bool    `json:"enabled"`
+	Use24h   bool    `json:"use_24h"`
+	Seconds  bool    `json:"seconds"`
+	Date     bool    `json:"date"`
+	Position string  `json:"position"` // Corner, same names as watermark positions
+	Size     float64 `json:"size"`     // Text scale (1 = 7x13 pixel font)
+	Color    string  `json:"color"`    // Hex "#RRGGBB"
+}
+
+// defaultClockSettings returns clock settings used when nothing was saved
+func defaultClockSettings() ClockSettings {
+	return ClockSettings{
+		Use24h:   true,
+		Position: WATERMARK_POS_TOP_RIGHT,
+		Size:     CLOCK_SIZE_DEFAULT,
+		Color:    CLOCK_COLOR,
+	}
+}
+
+// normalize replaces invalid values (hand-edited file) with defaults
+func (c *ClockSettings) normalize() {
+	defaults := defaultClockSettings()
+	if validateWatermarkPosition(c.Position) != nil {
+		c.Position = defaults.Position
+	}
+	if c.Size <= 0 {
+		c.Size = defaults.Size
+	}
+	c.Size = clampFloat(c.Size, CLOCK_SIZE_MIN, CLOCK_SIZE_MAX)
+	if !clockColorPattern.MatchString(c.Color) {
+		c.Color = defaults.Color
+	}
+}
+
+// layout returns time.Format layout of the time line
+func (c ClockSettings) layout() string {
+	switch {
+	case c.Use24h && c.Seconds:
+		return "15:04:05"
+	case c.Use24h:
+		return "15:04"
+	case c.Seconds:
+		return "3:04:05 PM"
+	default:
+		return "3:04 PM"
+	}
+}
+
+// clockLines returns overlay text for now: time, then date if enabled
+func clockLines(now time.Time, c ClockSettings) []string {
+	lines := []string{now.Format(c.layout())}
+	if c.Date {
+		lines = append(lines, now.Format("Mon, 2 Jan 2006"))
+	}
+	return lines
+}
+
+// clockRGB converts clock color to GL color components
+func clockRGB(hex string) [3]float32 {
+	rgba := color.RGBAModel.Convert(parseColor(hex)).(color.RGBA)
+	return [3]float32{float32(rgba.R) / 255, float32(rgba.G) / 255, float32(rgba.B) / 255}
+}
+
+// drawClock renders clock lines in the configured corner.
+// alpha fades the clock with the shader; hiDPI is framebuffer/window ratio.
+func drawClock(tr *TextRenderer, lines []string, c ClockSettings, alpha, hiDPI float32) {
+	if len(lines) == 0 || alpha <= 0 {
+		return
+	}
+	scale := float32(c.Size) * hiDPI
+	margin := CLOCK_MARGIN * hiDPI
+	lineHeight := CLOCK_LINE_HEIGHT * scale
+	right := c.Position == WATERMARK_POS_TOP_RIGHT || c.Position == WATERMARK_POS_BOTTOM_RIGHT
+
+	y := margin
+	if c.Position == WATERMARK_POS_BOTTOM_LEFT || c.Position == WATERMARK_POS_BOTTOM_RIGHT {
+		y = float32(tr.height) - margin - lineHeight*float32(len(lines))
+	}
+
+	// Shadow offset by one font pixel, then text; both draws reuse the uploaded texture
+	rgb := clockRGB(c.Color)
+	drawWithShadow := func(x, y float32, render func(x, y float32)) {
+		tr.SetColor(0.0, 0.0, 0.0, CLOCK_SHADOW_ALPHA*alpha)
+		render(x+scale, y+scale)
+		tr.SetColor(rgb[0], rgb[1], rgb[2], CLOCK_ALPHA*alpha)
+		render(x, y)
+	}
+	if right {
+		// Right-aligned lines start at different x, one draw each
+		for _, line := range lines {
+			x := float32(tr.width) - margin - tr.TextWidth(line, scale)
+			drawWithShadow(x, y, func(x, y float32) { tr.Render(line, x, y, scale) })
+			y += lineHeight
+		}
+	} else {
+		drawWithShadow(margin, y, func(x, y float32) { tr.RenderLines(lines, x, y, lineHeight, scale) })
+	}
+	tr.SetColor(1.0, 1.0, 1.0, 1.0)
+}
+
+// String describes clock format for logging ("24h, seconds at top-right")
+func (c ClockSettings) String() string {
+	format := "12h"
+	if c.Use24h {
+		format = "24h"
+	}
+	if c.Seconds {
+		format += ", seconds"
+	}
+	if c.Date {
+		format += ", date"
+	}
+	return fmt.Sprintf("%s at %s", format, c.Position)
+}
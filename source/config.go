@@ -0,0 +1,210 @@
+// Runtime configuration shared by all screensaver modes.
+//
+// Compile-time constants in `main.go` still define release defaults; Config
+// carries the values that can change per launch (command line flags, safe mode).
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateLayouts are accepted -date formats (without offset: local time)
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// WALL_CLOCK_PERIOD is iTime wrap period in seconds for -wall-clock without -time-wrap
+// (float32 iTime can't hold seconds since 1970 precisely)
+const WALL_CLOCK_PERIOD = 3600.0
+
+// Rendering limits in a Remote Desktop session (see remote_session_windows.go)
+const (
+	REMOTE_SESSION_MAX_FPS      = 10
+	REMOTE_SESSION_RENDER_SCALE = 0.5
+)
+
+// Config holds per-launch runtime settings
+type Config struct {
+	// SafeMode is set after a crashed run (see safemode.go)
+	SafeMode bool
+	// MaxFPS caps render loop frame rate (0 = unlimited)
+	MaxFPS int
+	// Multisample enables 4x MSAA for the fullscreen window
+	Multisample bool
+	// MinifyShader enables whitespace minify step after fixShaderCode
+	MinifyShader bool
+	// ShowWatermark draws shader title/author/URL in a corner after start (see watermark.go)
+	ShowWatermark bool
+	// WatermarkPosition is the corner for the watermark ("top-left", "bottom-right", ...)
+	WatermarkPosition string
+	// WatermarkDuration is how long the watermark stays visible, in seconds
+	WatermarkDuration float64
+	// RenderWidth/RenderHeight fix internal render resolution (0 = screen resolution)
+	RenderWidth  int
+	RenderHeight int
+	// Letterbox keeps aspect ratio when scaling fixed-size render to screen (otherwise stretch)
+	Letterbox bool
+	// UpscaleFilter is "linear" or "nearest" filtering for scaling fixed-size render
+	UpscaleFilter string
+	// Interactive keeps screensaver open on input (Esc exits), mouse drives iMouse
+	Interactive bool
+	// DumpUniformsPath receives uniform dumps of the U key in interactive mode (empty = log, see uniforms.go)
+	DumpUniformsPath string
+	// SeekStep is the iTime step of [ and ] in interactive mode, in seconds (see input.go)
+	SeekStep float64
+	// Wallpaper renders behind desktop icons until -wallpaper-stop (see wallpaper.go)
+	Wallpaper bool
+	// ExitOnMove exits on mouse movement of MoveThreshold window pixels in total (see input.go)
+	ExitOnMove    bool
+	MoveThreshold int
+	// Scale is feature size multiplier passed to shaders as iScale (see settings.go)
+	Scale float64
+	// Speed multiplies iTime and iTimeDelta; Brightness multiplies the final color (see uniforms.go)
+	Speed      float64
+	Brightness float64
+	// HideCursor hides the mouse cursor over the screensaver window (ignored with Interactive)
+	HideCursor bool
+	// TimeWrap wraps iTime modulo this many seconds to keep float precision (0 = off)
+	TimeWrap float64
+	// NoFix disables all shader repair passes; SkipFixes disables selected ones (see shader_fixes.go)
+	NoFix     bool
+	SkipFixes map[string]bool
+	// RenderScale renders at this fraction of framebuffer size (1.0 = native, see framebuffer.go)
+	RenderScale float64
+	// VSync synchronizes buffer swaps with display refresh
+	VSync bool
+	// ShaderPath loads shader from .json/.glsl file instead of embedded one (see shader_file.go)
+	ShaderPath string
+	// FragCoordMode is FRAGCOORD_PIXEL (Shadertoy) or FRAGCOORD_SQUARE (aspect-corrected, see uniforms.go)
+	FragCoordMode string
+	// NoFade skips fade-in and fade-out (iFade is always 1.0, input exits at once)
+	NoFade bool
+	// StartDelay shows black for this many milliseconds before the shader loads (see start_delay.go)
+	StartDelay int
+	// FreezeAfter/FreezeFor alternate live animation and a held frame to save power, in seconds (0 = off, see freeze.go)
+	FreezeAfter float64
+	FreezeFor   float64
+	// ShaderRate renders the shader this many times per second while every display frame presents the latest one (0 = every frame, see shader_rate.go)
+	ShaderRate int
+	// FixedStep advances iTime and iFrame in whole steps of this many seconds (0 = per frame, see frame_state.go)
+	FixedStep float64
+	// SnapTime advances iTime in whole refresh periods of the primary display (see frame_state.go)
+	SnapTime bool
+	// Dither is DITHER_OFF, DITHER_FADE or DITHER_ALWAYS (see uniforms.go)
+	Dither string
+	// Palette is the bundled color palette fed to iPalette (see palette.go)
+	Palette string
+	// Vignette darkens screen edges by this strength (0 = off), starting at VignetteRadius from the center (see uniforms.go)
+	Vignette       float64
+	VignetteRadius float64
+
+	// SimpleText draws overlay text as a stretched bitmap instead of SDF glyphs (see text_sdf.go)
+	SimpleText bool
+	// FlipCoord measures fragCoord.y and iMouse.y from the top, for shaders ported from top-left origin APIs
+	FlipCoord bool
+	// AllowHTTP permits plain http:// shader URLs (https only by default, see shader_url.go)
+	AllowHTTP bool
+	// WallClock derives iTime from system clock, so several machines show the same frame
+	WallClock bool
+	// ShaderParams holds saved shader parameter values: shader key -> param name -> value (see params.go)
+	ShaderParams map[string]map[string]float64
+	// Clock configures the clock overlay (see clock.go)
+	Clock ClockSettings
+	// DateBase is the iDate moment at start (-date); zero = real clock
+	DateBase time.Time
+	// Banner configures the message banner overlay (see banner.go)
+	Banner BannerSettings
+	// ExitScreenshotDir receives a PNG of the last frame on every exit (empty = off, see exit_screenshot.go)
+	ExitScreenshotDir string
+	// ExitScreenshotOverlay includes banner, watermark, clock and debug text in the exit screenshot
+	ExitScreenshotOverlay bool
+}
+
+// defaultConfig returns configuration matching release behavior
+func defaultConfig() Config {
+	return Config{
+		Multisample:       true,
+		MinifyShader:      true,
+		WatermarkPosition: WATERMARK_POS_BOTTOM_RIGHT,
+		WatermarkDuration: WATERMARK_DEFAULT_DURATION,
+		Letterbox:         true,
+		UpscaleFilter:     FILTER_LINEAR,
+		Scale:             SCALE_DEFAULT,
+		Speed:             SPEED_DEFAULT,
+		Brightness:        BRIGHTNESS_DEFAULT,
+		HideCursor:        HIDE_MOUSE_CURSOR,
+		RenderScale:       1.0,
+		VSync:             true,
+		FragCoordMode:     FRAGCOORD_PIXEL,
+		Dither:            DITHER_OFF,
+		Palette:           PALETTE_AURORA,
+		VignetteRadius:    VIGNETTE_RADIUS_DEFAULT,
+		Clock:             defaultClockSettings(),
+		Banner:            defaultBannerSettings(),
+		MoveThreshold:     MOVE_THRESHOLD_DEFAULT,
+		SeekStep:          SEEK_STEP_DEFAULT,
+		FreezeFor:         FREEZE_FOR_DEFAULT,
+	}
+}
+
+// hasRenderSize reports whether shader renders at fixed internal resolution
+func (c *Config) hasRenderSize() bool {
+	return c.RenderWidth > 0 && c.RenderHeight > 0
+}
+
+// usesRenderTarget reports whether shader renders offscreen and is scaled to screen
+func (c *Config) usesRenderTarget() bool {
+	return c.hasRenderSize() || (c.RenderScale > 0 && c.RenderScale != 1.0) || c.ShaderRate > 0
+}
+
+// shaderTimeOrigin returns moment iTime counts from: process start, or Unix epoch with WallClock
+func (c *Config) shaderTimeOrigin(start time.Time) time.Time {
+	if c.WallClock {
+		return time.Unix(0, 0)
+	}
+	return start
+}
+
+// parseDate parses -date value in one of dateLayouts
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if date, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (expected e.g. 2026-01-01T00:00:00 or 2026-01-01)", s)
+}
+
+// dateAt returns iDate moment elapsed seconds after start: DateBase plus elapsed, zero (real clock) without -date
+func (c *Config) dateAt(elapsed float64) time.Time {
+	if c.DateBase.IsZero() {
+		return time.Time{}
+	}
+	return c.DateBase.Add(time.Duration(elapsed * float64(time.Second)))
+}
+
+// shaderTimeWrap returns iTime wrap period (WallClock always wraps)
+func (c *Config) shaderTimeWrap() float64 {
+	if c.WallClock && c.TimeWrap <= 0 {
+		return WALL_CLOCK_PERIOD
+	}
+	return c.TimeWrap
+}
+
+// applySafeMode switches configuration to conservative settings
+func (c *Config) applySafeMode() {
+	c.SafeMode = true
+	c.MaxFPS = SAFE_MODE_MAX_FPS
+	c.Multisample = false
+}
+
+// applyRemoteSession reduces rendering to what a Remote Desktop session can show
+// (keeps lower frame rate caps and render scales that are already set)
+func (c *Config) applyRemoteSession() {
+	if c.MaxFPS == 0 || c.MaxFPS > REMOTE_SESSION_MAX_FPS {
+		c.MaxFPS = REMOTE_SESSION_MAX_FPS
+	}
+	c.RenderScale = min(c.RenderScale, REMOTE_SESSION_RENDER_SCALE)
+	c.Multisample = false
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDateOverride checks -date parsing and that iDate advances from the given date
+func TestDateOverride(t *testing.T) {
+	for value, want := range map[string]time.Time{
+		"2026-01-01T00:00:00":       time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local),
+		"2026-01-01":                time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local),
+		"2026-06-30T23:59:30+02:00": time.Date(2026, 6, 30, 21, 59, 30, 0, time.UTC),
+	} {
+		got, err := parseDate(value)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("-date %s parsed as %v, %v (expected %v)", value, got, err, want)
+		}
+	}
+	if _, err := parseDate("tomorrow"); err == nil {
+		t.Errorf("invalid -date accepted")
+	}
+
+	var cfg Config
+	if !cfg.dateAt(10).IsZero() {
+		t.Errorf("iDate overridden without -date")
+	}
+	cfg.DateBase = time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC)
+	if date := cfg.dateAt(90); !date.Equal(time.Date(2027, 1, 1, 0, 0, 30, 0, time.UTC)) {
+		t.Errorf("iDate 90 s after 2026-12-31 23:59 is %v", date)
+	}
+}
+
+// TestRemoteSession checks that Remote Desktop limits only ever lower frame rate and resolution
+func TestRemoteSession(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.applyRemoteSession()
+	if cfg.MaxFPS != REMOTE_SESSION_MAX_FPS || cfg.RenderScale != REMOTE_SESSION_RENDER_SCALE || cfg.Multisample {
+		t.Errorf("remote session config: %d FPS, render scale %g, MSAA %v", cfg.MaxFPS, cfg.RenderScale, cfg.Multisample)
+	}
+	cfg.MaxFPS, cfg.RenderScale = 5, 0.25
+	cfg.applyRemoteSession()
+	if cfg.MaxFPS != 5 || cfg.RenderScale != 0.25 {
+		t.Errorf("lower limits raised to %d FPS, render scale %g", cfg.MaxFPS, cfg.RenderScale)
+	}
+}
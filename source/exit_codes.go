@@ -0,0 +1,78 @@
+// Process exit codes.
+//
+// Scripts running `-selftest`, `-framedump` or the screensaver itself can tell
+// failure kinds apart by exit code; `-help` lists them. Offscreen modes return
+// glContextError and shaderError, which exitCode maps to their codes. Like
+// log.Fatal, fatal exits immediately without running deferred calls, so a
+// fullscreen run killed this way leaves its crash marker and the next launch
+// uses safe mode.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+const (
+	EXIT_OK      = 0
+	EXIT_FAILURE = 1 // Other errors (file I/O)
+	EXIT_SHADER  = 2 // Shader load/compile failure (after fallback also failed)
+	EXIT_GL      = 3 // GLFW/OpenGL initialization or first frame failure
+	EXIT_USAGE   = 4 // Invalid command line arguments
+)
+
+// exitCodesHelp is appended to -help output
+const exitCodesHelp = `
+Exit codes:
+  0  success
+  1  other error (file I/O)
+  2  shader load/compile failure (after fallback also failed)
+  3  GLFW/OpenGL initialization or first frame failure
+  4  invalid arguments
+`
+
+// glContextError reports that no GL context could be created (EXIT_GL)
+type glContextError struct {
+	err error
+}
+
+func (e *glContextError) Error() string {
+	return fmt.Sprintf("creating GL context: %v", e.err)
+}
+
+// shaderError reports a shader that failed to load, repair or compile (EXIT_SHADER)
+type shaderError struct {
+	err error
+}
+
+func (e *shaderError) Error() string {
+	return e.err.Error()
+}
+
+// exitCode returns the exit code for err: EXIT_GL or EXIT_SHADER for the typed
+// errors above, otherwise fallback
+func exitCode(err error, fallback int) int {
+	var contextErr *glContextError
+	var shaderErr *shaderError
+	switch {
+	case errors.As(err, &contextErr):
+		return EXIT_GL
+	case errors.As(err, &shaderErr):
+		return EXIT_SHADER
+	}
+	return fallback
+}
+
+// fatal logs v like log.Println and exits with code
+func fatal(code int, v ...any) {
+	log.Output(2, fmt.Sprintln(v...))
+	os.Exit(code)
+}
+
+// fatalf logs like log.Printf and exits with code
+func fatalf(code int, format string, v ...any) {
+	log.Output(2, fmt.Sprintf(format, v...))
+	os.Exit(code)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestExitCode checks the exit codes of offscreen mode errors
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"other error", errors.New("open frames/frame_0001.png: permission denied"), EXIT_FAILURE},
+		{"shader error", &shaderError{errors.New("fragment shader: 0:12: syntax error")}, EXIT_SHADER},
+		{"no GL context", &glContextError{errors.New("no display")}, EXIT_GL},
+		{"wrapped GL context error", fmt.Errorf("check: %w", &glContextError{errors.New("no display")}), EXIT_GL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err, EXIT_FAILURE); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
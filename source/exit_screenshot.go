@@ -0,0 +1,68 @@
+// Screenshot of the last frame on exit (`-exit-screenshot <dir>`).
+//
+// For catalog imagery, the screensaver can save what was on screen when it was
+// dismissed: the first frame after the exit request is drawn at the brightness
+// of the moment of exit (fade-out starts one frame later) and read back into
+// exit_<date>_<time>.png in the configured directory. By default only the
+// shader is captured; with `-exit-screenshot-overlay` the banner, watermark,
+// clock and debug text are included as well. The PNG is encoded while the
+// fade-out runs and written before the process exits. Off by default.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// exitScreenshot captures one frame after the exit request
+type exitScreenshot struct {
+	dir     string
+	overlay bool // Capture after overlays instead of right after the shader
+	taken   bool
+	written chan struct{} // Closed when the PNG is written (nil before capture)
+}
+
+// newExitScreenshot returns the exit screenshot configured in cfg (disabled without a directory)
+func newExitScreenshot(cfg *Config) *exitScreenshot {
+	return &exitScreenshot{dir: cfg.ExitScreenshotDir, overlay: cfg.ExitScreenshotOverlay}
+}
+
+// due reports whether the current frame is to be captured
+func (s *exitScreenshot) due(state *FrameState) bool {
+	return s.dir != "" && !s.taken && state.exiting()
+}
+
+// capture reads the back buffer of the window and writes it in the background
+func (s *exitScreenshot) capture(width, height int) {
+	s.taken = true
+	pixels := make([]byte, width*height*4)
+	if err := readPixelsSync(0, width, height, pixels); err != nil {
+		log.Printf("Error taking exit screenshot: %v", err)
+		return
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("exit_%s.png", time.Now().Format("20060102_150405.000")))
+	s.written = make(chan struct{})
+	go func() {
+		defer close(s.written)
+		img := pixelsToImage(pixels, width, height)
+		if err := os.MkdirAll(s.dir, 0755); err != nil {
+			log.Printf("Error writing exit screenshot: %v", err)
+			return
+		}
+		if err := writePNG(path, img); err != nil {
+			log.Printf("Error writing exit screenshot: %v", err)
+			return
+		}
+		log.Printf("Exit screenshot written to %s", path)
+	}()
+}
+
+// wait blocks until a captured screenshot is written
+func (s *exitScreenshot) wait() {
+	if s.written != nil {
+		<-s.written
+	}
+}
@@ -0,0 +1,251 @@
+// Command line parsing.
+//
+// Windows passes screensaver protocol arguments (`/s`, `/c`, `/p <HWND>`), while
+// developer/support options use dash-style flags (`-reset-safe-mode`).
+// Both styles can be mixed, so we split them before handing flags to `flag`.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// commandLine holds parsed command line
+type commandLine struct {
+	config          Config
+	screensaverArgs []string // Arguments for detectScreensaverMode
+	resetSafeMode   bool
+	wallpaperStop   bool
+	showVersion     bool
+	printConfig     bool // Print effective configuration and exit
+	selfTest        bool
+	checkShader     bool   // Compile the shader without rendering and exit
+	dumpShaderPath  string // Write processed shader code here and exit ("-" = stdout)
+	dumpFormat      string // Format of dumped shader: "", "pretty" or "min"
+	exportGLSLPath  string // Write complete fragment shader here and exit ("-" = stdout)
+	dumpFullShader  bool   // Log complete shader source on compile errors
+	softwareGL      bool   // Request software GL rasterizer (diagnostics)
+	ignoreRemote    bool   // Render normally in a Remote Desktop session
+	frameDumpDir    string // Render frames to PNG files here and exit
+	frameDumpCount  int
+	benchFix        bool   // Time shader parsing and repair passes and exit
+	benchFixDir     string // Also benchmark shader files in this directory
+	benchRuns       int
+	streamPath      string // Stream raw frames here until reader closes ("-" = stdout)
+	streamFormat    string // "rgba" or "nv12"
+	streamFPS       int
+}
+
+// isBoolFlag reports whether flag takes no separate value argument
+func isBoolFlag(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok {
+		return bf.IsBoolFlag()
+	}
+	return false
+}
+
+// splitArgs separates screensaver protocol arguments from dash-style flags.
+// A value following a non-bool flag is kept with the flag even if it starts
+// with "/" (e.g. an absolute path on macOS/Linux).
+func splitArgs(fs *flag.FlagSet, args []string) (screensaverArgs []string, flagArgs []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			flagArgs = append(flagArgs, arg)
+			name := strings.TrimLeft(arg, "-")
+			if strings.Contains(name, "=") {
+				continue
+			}
+			if !isBoolFlag(fs, name) && i+1 < len(args) {
+				i++
+				flagArgs = append(flagArgs, args[i])
+			}
+			continue
+		}
+
+		screensaverArgs = append(screensaverArgs, arg)
+		// Keep HWND together with "/p"
+		if strings.ToLower(arg) == "/p" && i+1 < len(args) {
+			if _, err := parseWindowHandle(args[i+1]); err == nil {
+				i++
+				screensaverArgs = append(screensaverArgs, args[i])
+			}
+		}
+	}
+	return screensaverArgs, flagArgs
+}
+
+// parseCommandLine parses program arguments (without program name).
+// Flags override values of base configuration (defaults plus saved settings).
+func parseCommandLine(args []string, base Config) (*commandLine, error) {
+	cl := &commandLine{config: base}
+
+	fs := flag.NewFlagSet(SCREENSAVER_NAME, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s:\n", SCREENSAVER_NAME)
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), exitCodesHelp)
+	}
+	fs.BoolVar(&cl.resetSafeMode, "reset-safe-mode", false, "clear safe mode flag set after a crashed run and exit")
+	fs.BoolVar(&cl.showVersion, "version", false, "print version and exit")
+	fs.BoolVar(&cl.printConfig, "print-config", false, "print effective configuration (defaults + settings file + flags) as key=value lines and exit (-format json for JSON)")
+	fs.BoolVar(&cl.selfTest, "selftest", false, "render a few frames offscreen, report OK/FAIL and exit (non-zero exit code on failure)")
+	fs.BoolVar(&cl.checkShader, "check-shader", false, "load, repair and compile the shader (all passes) without rendering and exit (exit code 2 on compile errors)")
+	fs.StringVar(&cl.frameDumpDir, "framedump", "", "render -frames frames offscreen to PNG files in `dir` and exit")
+	fs.IntVar(&cl.frameDumpCount, "frames", FRAMEDUMP_DEFAULT_FRAMES, "number of frames written by -framedump")
+	fs.BoolVar(&cl.benchFix, "bench-fix", false, "time JSON preprocessing and shader repair passes over the shader, print ms/run and MB/s and exit")
+	fs.StringVar(&cl.benchFixDir, "bench-fix-dir", "", "also benchmark the shader files in `dir` with -bench-fix (implies -bench-fix)")
+	fs.IntVar(&cl.benchRuns, "bench-runs", BENCH_FIX_DEFAULT_RUNS, "number of runs per shader for -bench-fix")
+	fs.StringVar(&cl.streamPath, "stream", "", "render raw frames offscreen to `file`, named pipe or stdout (\"-\") for an external encoder, until the reader closes")
+	fs.StringVar(&cl.streamFormat, "stream-format", STREAM_FORMAT_RGBA, "pixel format of -stream output: rgba or nv12")
+	fs.IntVar(&cl.streamFPS, "stream-fps", STREAM_DEFAULT_FPS, "frame rate of -stream output")
+	fs.StringVar(&cl.dumpShaderPath, "dump-shader", "", "write processed shader code to `file` (\"-\" for stdout) and exit")
+	fs.StringVar(&cl.exportGLSLPath, "export-glsl", "", "write complete fragment shader (uniforms, main) for external validators to `file` (\"-\" for stdout) and exit")
+	noMinify := fs.Bool("no-minify", false, "skip shader minify step (keep processed code as repaired)")
+	fs.BoolVar(&cl.config.ShowWatermark, "watermark", false, "show shader title/author/URL in a corner for a few seconds after start")
+	fs.StringVar(&cl.config.WatermarkPosition, "watermark-pos", cl.config.WatermarkPosition, "watermark corner: top-left, top-right, bottom-left or bottom-right")
+	fs.BoolVar(&cl.config.Clock.Enabled, "clock", cl.config.Clock.Enabled, "show the current time in a screen corner (format, corner and color: Settings -> Clock)")
+	fs.StringVar(&cl.config.Banner.Text, "banner", cl.config.Banner.Text, "show a message banner over the aurora (\\n starts a new line)")
+	fs.StringVar(&cl.config.Banner.Position, "banner-pos", cl.config.Banner.Position, "banner position: top, center or bottom")
+	fs.StringVar(&cl.config.Banner.Color, "banner-color", cl.config.Banner.Color, "banner text color as `#RRGGBB`")
+	fs.Float64Var(&cl.config.Banner.Opacity, "banner-opacity", cl.config.Banner.Opacity, "banner opacity (0-1)")
+	fs.Float64Var(&cl.config.WatermarkDuration, "watermark-duration", cl.config.WatermarkDuration, "watermark display time in `seconds` (including fade-out)")
+	date := fs.String("date", "", "feed iDate from this `date` (e.g. 2026-01-01T00:00:00, local time unless an offset is given) plus time since start instead of the real clock")
+	renderSize := fs.String("render-size", "", "render shader at fixed `WxH` resolution and scale it to the screen")
+	fs.BoolVar(&cl.config.Letterbox, "letterbox", cl.config.Letterbox, "keep aspect ratio of -render-size output (false = stretch to screen)")
+	fs.StringVar(&cl.config.UpscaleFilter, "filter", cl.config.UpscaleFilter, "upscale filter for -render-size: linear or nearest")
+	fs.BoolVar(&cl.config.NoFade, "no-fade", cl.config.NoFade, "start and exit instantly, without fade-in/fade-out")
+	fs.IntVar(&cl.config.StartDelay, "start-delay", cl.config.StartDelay, "show black for `ms` milliseconds before loading the shader and fading in (0 = start at once)")
+	fs.StringVar(&cl.config.Dither, "dither", cl.config.Dither, "dither final color against banding of dark gradients: off, fade (only during fade-in/fade-out) or always")
+	fs.StringVar(&cl.config.Palette, "palette", cl.config.Palette, "aurora color palette: "+paletteNames())
+	fs.Float64Var(&cl.config.Vignette, "vignette", cl.config.Vignette, "darken screen edges with this `strength` (0 = off, 1 = black corners)")
+	fs.Float64Var(&cl.config.VignetteRadius, "vignette-radius", cl.config.VignetteRadius, "distance from the center where the vignette starts (0 = center, 1 = corners; max 0.95)")
+	fs.BoolVar(&cl.config.SimpleText, "simple-text", cl.config.SimpleText, "draw overlay text as a scaled bitmap instead of sharp SDF glyphs")
+	fs.BoolVar(&cl.config.Interactive, "interactive", false, "don't exit on input (Esc exits), show cursor and feed mouse to iMouse")
+	fs.StringVar(&cl.config.ExitScreenshotDir, "exit-screenshot", cl.config.ExitScreenshotDir, "save the last frame as a PNG in `dir` whenever the screensaver exits (for catalog imagery)")
+	fs.BoolVar(&cl.config.ExitScreenshotOverlay, "exit-screenshot-overlay", cl.config.ExitScreenshotOverlay, "include banner, watermark, clock and debug text in -exit-screenshot")
+	fs.StringVar(&cl.config.DumpUniformsPath, "dump-uniforms", "", "append uniform values dumped with the U key in interactive mode to `file` (default: log)")
+	fs.Float64Var(&cl.config.SeekStep, "seek-step", cl.config.SeekStep, "`seconds` [ and ] move iTime back/forward in interactive mode (Shift: 10x)")
+	fs.BoolVar(&cl.config.Wallpaper, "wallpaper", false, "render behind desktop icons as a live wallpaper (Windows) until -wallpaper-stop or Ctrl+C")
+	fs.BoolVar(&cl.wallpaperStop, "wallpaper-stop", false, "stop a running -wallpaper instance and exit")
+	fs.BoolVar(&cl.config.ExitOnMove, "exit-on-move", cl.config.ExitOnMove, "exit on mouse movement (after a short grace period, see -move-threshold)")
+	fs.IntVar(&cl.config.MoveThreshold, "move-threshold", cl.config.MoveThreshold, "total cursor travel in `pixels` that exits with -exit-on-move (raise for jittery touchpads)")
+	fs.BoolVar(&cl.dumpFullShader, "dump-full-shader", false, "log complete shader source on compile errors (default: only lines around errors)")
+	fs.BoolVar(&cl.ignoreRemote, "ignore-remote", false, "render at full resolution and frame rate in a Remote Desktop session (reduced by default, Windows only)")
+	fs.BoolVar(&cl.softwareGL, "software", false, "use software OpenGL rendering (Mesa llvmpipe on Linux) to tell shader bugs from driver bugs; slow, for diagnostics")
+	fs.Float64Var(&cl.config.Speed, "speed", cl.config.Speed, "animation speed: multiply iTime and iTimeDelta by `factor` (0.1-4)")
+	fs.Float64Var(&cl.config.Brightness, "brightness", cl.config.Brightness, "multiply the final color by `factor` (0.1-2)")
+	fs.BoolVar(&cl.config.HideCursor, "hide-cursor", cl.config.HideCursor, "hide the mouse cursor over the screensaver (never with -interactive)")
+	fs.Float64Var(&cl.config.TimeWrap, "time-wrap", cl.config.TimeWrap, "wrap iTime every `seconds` to keep float precision on long runs (0 = off)")
+	fs.StringVar(&cl.config.FragCoordMode, "fragcoord", cl.config.FragCoordMode, "fragCoord mapping: pixel (Shadertoy) or square (aspect-corrected, for shaders that look stretched)")
+	fs.BoolVar(&cl.config.FlipCoord, "flip-coord", cl.config.FlipCoord, "measure fragCoord.y and iMouse.y from the top, for shaders that render upside down")
+	fs.BoolVar(&cl.config.WallClock, "wall-clock", cl.config.WallClock, "derive iTime from system clock so several machines stay in sync")
+	fs.StringVar(&cl.config.ShaderPath, "shader", cl.config.ShaderPath, "load shader from `file` or https URL (.json like the embedded one, or bare mainImage .glsl/.frag/.fs)")
+	fs.BoolVar(&cl.config.AllowHTTP, "allow-http", cl.config.AllowHTTP, "allow plain http:// shader URLs")
+	fs.BoolVar(&cl.config.NoFix, "no-fix", cl.config.NoFix, "skip all shader repair passes")
+	skipFixes := fs.String("skip-fixes", fixListString(cl.config.SkipFixes), "comma-separated shader repair passes to skip: "+knownFixNames())
+	fs.Float64Var(&cl.config.RenderScale, "render-scale", cl.config.RenderScale, "render at this fraction of screen resolution (0.25-2.0)")
+	fs.IntVar(&cl.config.MaxFPS, "max-fps", cl.config.MaxFPS, "frame rate cap (0 = unlimited)")
+	fs.IntVar(&cl.config.ShaderRate, "shader-rate", cl.config.ShaderRate, "render the shader `n` times per second while the display still refreshes at full rate with the latest frame (0 = every frame)")
+	fs.Float64Var(&cl.config.FreezeAfter, "freeze-after", cl.config.FreezeAfter, "save power: after `seconds` of animation hold the frame for -freeze-for seconds, then animate again (0 = always animate)")
+	fs.Float64Var(&cl.config.FreezeFor, "freeze-for", cl.config.FreezeFor, "`seconds` the frame is held per -freeze-after cycle")
+	fs.Float64Var(&cl.config.FixedStep, "fixed-step", cl.config.FixedStep, "advance iTime and iFrame in fixed steps of `seconds` (e.g. 0.016667), independent of frame rate (0 = per frame)")
+	fs.BoolVar(&cl.config.SnapTime, "snap-time", cl.config.SnapTime, "advance iTime in whole display refresh periods against micro-stutter (needs vsync; not for variable refresh rate displays)")
+	fs.BoolVar(&cl.config.VSync, "vsync", cl.config.VSync, "synchronize with display refresh")
+	fs.StringVar(&cl.dumpFormat, "format", SHADER_FORMAT_NONE, "format of -dump-shader output (pretty or min) or -print-config output (json)")
+
+	screensaverArgs, flagArgs := splitArgs(fs, args)
+	if err := fs.Parse(flagArgs); err != nil {
+		return nil, err
+	}
+	cl.screensaverArgs = append(screensaverArgs, fs.Args()...)
+
+	if *noMinify {
+		cl.config.MinifyShader = false
+	}
+	if cl.benchFixDir != "" {
+		cl.benchFix = true
+	}
+	if err := validateWatermarkPosition(cl.config.WatermarkPosition); err != nil {
+		return nil, err
+	}
+	if err := validateFragCoordMode(cl.config.FragCoordMode); err != nil {
+		return nil, err
+	}
+	if err := validateDitherMode(cl.config.Dither); err != nil {
+		return nil, err
+	}
+	if err := validatePalette(cl.config.Palette); err != nil {
+		return nil, err
+	}
+	if cl.config.Vignette < 0 || cl.config.Vignette > VIGNETTE_MAX || cl.config.VignetteRadius < 0 || cl.config.VignetteRadius > VIGNETTE_RADIUS_MAX {
+		return nil, fmt.Errorf("invalid -vignette %g / -vignette-radius %g (expected 0-%g and 0-%g)", cl.config.Vignette, cl.config.VignetteRadius, VIGNETTE_MAX, VIGNETTE_RADIUS_MAX)
+	}
+	if cl.config.Speed < SPEED_MIN || cl.config.Speed > SPEED_MAX {
+		return nil, fmt.Errorf("invalid -speed %g (expected %g-%g)", cl.config.Speed, SPEED_MIN, SPEED_MAX)
+	}
+	if cl.config.Brightness < BRIGHTNESS_MIN || cl.config.Brightness > BRIGHTNESS_MAX {
+		return nil, fmt.Errorf("invalid -brightness %g (expected %g-%g)", cl.config.Brightness, BRIGHTNESS_MIN, BRIGHTNESS_MAX)
+	}
+	if cl.config.FixedStep != 0 && (cl.config.FixedStep < FIXED_STEP_MIN || cl.config.FixedStep > FIXED_STEP_MAX) {
+		return nil, fmt.Errorf("invalid -fixed-step %g (expected 0 or %g-%g seconds)", cl.config.FixedStep, FIXED_STEP_MIN, FIXED_STEP_MAX)
+	}
+	if cl.config.FreezeAfter < 0 || cl.config.FreezeFor <= 0 {
+		return nil, fmt.Errorf("invalid -freeze-after %g / -freeze-for %g (expected seconds >= 0 and > 0)", cl.config.FreezeAfter, cl.config.FreezeFor)
+	}
+	if cl.config.ShaderRate < 0 || cl.config.ShaderRate > SHADER_RATE_MAX {
+		return nil, fmt.Errorf("invalid -shader-rate %d (expected 0-%d updates per second)", cl.config.ShaderRate, SHADER_RATE_MAX)
+	}
+	if cl.config.StartDelay < 0 || cl.config.StartDelay > START_DELAY_MAX {
+		return nil, fmt.Errorf("invalid -start-delay %d (expected 0-%d ms)", cl.config.StartDelay, START_DELAY_MAX)
+	}
+	if cl.config.SeekStep <= 0 {
+		return nil, fmt.Errorf("invalid -seek-step %g (expected seconds > 0)", cl.config.SeekStep)
+	}
+	if err := validateBannerPosition(cl.config.Banner.Position); err != nil {
+		return nil, err
+	}
+	if !clockColorPattern.MatchString(cl.config.Banner.Color) {
+		return nil, fmt.Errorf("invalid banner color %q (expected #RRGGBB)", cl.config.Banner.Color)
+	}
+	if cl.config.Banner.Opacity < 0 || cl.config.Banner.Opacity > 1 {
+		return nil, fmt.Errorf("banner opacity %g out of range (0-1)", cl.config.Banner.Opacity)
+	}
+	cl.config.Banner.Text = strings.ReplaceAll(cl.config.Banner.Text, `\n`, "\n")
+	fixes, err := parseFixList(*skipFixes)
+	if err != nil {
+		return nil, err
+	}
+	cl.config.SkipFixes = fixes
+	if cl.config.MoveThreshold < MOVE_THRESHOLD_MIN || cl.config.MoveThreshold > MOVE_THRESHOLD_MAX {
+		return nil, fmt.Errorf("move threshold %d out of range (%d-%d)", cl.config.MoveThreshold, MOVE_THRESHOLD_MIN, MOVE_THRESHOLD_MAX)
+	}
+	if cl.config.RenderScale < RENDER_SCALE_MIN || cl.config.RenderScale > RENDER_SCALE_MAX {
+		return nil, fmt.Errorf("render scale %g out of range (%g-%g)", cl.config.RenderScale, RENDER_SCALE_MIN, RENDER_SCALE_MAX)
+	}
+	if _, err := glFilter(cl.config.UpscaleFilter); err != nil {
+		return nil, err
+	}
+	if *renderSize != "" {
+		width, height, err := parseRenderSize(*renderSize)
+		if err != nil {
+			return nil, err
+		}
+		cl.config.RenderWidth = width
+		cl.config.RenderHeight = height
+	}
+	if *date != "" {
+		base, err := parseDate(*date)
+		if err != nil {
+			return nil, err
+		}
+		cl.config.DateBase = base
+	}
+
+	return cl, nil
+}
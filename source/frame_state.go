@@ -0,0 +1,326 @@
+// Per-frame timing shared by the render loops.
+//
+// FrameState holds real time (fades, overlays), shader time (iTime, which
+// excludes paused intervals), frame counters, averaged FPS, the fade factor
+// and the render resolution. Preview and fullscreen loops call advance once
+// per frame, so both derive these values the same way; offscreen rendering
+// drives a FrameState manually (fixedFrameState) for deterministic frames.
+//
+// advance takes the frame time as an argument, so tests can drive it with a
+// fake clock. Go's monotonic clock hides most system clock adjustments, but
+// -wall-clock measures iTime from the Unix epoch, where an NTP step or manual
+// change does show. advance therefore never lets iTime go backward (a backward
+// jump is absorbed into an offset), keeps deltas within 0..FRAME_DELTA_MAX and
+// treats a start in the future as elapsed 0.
+//
+// The fade runs through explicit phases: FADE_PHASE_IN, FADE_PHASE_VISIBLE,
+// FADE_PHASE_OUT and FADE_PHASE_DONE. An exit request in any phase starts
+// fade-out from the brightness at that moment, so exiting during fade-in
+// darkens from the partial level (in proportionally less time) instead of
+// continuing to brighten first.
+//
+// With a fixed timestep (-fixed-step) iTime moves in whole steps and iFrame
+// counts steps instead of displayed frames, so shaders integrating per frame
+// behave the same at 30 and 144 Hz. advance returns the steps taken, and
+// buffer passes (which hold simulation state) render once per step, each
+// with that step's iTime and iFrame (see atStep): none on a frame between
+// steps, several on a slow display, at most FIXED_STEP_RENDERS_MAX after a
+// stall. The image pass still renders once per displayed frame and sees the
+// steps as iTimeDelta.
+//
+// With refresh snapping (-snap-time) iTime advances by whole refresh periods
+// of the display: the measured delta is rounded to the nearest multiple of
+// 1/refresh rate, relative to the previous snapped iTime, so it never drifts
+// more than half a period from the clock. With vsync on a fixed-rate display
+// frames are shown at exact refresh intervals while the measured deltas
+// jitter by a millisecond or two, and snapping removes that jitter from the
+// motion. On variable refresh rate displays (G-Sync, FreeSync) or without
+// vsync frames are shown when they are ready, so the clock is the better
+// guess and snapping adds the stutter it was meant to remove. A fixed
+// timestep takes precedence.
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	FADE_IN_DURATION  = 1.0 // Seconds of fade-in after start
+	FADE_OUT_DURATION = 0.5 // Seconds of fade-out after exit request
+	FPS_UPDATE_PERIOD = time.Second
+	FRAME_DELTA_MAX   = 1.0 // Longest iTimeDelta in seconds (e.g. after system sleep)
+
+	RENDER_TIME_WINDOW = 5 * time.Second // Debug overlay averages render time over this period
+
+	FIXED_STEP_RENDERS_MAX = 8 // Buffer pass renders per frame at most with -fixed-step (the latest steps)
+)
+
+// fadePhase is the stage of fade-in/fade-out
+type fadePhase int
+
+const (
+	FADE_PHASE_IN      fadePhase = iota // Brightening after start
+	FADE_PHASE_VISIBLE                  // Full brightness
+	FADE_PHASE_OUT                      // Darkening after exit request
+	FADE_PHASE_DONE                     // Faded out, loop should end
+)
+
+// String returns phase name for logs and test messages
+func (p fadePhase) String() string {
+	switch p {
+	case FADE_PHASE_IN:
+		return "fading in"
+	case FADE_PHASE_VISIBLE:
+		return "visible"
+	case FADE_PHASE_OUT:
+		return "fading out"
+	default:
+		return "done"
+	}
+}
+
+// FrameState is the timing state of the current frame
+type FrameState struct {
+	start       time.Time // Real start (fades, overlays)
+	shaderStart time.Time // iTime origin (see Config.shaderTimeOrigin)
+	last        time.Time // Start of current frame
+	exitStart   time.Time // Exit requested (zero = running)
+
+	elapsed   float64 // Real seconds since start
+	deltaTime float64 // Real seconds since previous frame
+
+	shaderElapsed float64 // iTime before wrapping, paused intervals excluded, seek offset included
+	shaderClock   float64 // shaderElapsed without seek offset (never goes back)
+	shaderOffset  float64 // Seconds added to iTime after backward clock jumps
+	shaderDelta   float64 // iTimeDelta (0 while paused)
+	frame         int     // iFrame (doesn't advance while paused)
+	fixedStep     float64 // iTime advances in whole steps of this many seconds, iFrame once per step (0 = per frame)
+	snapPeriod    float64 // iTime advances in whole display refresh periods of this many seconds (0 = off)
+
+	fps       float64 // Frames per second averaged over last second (iFrameRate, 0 = not measured yet)
+	fpsFrames int
+	fpsUpdate time.Time
+
+	fade     float32   // iFade: fade-in after start, fade-out after exit request
+	phase    fadePhase // Fade stage of current frame
+	exitFade float32   // Fade level when exit was requested (fade-out starts from it)
+	noFade   bool      // iFade stays 1.0 and exit doesn't wait for fade-out (-no-fade)
+
+	width  int // Render resolution (iResolution)
+	height int
+}
+
+// renderTimeEntry is the render time of one frame
+type renderTimeEntry struct {
+	time  time.Time
+	delta float64 // Seconds
+}
+
+// renderTimes holds render times of the last RENDER_TIME_WINDOW in a ring buffer with a
+// running sum, so adding and averaging stay O(1) (amortized) even at 240 fps
+type renderTimes struct {
+	entries []renderTimeEntry
+	head    int // Oldest entry
+	count   int
+	sum     float64
+}
+
+// add records delta for frame at time at and drops entries outside the window before it
+// (the newest entry is always kept)
+func (r *renderTimes) add(at time.Time, delta float64) {
+	if r.count == len(r.entries) {
+		// Grow, oldest entry first
+		grown := make([]renderTimeEntry, max(2*len(r.entries), 64))
+		for i := 0; i < r.count; i++ {
+			grown[i] = r.entries[(r.head+i)%len(r.entries)]
+		}
+		r.entries, r.head = grown, 0
+	}
+	r.entries[(r.head+r.count)%len(r.entries)] = renderTimeEntry{at, delta}
+	r.count++
+	r.sum += delta
+
+	cutoff := at.Add(-RENDER_TIME_WINDOW)
+	for r.count > 1 && !r.entries[r.head].time.After(cutoff) {
+		r.sum -= r.entries[r.head].delta
+		r.head = (r.head + 1) % len(r.entries)
+		r.count--
+	}
+	if r.count == 1 {
+		// Restart running sum so rounding errors don't accumulate
+		r.sum = delta
+	}
+}
+
+// average returns mean render time in seconds (0 without entries)
+func (r *renderTimes) average() float64 {
+	if r.count == 0 {
+		return 0
+	}
+	return r.sum / float64(r.count)
+}
+
+// refreshPeriod returns the refresh period in seconds of a display refreshing
+// rate times per second, for FrameState.snapPeriod (0 = unknown rate, no snapping)
+func refreshPeriod(rate int) float64 {
+	if rate <= 0 {
+		return 0
+	}
+	return 1.0 / float64(rate)
+}
+
+// begin starts timing at start; iTime counts from shaderStart. A pending exit request is kept.
+func (s *FrameState) begin(start, shaderStart time.Time) {
+	s.start = start
+	s.shaderStart = shaderStart
+	s.last = start
+	s.fpsUpdate = start
+}
+
+// fixedFrameState returns state of frame (1-based) at frame * timeStep seconds, for offscreen rendering
+func fixedFrameState(frame int, timeStep float64, width, height int) FrameState {
+	return FrameState{
+		shaderElapsed: float64(frame) * timeStep,
+		shaderDelta:   timeStep,
+		frame:         frame,
+		fps:           1.0 / timeStep,
+		fade:          1.0,
+		width:         width,
+		height:        height,
+	}
+}
+
+// advance updates state for frame starting at now (pause may be nil: no pausing).
+// Returns how many times buffer passes render this frame: the fixed steps taken
+// (0 while paused), or 1 without a fixed timestep.
+func (s *FrameState) advance(now time.Time, pause *pauseState) int {
+	s.deltaTime = clampFloat(now.Sub(s.last).Seconds(), 0, FRAME_DELTA_MAX)
+	s.last = now
+	s.elapsed = max(now.Sub(s.start).Seconds(), 0)
+
+	// FPS averaged over FPS_UPDATE_PERIOD (restarted if clock went back)
+	s.fpsFrames++
+	if period := now.Sub(s.fpsUpdate); period < 0 {
+		s.fpsFrames = 0
+		s.fpsUpdate = now
+	} else if period >= FPS_UPDATE_PERIOD {
+		s.fps = float64(s.fpsFrames) / period.Seconds()
+		s.fpsFrames = 0
+		s.fpsUpdate = now
+	}
+
+	// Shader time excludes paused intervals; fades and overlays use real time
+	shaderClock := now.Sub(s.shaderStart).Seconds() + s.shaderOffset
+	if pause != nil {
+		shaderClock -= pause.pausedFor(now).Seconds()
+	}
+	if shaderClock < s.shaderClock {
+		// Clock went back: continue from previous iTime instead of jumping back
+		s.shaderOffset += s.shaderClock - shaderClock
+		shaderClock = s.shaderClock
+	}
+	s.shaderClock = shaderClock
+	previous := s.shaderElapsed
+	s.shaderElapsed = shaderClock
+	if pause != nil {
+		// Seeking back stops at iTime 0
+		pause.seek = max(pause.seek, -shaderClock)
+		s.shaderElapsed += pause.seek
+	}
+	s.shaderDelta = s.deltaTime
+	steps := 1
+	if s.fixedStep > 0 {
+		// Game loop style: whole steps reached since previous frame (none on fast displays,
+		// several on slow ones); the first frame is one step whatever iTime starts at
+		s.shaderElapsed = math.Floor(s.shaderElapsed/s.fixedStep) * s.fixedStep
+		if s.frame > 0 {
+			steps = max(int(math.Round((s.shaderElapsed-previous)/s.fixedStep)), 0)
+		}
+		s.shaderDelta = float64(steps) * s.fixedStep
+	} else if s.snapPeriod > 0 && s.frame > 0 {
+		periods := math.Round((s.shaderElapsed - previous) / s.snapPeriod)
+		s.shaderElapsed = max(previous+periods*s.snapPeriod, 0)
+		s.shaderDelta = clampFloat(s.shaderElapsed-previous, 0, FRAME_DELTA_MAX)
+	}
+	paused := pause != nil && pause.paused
+	if paused {
+		s.shaderDelta = 0
+	} else {
+		s.frame += steps
+	}
+
+	s.phase, s.fade = s.fadeAt(now)
+	if s.fixedStep <= 0 {
+		return 1
+	}
+	if paused {
+		return 0
+	}
+	return steps
+}
+
+// atStep returns the state of step (0 = first) of the steps the last advance took:
+// iTime and iFrame of that step and a one step iTimeDelta. Without a fixed timestep
+// the frame is its only step and s is returned.
+func (s *FrameState) atStep(step, steps int) *FrameState {
+	if s.fixedStep <= 0 {
+		return s
+	}
+	state := *s
+	later := steps - 1 - step
+	state.shaderElapsed -= float64(later) * s.fixedStep
+	state.frame -= later
+	state.shaderDelta = s.fixedStep
+	return &state
+}
+
+// fadeInAt returns fade-in level at now, ignoring exit requests
+func (s *FrameState) fadeInAt(now time.Time) (fadePhase, float32) {
+	elapsed := max(now.Sub(s.start).Seconds(), 0)
+	if s.noFade || elapsed >= FADE_IN_DURATION {
+		return FADE_PHASE_VISIBLE, 1.0
+	}
+	return FADE_PHASE_IN, float32(elapsed / FADE_IN_DURATION)
+}
+
+// fadeAt returns fade phase and factor at now
+func (s *FrameState) fadeAt(now time.Time) (fadePhase, float32) {
+	if s.exitStart.IsZero() {
+		return s.fadeInAt(now)
+	}
+	if s.noFade {
+		return FADE_PHASE_DONE, 1.0
+	}
+	// Fade-out takes FADE_OUT_DURATION from full brightness, less from a partial fade-in.
+	// Exit may be requested by an input callback after the frame started.
+	duration := FADE_OUT_DURATION * float64(s.exitFade)
+	exitElapsed := max(now.Sub(s.exitStart).Seconds(), 0)
+	if exitElapsed >= duration {
+		return FADE_PHASE_DONE, 0.0
+	}
+	return FADE_PHASE_OUT, s.exitFade * float32(1.0-exitElapsed/duration)
+}
+
+// requestExit starts fade-out at now from the current fade level (later requests keep the first)
+func (s *FrameState) requestExit(now time.Time) {
+	if !s.exitStart.IsZero() {
+		return
+	}
+	_, s.exitFade = s.fadeInAt(now)
+	s.exitStart = now
+	if s.noFade {
+		s.phase = FADE_PHASE_DONE
+	}
+}
+
+// exiting reports whether exit was requested
+func (s *FrameState) exiting() bool {
+	return !s.exitStart.IsZero()
+}
+
+// fadeOutDone reports whether fade-out finished by start of current frame
+// (with noFade, as soon as exit is requested)
+func (s *FrameState) fadeOutDone() bool {
+	return s.phase == FADE_PHASE_DONE
+}
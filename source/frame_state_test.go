@@ -0,0 +1,307 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// clockStart is the fake clock start of frame timing tests
+var clockStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// clockAt returns the fake clock time seconds after clockStart
+func clockAt(seconds float64) time.Time {
+	return clockStart.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+// expectNear reports name if got differs from want by more than 1e-6
+func expectNear(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-6 {
+		t.Errorf("%s = %g, expected %g", name, got, want)
+	}
+}
+
+// TestFrameState drives frame timing on a fake clock: fade-in, pause (iTime and iFrame stop), fade-out
+func TestFrameState(t *testing.T) {
+	var state FrameState
+	var pause pauseState
+	state.begin(clockStart, clockStart)
+	state.advance(clockAt(0.5), &pause)
+	expectNear(t, "first frame elapsed", state.elapsed, 0.5)
+	expectNear(t, "first frame fade-in", float64(state.fade), 0.5)
+	expectNear(t, "first frame deltaTime", state.deltaTime, 0.5)
+	if state.frame != 1 {
+		t.Errorf("first frame: frame %d", state.frame)
+	}
+
+	pause.toggle(clockAt(1.0))
+	state.advance(clockAt(1.5), &pause)
+	expectNear(t, "paused iTime", state.shaderElapsed, 1.0)
+	expectNear(t, "paused iTimeDelta", state.shaderDelta, 0)
+	expectNear(t, "paused fps", state.fps, 2/1.5)
+	if state.frame != 1 {
+		t.Errorf("paused frame: frame %d", state.frame)
+	}
+	pause.toggle(clockAt(2.0))
+	state.advance(clockAt(2.25), &pause)
+	expectNear(t, "resumed iTime", state.shaderElapsed, 1.25)
+	if state.frame != 2 {
+		t.Errorf("resumed frame: frame %d", state.frame)
+	}
+
+	state.requestExit(clockAt(3.0))
+	state.advance(clockAt(3.25), &pause)
+	expectNear(t, "fade-out", float64(state.fade), 0.5)
+	if state.fadeOutDone() {
+		t.Errorf("fade-out done after %gs", 0.25)
+	}
+	state.advance(clockAt(3.5), &pause)
+	if !state.fadeOutDone() || state.fade != 0 {
+		t.Errorf("fade-out not finished after %gs (fade %g)", FADE_OUT_DURATION, state.fade)
+	}
+
+	// -no-fade: full brightness from the first frame, exit right after the request
+	state = FrameState{noFade: true}
+	state.begin(clockStart, clockStart)
+	state.advance(clockAt(0.1), nil)
+	if state.fade != 1 || state.fadeOutDone() {
+		t.Errorf("no-fade: fade %g, done %v without exit request", state.fade, state.fadeOutDone())
+	}
+	state.requestExit(clockAt(0.15))
+	if !state.fadeOutDone() {
+		t.Errorf("no-fade: exit waits for fade-out")
+	}
+}
+
+// TestClockJumps drives frame timing with a fake clock that jumps back and forward:
+// iTime must never decrease and iTimeDelta must stay within 0..FRAME_DELTA_MAX
+func TestClockJumps(t *testing.T) {
+	// Start in the future (clock stepped back right after start)
+	var state FrameState
+	state.begin(clockAt(10), clockAt(10))
+	state.advance(clockAt(0), nil)
+	if state.elapsed != 0 || state.fade != 0 || state.shaderElapsed != 0 || state.deltaTime != 0 {
+		t.Errorf("start in the future: elapsed %g, fade %g, iTime %g, delta %g",
+			state.elapsed, state.fade, state.shaderElapsed, state.deltaTime)
+	}
+
+	state = FrameState{}
+	state.begin(clockStart, clockStart)
+	var previous float64
+	for i, seconds := range []float64{1.0, 2.0, 0.5, 0.75, 3600, 3601, 1.0} {
+		state.advance(clockAt(seconds), nil)
+		if state.shaderElapsed < previous {
+			t.Errorf("step %d (%gs): iTime went back from %g to %g", i, seconds, previous, state.shaderElapsed)
+		}
+		if state.shaderDelta < 0 || state.shaderDelta > FRAME_DELTA_MAX {
+			t.Errorf("step %d (%gs): iTimeDelta %g", i, seconds, state.shaderDelta)
+		}
+		previous = state.shaderElapsed
+	}
+	// After the jump back to 0.5s, time continues from 2.0s
+	state = FrameState{}
+	state.begin(clockStart, clockStart)
+	for _, seconds := range []float64{2.0, 0.5, 0.75} {
+		state.advance(clockAt(seconds), nil)
+	}
+	expectNear(t, "iTime after backward jump", state.shaderElapsed, 2.25)
+}
+
+// TestFadePhases requests exit during fade-in, at its end and later at 60 fps on a fake clock:
+// phases must advance in order, and fade-out must start from the current level and only darken
+func TestFadePhases(t *testing.T) {
+	const step = 1.0 / 60
+
+	for _, exitAt := range []float64{0.2, 1.0, 5.0} {
+		var state FrameState
+		state.begin(clockStart, clockStart)
+		phase, fade := FADE_PHASE_IN, float32(0)
+		exitFade := float32(math.Min(exitAt/FADE_IN_DURATION, 1))
+		for frame := 1; !state.fadeOutDone(); frame++ {
+			seconds := float64(frame) * step
+			if seconds > exitAt+FADE_OUT_DURATION+step {
+				t.Fatalf("exit at %gs: still %v at %.3fs", exitAt, state.phase, seconds)
+			}
+			if seconds >= exitAt {
+				state.requestExit(clockAt(exitAt))
+			}
+			state.advance(clockAt(seconds), nil)
+
+			if state.phase < phase {
+				t.Errorf("exit at %gs: phase went from %v back to %v at %.3fs", exitAt, phase, state.phase, seconds)
+			}
+			switch state.phase {
+			case FADE_PHASE_IN, FADE_PHASE_VISIBLE:
+				if state.fade < fade {
+					t.Errorf("exit at %gs: fade dropped to %g while %v", exitAt, state.fade, state.phase)
+				}
+			case FADE_PHASE_OUT:
+				if state.fade > exitFade+1e-6 || (phase == FADE_PHASE_OUT && state.fade > fade) {
+					t.Errorf("exit at %gs: fade-out brightened to %g (exit level %g)", exitAt, state.fade, exitFade)
+				}
+			case FADE_PHASE_DONE:
+				if state.fade != 0 {
+					t.Errorf("exit at %gs: done with fade %g", exitAt, state.fade)
+				}
+			}
+			phase, fade = state.phase, state.fade
+		}
+	}
+}
+
+// TestRenderTimes compares the render time ring buffer with averaging a plain slice
+// over the same window, at 240 fps with a stall in between
+func TestRenderTimes(t *testing.T) {
+	var ring renderTimes
+	var plain []renderTimeEntry
+	now := clockStart
+	for frame := 0; frame < 5000; frame++ {
+		step := time.Second / 240
+		if frame == 2000 {
+			step = 6 * time.Second
+		}
+		now = now.Add(step)
+		delta := float64(frame%7+1) / 1000
+		ring.add(now, delta)
+
+		plain = append(plain, renderTimeEntry{now, delta})
+		for len(plain) > 1 && !plain[0].time.After(now.Add(-RENDER_TIME_WINDOW)) {
+			plain = plain[1:]
+		}
+		sum := 0.0
+		for _, entry := range plain {
+			sum += entry.delta
+		}
+		if want := sum / float64(len(plain)); ring.count != len(plain) || math.Abs(ring.average()-want) > 1e-9 {
+			t.Fatalf("frame %d: %d entries, average %g; expected %d, %g", frame, ring.count, ring.average(), len(plain), want)
+		}
+	}
+}
+
+// TestSeek checks [ and ] seeking: iTime moves while paused, stops at 0 and keeps
+// running from the new position
+func TestSeek(t *testing.T) {
+	var state FrameState
+	var pause pauseState
+	state.begin(clockStart, clockStart)
+	state.advance(clockAt(2), &pause)
+	pause.toggle(clockAt(2))
+	pause.seekKey(glfw.KeyRightBracket, 0, 0.1)
+	pause.seekKey(glfw.KeyRightBracket, glfw.ModShift, 0.1)
+	state.advance(clockAt(3), &pause)
+	expectNear(t, "iTime paused, +1.1 s", state.shaderElapsed, 3.1)
+	for i := 0; i < 5; i++ {
+		pause.seekKey(glfw.KeyLeftBracket, glfw.ModShift, 0.1)
+	}
+	state.advance(clockAt(4), &pause)
+	expectNear(t, "iTime seeking before start", state.shaderElapsed, 0)
+	pause.seekKey(glfw.KeyRightBracket, 0, 0.5)
+	pause.toggle(clockAt(4))
+	state.advance(clockAt(5), &pause)
+	expectNear(t, "iTime resumed after seek", state.shaderElapsed, 1.5)
+	if pause.seekKey(glfw.KeySpace, 0, 0.1) {
+		t.Errorf("space handled as seek key")
+	}
+}
+
+// TestFixedStep checks -fixed-step: a second at 144 Hz and at 30 Hz gives the same
+// iFrame and whole-step iTime
+func TestFixedStep(t *testing.T) {
+	const step = 1.0 / 60
+	for _, rate := range []float64{144, 30} {
+		var state FrameState
+		state.fixedStep = step
+		state.begin(clockStart, clockStart)
+		for i := 1; i <= int(rate); i++ {
+			state.advance(clockStart.Add(time.Duration(float64(i)/rate*float64(time.Second))), nil)
+			if steps := state.shaderDelta / step; math.Abs(steps-math.Round(steps)) > 1e-6 {
+				t.Fatalf("%g Hz frame %d: iTimeDelta %g is not whole steps", rate, i, state.shaderDelta)
+			}
+		}
+		if state.frame < 59 || state.frame > 61 || math.Abs(state.shaderElapsed-1) > step {
+			t.Fatalf("%g Hz after 1 s: iFrame %d, iTime %g (expected about 60 steps)", rate, state.frame, state.shaderElapsed)
+		}
+	}
+}
+
+// TestFixedStepRenders checks the buffer pass renders advance reports with -fixed-step
+// and the per-step state atStep gives them
+func TestFixedStepRenders(t *testing.T) {
+	const step = 1.0 / 60
+	var state FrameState
+	state.fixedStep = step
+	state.begin(clockStart, clockStart)
+	if steps := state.advance(clockAt(0), nil); steps != 1 {
+		t.Fatalf("first frame: %d steps, want 1", steps)
+	}
+	// 30 Hz: two steps per frame, the first one a step earlier
+	steps := state.advance(clockAt(2*step+1e-6), nil)
+	if steps != 2 {
+		t.Fatalf("30 Hz frame: %d steps, want 2", steps)
+	}
+	first := state.atStep(0, steps)
+	if first.frame != state.frame-1 || first.shaderDelta != step {
+		t.Errorf("first step: iFrame %d, iTimeDelta %g (frame iFrame %d)", first.frame, first.shaderDelta, state.frame)
+	}
+	expectNear(t, "first step iTime", first.shaderElapsed, state.shaderElapsed-step)
+	if last := state.atStep(1, steps); last.frame != state.frame || last.shaderElapsed != state.shaderElapsed {
+		t.Errorf("last step: iFrame %d, iTime %g, want the frame's %d, %g", last.frame, last.shaderElapsed, state.frame, state.shaderElapsed)
+	}
+	// 144 Hz: no step between steps
+	if steps := state.advance(clockAt(2*step+0.007), nil); steps != 0 {
+		t.Errorf("frame between steps: %d steps, want 0", steps)
+	}
+	pause := pauseState{paused: true, pausedAt: clockAt(0.1)}
+	if steps := state.advance(clockAt(0.2), &pause); steps != 0 {
+		t.Errorf("paused: %d steps, want 0", steps)
+	}
+
+	var variable FrameState
+	variable.begin(clockStart, clockStart)
+	for _, seconds := range []float64{0, 0.001, 0.5} {
+		if steps := variable.advance(clockAt(seconds), nil); steps != 1 {
+			t.Errorf("without fixed step at %g s: %d renders, want 1", seconds, steps)
+		}
+		if variable.atStep(0, 1) != &variable {
+			t.Errorf("without fixed step atStep is not the frame state")
+		}
+	}
+}
+
+// TestSnapTime checks that -snap-time turns jittery frame times on a 60 Hz display
+// into whole refresh periods without drifting from the clock
+func TestSnapTime(t *testing.T) {
+	period := refreshPeriod(60)
+	jitter := []float64{0, 0.002, -0.003, 0.001, -0.001, 0.003, -0.002}
+	for _, every := range []int{1, 2} { // 60 fps, and 30 fps on the same display
+		var state FrameState
+		state.snapPeriod = period
+		state.begin(clockStart, clockStart)
+		for i := 1; i <= 120; i++ {
+			clock := float64(i*every)*period + jitter[i%len(jitter)]
+			if i >= 60 {
+				clock += period // Frame 60 dropped
+			}
+			state.advance(clockStart.Add(time.Duration(clock*float64(time.Second))), nil)
+			if diff := state.shaderElapsed - clock; diff > period/2+1e-9 || diff < -period/2-1e-9 {
+				t.Fatalf("every %d: frame %d iTime %g is %g s off the clock", every, i, state.shaderElapsed, diff)
+			}
+			if i == 1 {
+				continue // Starts at the clock
+			}
+			want := float64(every) * period
+			if i == 60 {
+				want += period
+			}
+			if math.Abs(state.shaderDelta-want) > 1e-9 {
+				t.Fatalf("every %d: frame %d iTimeDelta %g, expected %g", every, i, state.shaderDelta, want)
+			}
+		}
+	}
+	if refreshPeriod(0) != 0 {
+		t.Fatalf("unknown refresh rate snaps")
+	}
+}
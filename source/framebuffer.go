@@ -0,0 +1,292 @@
+// Offscreen render target for fixed internal resolution (`-render-size WxH`)
+// and relative render scale (`-render-scale 0.5`).
+//
+// The shader renders into an FBO of exactly the requested size (iResolution
+// reports that size), then the image is blitted to the window framebuffer,
+// scaled to fit. Render scale sizes the FBO relative to the window framebuffer
+// and follows its size; a fixed render size takes precedence over it. With
+// letterboxing the aspect ratio is kept and the rest of the screen stays
+// black; without it the image is stretched. Upscale filtering is linear by
+// default; `-filter nearest` keeps pixel-art shaders crisp.
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// MAX_RENDER_SIZE limits -render-size to something all GL 3.3 drivers support
+const MAX_RENDER_SIZE = 8192
+
+// Accepted -render-scale range (above 1.0 = supersampling)
+const (
+	RENDER_SCALE_MIN = 0.25
+	RENDER_SCALE_MAX = 2.0
+)
+
+const (
+	FILTER_LINEAR  = "linear"
+	FILTER_NEAREST = "nearest"
+)
+
+// renderTarget is an FBO with a color texture, or several for buffer passes
+// writing multiple render targets
+type renderTarget struct {
+	fbo         uint32
+	texture     uint32   // COLOR_ATTACHMENT0, the one blitted and read back
+	attachments []uint32 // COLOR_ATTACHMENT1 and up (MRT, see shader_outputs.go)
+	width       int
+	height      int
+	filter      int32 // gl.LINEAR or gl.NEAREST, used for texture sampling and blit
+	format      int32 // Internal format of the textures, gl.RGBA8 or gl.RGBA32F (buffer passes)
+}
+
+// glFilter converts filter name to GL constant
+func glFilter(name string) (int32, error) {
+	switch name {
+	case FILTER_LINEAR:
+		return gl.LINEAR, nil
+	case FILTER_NEAREST:
+		return gl.NEAREST, nil
+	default:
+		return 0, fmt.Errorf("unknown filter %q (expected linear or nearest)", name)
+	}
+}
+
+// parseRenderSize parses "WxH" (e.g. "1920x1080")
+func parseRenderSize(s string) (int, int, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "x")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid render size %q (expected WxH, e.g. 1920x1080)", s)
+	}
+	width, errW := strconv.Atoi(parts[0])
+	height, errH := strconv.Atoi(parts[1])
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid render size %q (expected WxH, e.g. 1920x1080)", s)
+	}
+	if width > MAX_RENDER_SIZE || height > MAX_RENDER_SIZE {
+		return 0, 0, fmt.Errorf("render size %q too large (max %dx%d)", s, MAX_RENDER_SIZE, MAX_RENDER_SIZE)
+	}
+	return width, height, nil
+}
+
+// newRenderTarget creates FBO with RGBA8 color texture of given size
+// filter is gl.LINEAR or gl.NEAREST
+func newRenderTarget(width, height int, filter int32) (*renderTarget, error) {
+	return newRenderTargetFormat(width, height, filter, gl.RGBA8)
+}
+
+// newRenderTargetFormat is newRenderTarget with a color texture of internal format
+func newRenderTargetFormat(width, height int, filter int32, format int32) (*renderTarget, error) {
+	return newRenderTargetAttachments(width, height, filter, format, 1)
+}
+
+// newRenderTargetAttachments is newRenderTargetFormat with count color textures,
+// all drawn to (fragment output location N writes COLOR_ATTACHMENT0+N)
+func newRenderTargetAttachments(width, height int, filter int32, format int32, count int) (*renderTarget, error) {
+	rt := &renderTarget{width: width, height: height, filter: filter, format: format}
+	textures := make([]uint32, count)
+	gl.GenTextures(int32(count), &textures[0])
+	rt.texture, rt.attachments = textures[0], textures[1:]
+	for _, texture := range textures {
+		gl.BindTexture(gl.TEXTURE_2D, texture)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, filter)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
+		rt.allocate()
+	}
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	checkGLError("render target TexImage2D")
+
+	gl.GenFramebuffers(1, &rt.fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, rt.fbo)
+	drawBuffers := make([]uint32, count)
+	for i, texture := range textures {
+		drawBuffers[i] = gl.COLOR_ATTACHMENT0 + uint32(i)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, drawBuffers[i], gl.TEXTURE_2D, texture, 0)
+	}
+	if count > 1 {
+		// Draw buffers are FBO state: set once, kept across binds
+		gl.DrawBuffers(int32(count), &drawBuffers[0])
+	}
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		rt.delete()
+		return nil, fmt.Errorf("framebuffer %dx%d incomplete (status 0x%x)", width, height, status)
+	}
+	return rt, nil
+}
+
+// DEGENERATE_SIZE_WAIT is how long render loops wait for events while the
+// framebuffer has no area, in seconds
+const DEGENERATE_SIZE_WAIT = 0.05
+
+// drawableSize reports whether a framebuffer of width x height can be drawn to. During
+// fullscreen/windowed transitions and display mode switches (and while minimized) one
+// dimension can be 0; loops skip such frames instead of feeding Inf aspect to the shader.
+func drawableSize(width, height int) bool {
+	return width > 0 && height > 0
+}
+
+// scaledSize returns render size for framebuffer size and relative scale (at least 1x1)
+func scaledSize(fbWidth, fbHeight int, scale float64) (int, int) {
+	width := int(float64(fbWidth)*scale + 0.5)
+	height := int(float64(fbHeight)*scale + 0.5)
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return width, height
+}
+
+// resize reallocates color textures for new size (FBO attachments are kept)
+func (rt *renderTarget) resize(width, height int) {
+	if width == rt.width && height == rt.height {
+		return
+	}
+	rt.width = width
+	rt.height = height
+	for _, texture := range append([]uint32{rt.texture}, rt.attachments...) {
+		gl.BindTexture(gl.TEXTURE_2D, texture)
+		rt.allocate()
+	}
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	checkGLError("render target resize")
+	if DEBUG_MODE {
+		log.Printf("Render target resized to %dx%d", width, height)
+	}
+}
+
+// allocate (re)creates storage of the bound color texture for the target size
+func (rt *renderTarget) allocate() {
+	pixelType := uint32(gl.UNSIGNED_BYTE)
+	if rt.format != gl.RGBA8 {
+		pixelType = gl.FLOAT
+	}
+	gl.TexImage2D(gl.TEXTURE_2D, 0, rt.format, int32(rt.width), int32(rt.height), 0, gl.RGBA, pixelType, nil)
+}
+
+// bind makes target current for drawing and sets viewport to its size
+func (rt *renderTarget) bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, rt.fbo)
+	gl.Viewport(0, 0, int32(rt.width), int32(rt.height))
+}
+
+// fitRect returns destination rectangle (x0, y0, x1, y1) for drawing
+// srcW x srcH image into dstW x dstH screen
+func fitRect(srcW, srcH, dstW, dstH int, letterbox bool) (int, int, int, int) {
+	if !letterbox || srcW <= 0 || srcH <= 0 {
+		return 0, 0, dstW, dstH
+	}
+	// Scale to fit the limiting dimension, center the other one
+	w, h := dstW, srcH*dstW/srcW
+	if h > dstH {
+		w, h = srcW*dstH/srcH, dstH
+	}
+	x0 := (dstW - w) / 2
+	y0 := (dstH - h) / 2
+	return x0, y0, x0 + w, y0 + h
+}
+
+// blitToScreen scales target contents to window framebuffer (black bars when letterboxing).
+// Leaves window framebuffer bound with full-screen viewport for overlays.
+func (rt *renderTarget) blitToScreen(screenWidth, screenHeight int, letterbox bool) {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(screenWidth), int32(screenHeight))
+	gl.ClearColor(0.0, 0.0, 0.0, 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+
+	x0, y0, x1, y1 := fitRect(rt.width, rt.height, screenWidth, screenHeight, letterbox)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, rt.fbo)
+	gl.BlitFramebuffer(0, 0, int32(rt.width), int32(rt.height),
+		int32(x0), int32(y0), int32(x1), int32(y1), gl.COLOR_BUFFER_BIT, uint32(rt.filter))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	checkGLError("BlitFramebuffer")
+}
+
+// mapMouse converts iMouse value from window framebuffer pixels to target pixels.
+// Signs of .zw (button released) are kept.
+func (rt *renderTarget) mapMouse(mouse [4]float32, screenWidth, screenHeight int, letterbox bool) [4]float32 {
+	x0, y0, x1, y1 := fitRect(rt.width, rt.height, screenWidth, screenHeight, letterbox)
+	if x1 <= x0 || y1 <= y0 {
+		return mouse
+	}
+	scaleX := float32(rt.width) / float32(x1-x0)
+	scaleY := float32(rt.height) / float32(y1-y0)
+	mapPoint := func(x, y float32) (float32, float32) {
+		return (x - float32(x0)) * scaleX, (y - float32(y0)) * scaleY
+	}
+
+	result := mouse
+	result[0], result[1] = mapPoint(mouse[0], mouse[1])
+	if mouse[2] != noMouse[2] || mouse[3] != noMouse[3] {
+		zx, zy := mouse[2], mouse[3]
+		released := zx < 0
+		if released {
+			zx, zy = -zx, -zy
+		}
+		result[2], result[3] = mapPoint(zx, zy)
+		if released {
+			result[2], result[3] = -result[2], -result[3]
+		}
+	}
+	return result
+}
+
+// delete releases GL objects of the target
+func (rt *renderTarget) delete() {
+	if rt.fbo != 0 {
+		gl.DeleteFramebuffers(1, &rt.fbo)
+	}
+	if rt.texture != 0 {
+		gl.DeleteTextures(1, &rt.texture)
+	}
+	if len(rt.attachments) > 0 {
+		gl.DeleteTextures(int32(len(rt.attachments)), &rt.attachments[0])
+	}
+}
+
+// colorTexture returns the texture of color attachment index (0 = rt.texture)
+func (rt *renderTarget) colorTexture(index int) uint32 {
+	if index == 0 {
+		return rt.texture
+	}
+	return rt.attachments[index-1]
+}
+
+// setupRenderTarget creates render target for fixed render size or render scale
+// (fbWidth x fbHeight is current window framebuffer size).
+// Returns nil when neither is enabled or FBO creation failed
+// (then shader renders directly at screen resolution).
+func setupRenderTarget(cfg *Config, fbWidth, fbHeight int) *renderTarget {
+	if !cfg.usesRenderTarget() {
+		return nil
+	}
+	width, height := cfg.RenderWidth, cfg.RenderHeight
+	if !cfg.hasRenderSize() {
+		width, height = scaledSize(fbWidth, fbHeight, cfg.RenderScale)
+	}
+	filter, err := glFilter(cfg.UpscaleFilter)
+	if err != nil {
+		log.Printf("Warning: %v, using linear", err)
+		filter = gl.LINEAR
+	}
+	rt, err := newRenderTarget(width, height, filter)
+	if err != nil {
+		log.Printf("Warning: %v, rendering at screen resolution", err)
+		return nil
+	}
+	if DEBUG_MODE {
+		log.Printf("Rendering at internal resolution %dx%d (letterbox: %v, filter: %s)", rt.width, rt.height, cfg.Letterbox, cfg.UpscaleFilter)
+	}
+	return rt
+}
@@ -0,0 +1,109 @@
+// Frame dump for golden-image regression tests (`-framedump <dir> -frames N`).
+//
+// Renders N frames offscreen at a fixed resolution (`-render-size`, default
+// 640x360) and time step (1/60 s), with fixed iDate and seeded noise, and
+// writes them as frame_0001.png, frame_0002.png, ... Comparing the files
+// against committed references catches changes in shader repair, uniform
+// handling and rendering. Different GPUs/drivers round differently, so
+// comparisons should allow a small per-pixel threshold.
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+const (
+	FRAMEDUMP_WIDTH          = 640
+	FRAMEDUMP_HEIGHT         = 360
+	FRAMEDUMP_DEFAULT_FRAMES = 60
+	FRAMEDUMP_TIME_STEP      = 1.0 / 60.0
+	FRAMEDUMP_NOISE_SEED     = 1
+)
+
+// runFrameDump renders frames offscreen into numbered PNG files in dir
+func runFrameDump(cfg *Config, dir string, frames int) error {
+	if frames <= 0 {
+		return fmt.Errorf("frame count must be positive, got %d", frames)
+	}
+	width, height := FRAMEDUMP_WIDTH, FRAMEDUMP_HEIGHT
+	if cfg.hasRenderSize() {
+		width, height = cfg.RenderWidth, cfg.RenderHeight
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	shaderData, err := loadShader(cfg)
+	if err != nil {
+		return &shaderError{fmt.Errorf("loading shader: %v", err)}
+	}
+	vertexShader, fragmentShader, err := getMainShaderCode(shaderData, cfg)
+	if err != nil {
+		return &shaderError{fmt.Errorf("extracting shader code: %v", err)}
+	}
+
+	// glfw.Terminate is safe to call even if Init failed
+	defer glfw.Terminate()
+	window, err := createOffscreenContext(width, height)
+	if err != nil {
+		return err
+	}
+	defer window.Destroy()
+
+	program, err := buildProgram(vertexShader, fragmentShader)
+	if err != nil {
+		return &shaderError{err}
+	}
+	defer gl.DeleteProgram(program)
+
+	noiseSeed = FRAMEDUMP_NOISE_SEED
+	renderer, err := newFrameRenderer(program, shaderData, cfg, width, height)
+	if err != nil {
+		return err
+	}
+	defer renderer.delete()
+
+	// Readback lags one frame: PNG of frame N-1 is encoded while frame N renders
+	readback := newPixelReadback(width, height)
+	defer readback.delete()
+	written := 0
+	writeFrame := func(pixels []byte) error {
+		written++
+		path := filepath.Join(dir, fmt.Sprintf("frame_%04d.png", written))
+		return writePNG(path, pixelsToImage(pixels, width, height))
+	}
+	for frame := 1; frame <= frames; frame++ {
+		renderer.renderFrame(frame, FRAMEDUMP_TIME_STEP)
+		if err := pendingGLError(); err != nil {
+			return fmt.Errorf("frame %d: %v", frame, err)
+		}
+		if err := readback.readbackFramebuffer(renderer.target.fbo, writeFrame); err != nil {
+			return fmt.Errorf("frame %d: %v", frame, err)
+		}
+	}
+	if err := readback.flush(writeFrame); err != nil {
+		return fmt.Errorf("frame %d: %v", frames, err)
+	}
+	fmt.Printf("Wrote %d frames (%dx%d) to %s\n", frames, width, height, dir)
+	return nil
+}
+
+// writePNG encodes image to file
+func writePNG(path string, img *image.RGBA) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,49 @@
+// Power saving freeze cycle (`-freeze-after`, `-freeze-for`).
+//
+// On always-on displays the shader can run live for FreezeAfter seconds, then
+// hold the last frame for FreezeFor seconds without drawing (the GPU idles,
+// the window keeps showing the last swapped frame), then animate again, and
+// so on. iTime is paused while frozen, so animation resumes where it stopped.
+// The event loop keeps waiting for events while frozen, so input still exits
+// at once (the fade-out starts from the frozen frame), and a window refresh
+// redraws the frozen frame. Not used in interactive mode, where Space pauses.
+package main
+
+import "math"
+
+const (
+	FREEZE_FOR_DEFAULT = 300.0 // Seconds frozen per cycle
+	FREEZE_MAX         = 86400.0
+)
+
+// freezeCycle alternates live and frozen periods from the start of the run
+type freezeCycle struct {
+	live   float64 // Seconds of animation per cycle (0 = never freeze)
+	frozen float64 // Seconds without drawing per cycle
+}
+
+// newFreezeCycle returns cycle configured in cfg
+func newFreezeCycle(cfg *Config) freezeCycle {
+	if cfg.Interactive {
+		return freezeCycle{}
+	}
+	return freezeCycle{cfg.FreezeAfter, cfg.FreezeFor}
+}
+
+// enabled reports whether the run ever freezes
+func (c freezeCycle) enabled() bool {
+	return c.live > 0 && c.frozen > 0
+}
+
+// frozenAt reports whether elapsed seconds after start fall in a frozen period,
+// and seconds until the current period ends
+func (c freezeCycle) frozenAt(elapsed float64) (bool, float64) {
+	if !c.enabled() {
+		return false, math.Inf(1)
+	}
+	phase := math.Mod(max(elapsed, 0), c.live+c.frozen)
+	if phase < c.live {
+		return false, c.live - phase
+	}
+	return true, c.live + c.frozen - phase
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestFreezeCycle checks live/frozen periods of -freeze-after 60 -freeze-for 30
+func TestFreezeCycle(t *testing.T) {
+	cycle := freezeCycle{live: 60, frozen: 30}
+	for _, c := range []struct {
+		elapsed   float64
+		frozen    bool
+		remaining float64
+	}{{0, false, 60}, {59.5, false, 0.5}, {60, true, 30}, {89, true, 1}, {90, false, 60}, {155, true, 25}} {
+		frozen, remaining := cycle.frozenAt(c.elapsed)
+		if frozen != c.frozen || math.Abs(remaining-c.remaining) > 1e-9 {
+			t.Errorf("at %gs: frozen %v for %gs more, expected %v for %gs", c.elapsed, frozen, remaining, c.frozen, c.remaining)
+		}
+	}
+	cfg := defaultConfig()
+	cfg.FreezeAfter = 60
+	cfg.Interactive = true
+	if newFreezeCycle(&cfg).enabled() {
+		t.Errorf("freeze cycle enabled in interactive mode")
+	}
+}
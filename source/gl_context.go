@@ -0,0 +1,78 @@
+// OpenGL context creation hints and post-creation checks.
+//
+// All modes ask for a 3.3 core, forward-compatible context: macOS only
+// exposes GL 3.2+ through that exact profile and otherwise falls back to a
+// legacy 2.1 context, where our #version 330 shaders don't compile. After
+// gl.Init the obtained version and renderer are checked and logged (see
+// checkGLContext in gl_context_darwin.go / gl_context_other.go). With
+// `-software` the driver is asked for a software rasterizer first (see
+// requestSoftwareRendering) and the renderer is always logged, so a black
+// screen can be told apart from a driver bug.
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+const (
+	GL_REQUIRED_MAJOR = 3
+	GL_REQUIRED_MINOR = 3
+)
+
+// softwareRendering is set when -software switched the driver to a software rasterizer
+var softwareRendering bool
+
+// setGLContextHints requests context used by all modes (call before CreateWindow)
+func setGLContextHints() {
+	glfw.WindowHint(glfw.ContextVersionMajor, GL_REQUIRED_MAJOR)
+	glfw.WindowHint(glfw.ContextVersionMinor, GL_REQUIRED_MINOR)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+}
+
+// glContextInfo describes context actually obtained from the driver
+type glContextInfo struct {
+	version  string
+	renderer string
+	vendor   string
+	major    int
+	minor    int
+}
+
+// getGLContextInfo queries current context (call after gl.Init)
+func getGLContextInfo() glContextInfo {
+	info := glContextInfo{
+		version:  gl.GoStr(gl.GetString(gl.VERSION)),
+		renderer: gl.GoStr(gl.GetString(gl.RENDERER)),
+		vendor:   gl.GoStr(gl.GetString(gl.VENDOR)),
+	}
+	// Version string starts with "major.minor", rest is vendor-specific ("4.1 Metal - 88")
+	fmt.Sscanf(info.version, "%d.%d", &info.major, &info.minor)
+	return info
+}
+
+// meetsRequirement reports whether context is at least GL 3.3
+func (info glContextInfo) meetsRequirement() bool {
+	if info.major != GL_REQUIRED_MAJOR {
+		return info.major > GL_REQUIRED_MAJOR
+	}
+	return info.minor >= GL_REQUIRED_MINOR
+}
+
+// logGLContext checks obtained context and logs version with platform-specific warnings
+func logGLContext() {
+	info := getGLContextInfo()
+	if softwareRendering {
+		log.Printf("Software rendering, renderer: %s", info.renderer)
+	} else if DEBUG_MODE {
+		log.Printf("OpenGL %s, renderer: %s, vendor: %s", info.version, info.renderer, info.vendor)
+	}
+	if !info.meetsRequirement() {
+		log.Printf("Warning: OpenGL %d.%d required, driver provided %s", GL_REQUIRED_MAJOR, GL_REQUIRED_MINOR, info.version)
+	}
+	checkGLContext(info)
+}
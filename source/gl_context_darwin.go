@@ -0,0 +1,25 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// checkGLContext logs obtained version on macOS, where OpenGL is deprecated
+// and runs on top of Metal. GLFW 3.3 can't select ANGLE, so the best we can
+// do is point at the usual causes when the context looks wrong.
+func checkGLContext(info glContextInfo) {
+	log.Printf("macOS OpenGL context: %s (%s)", info.version, info.renderer)
+
+	if strings.Contains(info.renderer, "Software") {
+		log.Printf("Warning: OpenGL is running on the software renderer; expect low frame rate. " +
+			"Check that the app is not forced to run under Rosetta and that GPU switching is not disabled.")
+	}
+	if !info.meetsRequirement() {
+		log.Printf("Warning: macOS returned a legacy OpenGL context. " +
+			"Run with -selftest and report its output together with the macOS version and Mac model.")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !darwin
+// +build !darwin
+
+package main
+
+// checkGLContext has no platform-specific checks outside macOS.
+func checkGLContext(info glContextInfo) {}
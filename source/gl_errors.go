@@ -0,0 +1,66 @@
+// GL error checks.
+//
+// GL calls don't return errors; a bad enum or uniform just sets an error flag
+// and rendering silently goes black. checkGLError after key setup calls and
+// draws logs pending errors with the call site in DEBUG_MODE. Each
+// (call site, error) pair is logged once, so a per-frame error doesn't flood
+// the log.
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// loggedGLErrors remembers "tag: error" lines already logged by checkGLError
+var loggedGLErrors = make(map[string]bool)
+
+// glErrorName returns GL error enum name
+func glErrorName(code uint32) string {
+	switch code {
+	case gl.INVALID_ENUM:
+		return "GL_INVALID_ENUM"
+	case gl.INVALID_VALUE:
+		return "GL_INVALID_VALUE"
+	case gl.INVALID_OPERATION:
+		return "GL_INVALID_OPERATION"
+	case gl.INVALID_FRAMEBUFFER_OPERATION:
+		return "GL_INVALID_FRAMEBUFFER_OPERATION"
+	case gl.OUT_OF_MEMORY:
+		return "GL_OUT_OF_MEMORY"
+	case gl.CONTEXT_LOST:
+		return "GL_CONTEXT_LOST"
+	default:
+		return fmt.Sprintf("GL error 0x%x", code)
+	}
+}
+
+// pendingGLError returns first pending GL error (and clears the rest)
+func pendingGLError() error {
+	var first uint32
+	for code := gl.GetError(); code != gl.NO_ERROR; code = gl.GetError() {
+		if first == 0 {
+			first = code
+		}
+	}
+	if first != 0 {
+		return fmt.Errorf("%s (0x%x)", glErrorName(first), first)
+	}
+	return nil
+}
+
+// checkGLError logs pending GL errors with call site tag (DEBUG_MODE only)
+func checkGLError(tag string) {
+	if !DEBUG_MODE {
+		return
+	}
+	for code := gl.GetError(); code != gl.NO_ERROR; code = gl.GetError() {
+		line := fmt.Sprintf("%s: %s (0x%x)", tag, glErrorName(code), code)
+		if !loggedGLErrors[line] {
+			loggedGLErrors[line] = true
+			log.Printf("GL error after %s", line)
+		}
+	}
+}
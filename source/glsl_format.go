@@ -0,0 +1,231 @@
+// GLSL formatting helpers for processed shader code.
+//
+// minifyShaderCode is part of the regular shader pipeline; pretty/min
+// formats are used by `-dump-shader`. `-export-glsl` writes the complete
+// fragment shader as compiled (template, uniforms, main) for external
+// validators such as `glslangValidator`, which need no GPU.
+//
+// Both formatters expect comment-free code (fixShaderCode strips comments
+// first). Preprocessor directives always stay on their own line: joining a
+// `#define` with the following code would change its meaning.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	SHADER_FORMAT_NONE   = ""
+	SHADER_FORMAT_PRETTY = "pretty"
+	SHADER_FORMAT_MIN    = "min"
+)
+
+func isGLSLWordChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isGLSLOperatorChar(c byte) bool {
+	return strings.IndexByte("+-*/%<>=!&|^~?:", c) >= 0
+}
+
+// isPreprocessorLine reports whether line is a preprocessor directive
+func isPreprocessorLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "#")
+}
+
+// minifyGLSLLine collapses whitespace in a single line of code.
+// A space is kept only where removing it would merge tokens:
+// between two identifier/number chars ("float x") or two operator chars ("a - -b").
+func minifyGLSLLine(line string) string {
+	var result strings.Builder
+	pendingSpace := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v' {
+			pendingSpace = result.Len() > 0
+			continue
+		}
+		if pendingSpace {
+			out := result.String()
+			prev := out[len(out)-1]
+			if (isGLSLWordChar(prev) && isGLSLWordChar(c)) || (isGLSLOperatorChar(prev) && isGLSLOperatorChar(c)) {
+				result.WriteByte(' ')
+			}
+			pendingSpace = false
+		}
+		result.WriteByte(c)
+	}
+	return result.String()
+}
+
+// minifyGLSL strips redundant whitespace from shader code.
+// With joinLines, consecutive code lines are joined into one line
+// (preprocessor directives still get their own line); otherwise only
+// whitespace inside lines and blank lines are removed, keeping line structure.
+func minifyGLSL(code string, joinLines bool) string {
+	var result strings.Builder
+	var current strings.Builder // joined code line being built
+
+	flushCurrent := func() {
+		if current.Len() > 0 {
+			result.WriteString(current.String())
+			result.WriteByte('\n')
+			current.Reset()
+		}
+	}
+
+	inDirective := false // inside multi-line directive ("\" continuation)
+	for _, line := range strings.Split(code, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if inDirective || isPreprocessorLine(line) {
+			flushCurrent()
+			trimmed := strings.TrimSpace(line)
+			result.WriteString(trimmed)
+			result.WriteByte('\n')
+			inDirective = strings.HasSuffix(trimmed, "\\")
+			continue
+		}
+
+		minified := minifyGLSLLine(line)
+		if !joinLines {
+			result.WriteString(minified)
+			result.WriteByte('\n')
+			continue
+		}
+		if current.Len() > 0 {
+			out := current.String()
+			if isGLSLWordChar(out[len(out)-1]) && isGLSLWordChar(minified[0]) ||
+				isGLSLOperatorChar(out[len(out)-1]) && isGLSLOperatorChar(minified[0]) {
+				current.WriteByte(' ')
+			}
+		}
+		current.WriteString(minified)
+	}
+	flushCurrent()
+
+	return result.String()
+}
+
+// prettyGLSL re-indents shader code: one statement per line, 4 spaces per brace level.
+// Semicolons inside parentheses (for loop headers) don't break lines.
+func prettyGLSL(code string) string {
+	var result strings.Builder
+	var current strings.Builder
+	depth := 0
+	parenDepth := 0
+
+	flushCurrent := func() {
+		text := strings.TrimSpace(current.String())
+		current.Reset()
+		if text == "" {
+			return
+		}
+		result.WriteString(strings.Repeat("    ", depth))
+		result.WriteString(text)
+		result.WriteByte('\n')
+	}
+
+	for _, line := range strings.Split(minifyGLSL(code, true), "\n") {
+		if line == "" {
+			continue
+		}
+		if isPreprocessorLine(line) {
+			flushCurrent()
+			result.WriteString(line)
+			result.WriteByte('\n')
+			continue
+		}
+
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			switch {
+			case c == '(':
+				parenDepth++
+				current.WriteByte(c)
+			case c == ')':
+				if parenDepth > 0 {
+					parenDepth--
+				}
+				current.WriteByte(c)
+			case c == ';' && parenDepth == 0:
+				current.WriteByte(c)
+				flushCurrent()
+			case c == '{':
+				current.WriteString(" {")
+				flushCurrent()
+				depth++
+			case c == '}':
+				flushCurrent()
+				if depth > 0 {
+					depth--
+				}
+				current.WriteByte(c)
+				// Keep "} else" and "};" together
+				rest := line[i+1:]
+				if !strings.HasPrefix(rest, "else") && !strings.HasPrefix(rest, ";") {
+					flushCurrent()
+				} else if strings.HasPrefix(rest, "else") {
+					current.WriteByte(' ')
+				}
+			default:
+				current.WriteByte(c)
+			}
+		}
+		flushCurrent()
+	}
+
+	return result.String()
+}
+
+// formatShaderCode applies named format ("", "pretty" or "min")
+func formatShaderCode(code string, format string) (string, error) {
+	switch format {
+	case SHADER_FORMAT_NONE:
+		return code, nil
+	case SHADER_FORMAT_PRETTY:
+		return prettyGLSL(code), nil
+	case SHADER_FORMAT_MIN:
+		return minifyGLSL(code, true), nil
+	default:
+		return "", fmt.Errorf("unknown shader format %q (expected pretty or min)", format)
+	}
+}
+
+// minifyShaderCode is the conservative minify step of the shader pipeline.
+// It collapses whitespace and drops blank lines but never joins lines, so
+// preprocessor directives and statements keep their own lines.
+func minifyShaderCode(code string) string {
+	return strings.TrimSuffix(minifyGLSL(code, false), "\n")
+}
+
+// dumpProcessedShader writes processed image pass code to path ("-" = stdout)
+func dumpProcessedShader(shaderData *ShaderData, cfg *Config, path string, format string) error {
+	code := processShaderCode(selectImagePass(shaderData).Code, shaderData.Metadata, cfg)
+	code, err := formatShaderCode(code, format)
+	if err != nil {
+		return err
+	}
+	return writeShaderOutput(path, code)
+}
+
+// exportFragmentShader writes complete fragment shader as it is compiled to path ("-" = stdout)
+func exportFragmentShader(shaderData *ShaderData, cfg *Config, path string) error {
+	_, fragmentShader, err := getMainShaderCode(shaderData, cfg)
+	if err != nil {
+		return err
+	}
+	return writeShaderOutput(path, strings.TrimRight(fragmentShader, "\x00")+"\n")
+}
+
+// writeShaderOutput writes code to file, or to stdout for "-"
+func writeShaderOutput(path string, code string) error {
+	if path == "-" {
+		_, err := os.Stdout.WriteString(code)
+		return err
+	}
+	return os.WriteFile(path, []byte(code), 0644)
+}
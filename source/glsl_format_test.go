@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// glslTokens splits code into identifier/number runs, operator runs and single
+// characters; whitespace only separates tokens
+func glslTokens(code string) []string {
+	var tokens []string
+	for i := 0; i < len(code); {
+		c := code[i]
+		if strings.IndexByte(" \t\r\n\f\v", c) >= 0 {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(code) && (isGLSLWordChar(c) && isGLSLWordChar(code[j]) ||
+			isGLSLOperatorChar(c) && isGLSLOperatorChar(code[j])) {
+			j++
+		}
+		tokens = append(tokens, code[i:j])
+		i = j
+	}
+	return tokens
+}
+
+// preprocessorLines returns trimmed directive lines of code
+func preprocessorLines(code string) []string {
+	var lines []string
+	for _, line := range strings.Split(code, "\n") {
+		if isPreprocessorLine(line) {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+func TestMinifyShaderCode(t *testing.T) {
+	embedded, err := loadEmbeddedShader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultConfig()
+	cfg.MinifyShader = false
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"operators", "float f(float a, float b) {\n\treturn a - -b + a++ - --b;\n}"},
+		{"declarations", "uniform  float   x ;\n\n\n vec3   c = vec3( 1.0 , 2.0e-3,x );"},
+		{"directives", "#define   SCALE  2.0\n  #ifdef SCALE \nfloat s = SCALE;\n#endif\n#define F(x) \\\n  ((x) * 2.0)"},
+		{"embedded shader", processShaderCode(selectImagePass(embedded).Code, embedded.Metadata, &cfg)},
+	}
+	for _, tt := range tests {
+		minified := minifyShaderCode(tt.code)
+		if got, want := glslTokens(minified), glslTokens(tt.code); !slices.Equal(got, want) {
+			t.Errorf("%s: minify changed tokens:\n%q\nwant\n%q", tt.name, got, want)
+		}
+		if got, want := preprocessorLines(minified), preprocessorLines(tt.code); !slices.Equal(got, want) {
+			t.Errorf("%s: minify changed directives %q, want %q", tt.name, got, want)
+		}
+		if strings.Contains(minified, "\n\n") || len(minified) > len(tt.code) {
+			t.Errorf("%s: minify left blank lines or grew the code:\n%s", tt.name, minified)
+		}
+	}
+}
+
+func TestMinifyKeepsRenderedPixels(t *testing.T) {
+	embedded, err := loadEmbeddedShader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultConfig()
+	cfg.MinifyShader = false
+	plain := renderShaderPixels(t, embedded, &cfg, 32)
+	cfg.MinifyShader = true
+	if minified := renderShaderPixels(t, embedded, &cfg, 32); !bytes.Equal(plain, minified) {
+		t.Errorf("minified shader renders different pixels")
+	}
+}
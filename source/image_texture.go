@@ -0,0 +1,119 @@
+// Image file channel textures.
+//
+// Inputs of type "texture" (or with a .png/.jpg/.jpeg src) get the image
+// named by src when the channel textures are set up: the file is decoded with
+// image.Decode, converted to RGBA, padded or cropped to the sampler's aspect
+// (see texture_fit.go) and uploaded with the sampler's wrap and filter, so
+// iChannelResolution reports its real size. Relative src paths are resolved
+// against the shader file's directory; src URLs are not downloaded. GIF files
+// play as animations (see animated_texture.go). Files are limited to
+// IMAGE_TEXTURE_MAX_FILE and images to the GL texture size limit and
+// IMAGE_TEXTURE_MAX_PIXELS; a missing, oversized or undecodable file leaves
+// the channel black with a warning (Shadertoy's own /media/a/... files aren't
+// on disk unless the shader's directory has them).
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+const (
+	IMAGE_TEXTURE_MAX_FILE   = 32 << 20
+	IMAGE_TEXTURE_MAX_PIXELS = 8192 * 8192
+)
+
+// isImageInput reports whether input should get a texture decoded from its src
+func isImageInput(input ShaderInput) bool {
+	switch strings.ToLower(filepath.Ext(input.Src)) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	}
+	return input.Type == "texture"
+}
+
+// readChannelFile reads a channel's source file of at most maxBytes
+func readChannelFile(path string, maxBytes int) ([]byte, error) {
+	if strings.Contains(path, "://") {
+		return nil, fmt.Errorf("%s: only local files are loaded", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, int64(maxBytes)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBytes {
+		return nil, fmt.Errorf("%s is larger than %d MiB", path, maxBytes>>20)
+	}
+	return data, nil
+}
+
+// decodeChannelImage decodes a PNG, JPEG or GIF image of at most maxPixels into RGBA
+func decodeChannelImage(data []byte, maxPixels int) (*image.RGBA, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %v", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%s image %dx%d too large", format, config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s image: %v", format, err)
+	}
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba, nil
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
+
+// loadChannelImage reads and decodes an image file
+func loadChannelImage(path string, maxPixels int) (*image.RGBA, error) {
+	data, err := readChannelFile(path, IMAGE_TEXTURE_MAX_FILE)
+	if err != nil {
+		return nil, err
+	}
+	return decodeChannelImage(data, maxPixels)
+}
+
+// setupImageChannel loads input's image and uploads it; false leaves the channel black
+func setupImageChannel(channel int, input ShaderInput, sampler ShaderSampler) (channelTexture, bool) {
+	if input.Src == "" {
+		log.Printf("Warning: iChannel%d texture has no src, channel stays black", channel)
+		return channelTexture{}, false
+	}
+	var maxSize int32
+	gl.GetIntegerv(gl.MAX_TEXTURE_SIZE, &maxSize)
+	img, err := loadChannelImage(input.Src, IMAGE_TEXTURE_MAX_PIXELS)
+	if err != nil {
+		log.Printf("Warning: iChannel%d: %v, channel stays black", channel, err)
+		return channelTexture{}, false
+	}
+	img = fitAspect(img, sampler.Fit, sampler.Aspect)
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if maxSize > 0 && (width > int(maxSize) || height > int(maxSize)) {
+		log.Printf("Warning: iChannel%d: %s is %dx%d, larger than the GL limit %d, channel stays black", channel, input.Src, width, height, maxSize)
+		return channelTexture{}, false
+	}
+	if DEBUG_MODE {
+		log.Printf("iChannel%d: %s, %dx%d", channel, input.Src, width, height)
+	}
+	return channelTexture{texture: uploadTexture(img, sampler), width: width, height: height}, true
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// TestImageTextures checks decoding of channel image files: size, pixels, formats and limits
+func TestImageTextures(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i := range src.Pix {
+		src.Pix[i] = 255
+	}
+	src.SetNRGBA(2, 1, color.NRGBA{255, 0, 0, 255})
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, src); err != nil {
+		t.Fatal(err)
+	}
+	img, err := decodeChannelImage(pngData.Bytes(), IMAGE_TEXTURE_MAX_PIXELS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 3, 2) {
+		t.Fatalf("PNG decoded to %v, expected 3x2", img.Bounds())
+	}
+	if got := img.RGBAAt(2, 1); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("PNG pixel %v, expected red", got)
+	}
+	var jpegData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, image.NewGray(image.Rect(0, 0, 16, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if img, err = decodeChannelImage(jpegData.Bytes(), IMAGE_TEXTURE_MAX_PIXELS); err != nil || img.Bounds().Dx() != 16 || img.Bounds().Dy() != 8 {
+		t.Errorf("JPEG decoded to %v, %v (expected 16x8)", img, err)
+	}
+	if _, err := decodeChannelImage(pngData.Bytes(), 5); err == nil {
+		t.Errorf("3x2 image accepted with a 5 pixel limit")
+	}
+	if _, err := decodeChannelImage([]byte("not an image"), IMAGE_TEXTURE_MAX_PIXELS); err == nil {
+		t.Errorf("garbage decoded as an image")
+	}
+	if _, err := readChannelFile("https://example.com/tex.png", IMAGE_TEXTURE_MAX_FILE); err == nil {
+		t.Errorf("texture URL read")
+	}
+	for input, want := range map[ShaderInput]bool{
+		{Type: "texture", Src: "/media/a/tex.jpg"}: true, {Src: "wood.PNG"}: true, {Type: "texture"}: true,
+		{Type: "noise"}: false, {Type: "buffer"}: false, {Type: "keyboard"}: false,
+	} {
+		if isImageInput(input) != want {
+			t.Errorf("isImageInput(%+v) = %v", input, !want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Mouse and keyboard input for interactive mode (`-interactive`).
+//
+// In interactive mode input doesn't close the screensaver (Esc does), and the
+// cursor drives `iMouse` with Shadertoy semantics: .xy is the position while
+// a button is held, .zw is the click position, negated after release.
+// Space pauses/resumes the animation (iTime and iFrame stop advancing).
+// [ and ] move iTime back/forward by -seek-step (Shift: SEEK_FAST_FACTOR
+// steps, keys repeat while held), which together with pause scrubs through the
+// animation. Shaders that feed back their previous frames through buffers
+// don't scrub cleanly: their state depends on every frame in between.
+//
+// GLFW reports cursor positions in window coordinates (top-left origin),
+// while shaders work in framebuffer pixels (bottom-left origin). On HiDPI
+// displays with a scaled framebuffer (macOS Retina, Wayland) these differ, so
+// positions are scaled by framebuffer/window size ratio and flipped on Y.
+// The ratio is used instead of GetContentScale: on Windows window coordinates
+// are already pixels while content scale still reports the DPI factor.
+//
+// Outside interactive mode, exit on mouse movement (off by default) ignores
+// the first MOVE_EXIT_GRACE after start and then exits only once the cursor
+// has traveled MoveThreshold pixels in total, so touchpad jitter doesn't
+// close the screensaver right away.
+//
+// Events that aren't user input never exit: a window refresh (window exposed
+// by the window manager) only requests an immediate redraw, and joystick
+// connect/disconnect is logged in debug builds.
+package main
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+const (
+	MOVE_EXIT_GRACE        = time.Second // Movement right after start doesn't count
+	MOVE_THRESHOLD_DEFAULT = 40          // Cursor travel in window pixels that exits
+	MOVE_THRESHOLD_MIN     = 1
+	MOVE_THRESHOLD_MAX     = 500
+	SEEK_STEP_DEFAULT      = 0.1 // iTime step of [ and ] in seconds
+	SEEK_FAST_FACTOR       = 10  // Step multiplier with Shift
+)
+
+// noMouse is iMouse value when there is no mouse input (never clicked)
+var noMouse = [4]float32{0.0, 0.0, -1.0, -1.0}
+
+// mouseInput tracks iMouse state, in framebuffer pixels
+type mouseInput struct {
+	position [2]float32 // Last position while button was held
+	click    [2]float32 // Position of last button press
+	pressed  bool
+	clicked  bool // At least one click happened
+}
+
+// cursorToFramebuffer converts GLFW cursor position to framebuffer pixels with bottom-left origin
+func cursorToFramebuffer(window *glfw.Window, xpos, ypos float64) (float32, float32) {
+	width, height := window.GetSize()
+	fbWidth, fbHeight := window.GetFramebufferSize()
+	scaleX, scaleY := 1.0, 1.0
+	if width > 0 && height > 0 {
+		scaleX = float64(fbWidth) / float64(width)
+		scaleY = float64(fbHeight) / float64(height)
+	}
+	return float32(xpos * scaleX), float32(float64(fbHeight) - ypos*scaleY)
+}
+
+// installMouseCallbacks makes window update mouse state (left button only, like Shadertoy)
+func installMouseCallbacks(window *glfw.Window, mouse *mouseInput) {
+	window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if button != glfw.MouseButtonLeft {
+			return
+		}
+		switch action {
+		case glfw.Press:
+			xpos, ypos := w.GetCursorPos()
+			x, y := cursorToFramebuffer(w, xpos, ypos)
+			mouse.position = [2]float32{x, y}
+			mouse.click = [2]float32{x, y}
+			mouse.pressed = true
+			mouse.clicked = true
+		case glfw.Release:
+			mouse.pressed = false
+		}
+	})
+	window.SetCursorPosCallback(func(w *glfw.Window, xpos, ypos float64) {
+		if mouse.pressed {
+			x, y := cursorToFramebuffer(w, xpos, ypos)
+			mouse.position = [2]float32{x, y}
+		}
+	})
+}
+
+// installDeviceCallbacks handles window events that aren't key/mouse input.
+// Refresh sets redraw, which makes limitFrameRate stop waiting and draw the next frame right away.
+func installDeviceCallbacks(window *glfw.Window, redraw *bool) {
+	window.SetRefreshCallback(func(w *glfw.Window) {
+		*redraw = true
+	})
+	glfw.SetJoystickCallback(func(joy glfw.Joystick, event glfw.PeripheralEvent) {
+		if DEBUG_MODE {
+			connected := event == glfw.Connected
+			log.Printf("Joystick %d connected: %v (ignored)", joy, connected)
+		}
+	})
+}
+
+// movementExit accumulates cursor travel for exit on mouse movement
+type movementExit struct {
+	threshold float64   // Total travel in window pixels that exits
+	start     time.Time // Movement before start + MOVE_EXIT_GRACE is ignored
+	last      [2]float64
+	hasLast   bool
+	traveled  float64
+}
+
+// moved records cursor position at now; returns true once travel passed threshold
+func (m *movementExit) moved(xpos, ypos float64, now time.Time) bool {
+	// First report (often the initial position) and the grace period only set the reference
+	if !m.hasLast || now.Sub(m.start) < MOVE_EXIT_GRACE {
+		m.last = [2]float64{xpos, ypos}
+		m.hasLast = true
+		return false
+	}
+	m.traveled += math.Hypot(xpos-m.last[0], ypos-m.last[1])
+	m.last = [2]float64{xpos, ypos}
+	return m.traveled >= m.threshold
+}
+
+// pauseState tracks paused intervals so iTime resumes without a jump, and the
+// manual iTime offset of [ and ]
+type pauseState struct {
+	paused   bool
+	pausedAt time.Time     // Start of current pause
+	total    time.Duration // Length of finished pauses
+	seek     float64       // Seconds added to iTime (FrameState.advance keeps iTime >= 0)
+}
+
+// seekKey moves iTime by step seconds for [ and ] (Shift: SEEK_FAST_FACTOR steps); false for other keys
+func (p *pauseState) seekKey(key glfw.Key, mods glfw.ModifierKey, step float64) bool {
+	if mods&glfw.ModShift != 0 {
+		step *= SEEK_FAST_FACTOR
+	}
+	switch key {
+	case glfw.KeyLeftBracket:
+		p.seek -= step
+	case glfw.KeyRightBracket:
+		p.seek += step
+	default:
+		return false
+	}
+	return true
+}
+
+// toggle pauses or resumes at given time
+func (p *pauseState) toggle(now time.Time) {
+	if p.paused {
+		p.total += now.Sub(p.pausedAt)
+	} else {
+		p.pausedAt = now
+	}
+	p.paused = !p.paused
+}
+
+// pausedFor returns total paused time up to now, including current pause
+func (p *pauseState) pausedFor(now time.Time) time.Duration {
+	if p.paused {
+		return p.total + now.Sub(p.pausedAt)
+	}
+	return p.total
+}
+
+// uniform returns iMouse value for current state
+func (m *mouseInput) uniform() [4]float32 {
+	if !m.clicked {
+		return noMouse
+	}
+	if m.pressed {
+		return [4]float32{m.position[0], m.position[1], m.click[0], m.click[1]}
+	}
+	return [4]float32{m.position[0], m.position[1], -m.click[0], -m.click[1]}
+}
@@ -0,0 +1,107 @@
+//go:build linux && !wayland
+// +build linux,!wayland
+
+// Linux/X11 helpers for `/p` preview mode.
+//
+// Desktop screensaver hosts (XScreenSaver-style) pass an X11 `Window` id and
+// expect the preview to draw inside it. This is the X11 counterpart of
+// `windows_embed.go`: GLFW exposes the native window, and we reparent it with
+// Xlib `XReparentWindow`.
+//
+// The parent id comes from the command line and may be stale or invalid. Xlib's
+// default error handler exits the process on the resulting BadWindow, so the
+// calls on the parent run under a temporary handler that records the error.
+package main
+
+/*
+#cgo LDFLAGS: -lX11
+#include <X11/Xlib.h>
+
+static int embedErrorCode;
+
+static int recordEmbedError(Display *display, XErrorEvent *event) {
+	embedErrorCode = event->error_code;
+	return 0;
+}
+
+// beginTrapErrors flushes pending requests and installs recordEmbedError
+static XErrorHandler beginTrapErrors(Display *display) {
+	XSync(display, False);
+	embedErrorCode = 0;
+	return XSetErrorHandler(recordEmbedError);
+}
+
+// endTrapErrors waits for the requests since beginTrapErrors, restores the
+// previous handler and returns the X error code of the first failure (0 = none)
+static int endTrapErrors(Display *display, XErrorHandler previous) {
+	XSync(display, False);
+	XSetErrorHandler(previous);
+	return embedErrorCode;
+}
+*/
+import "C"
+
+import (
+	"log"
+	"unsafe"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// previewEmbeddingSupported reports whether embedWindowIntoParent is implemented
+const previewEmbeddingSupported = true
+
+// hideWindow hides a GLFW window (window title is not needed on X11)
+func hideWindow(window *glfw.Window, windowTitle string) {
+	window.Hide()
+}
+
+// showWindow shows a GLFW window
+func showWindow(window *glfw.Window, windowTitle string) {
+	window.Show()
+}
+
+// embedWindowIntoParent reparents GLFW window into parent X11 window
+// Returns the width and height of the parent window
+func embedWindowIntoParent(window *glfw.Window, parentWindow uintptr, windowTitle string) (int, int) {
+	display := (*C.Display)(unsafe.Pointer(glfw.GetX11Display()))
+	if display == nil {
+		if DEBUG_MODE {
+			log.Printf("Warning: X11 display unavailable for embedding")
+		}
+		return 320, 240 // Default size if embedding failed
+	}
+	child := C.Window(window.GetX11Window())
+	parent := C.Window(parentWindow)
+
+	// Get parent window size
+	var attrs C.XWindowAttributes
+	previous := C.beginTrapErrors(display)
+	status := C.XGetWindowAttributes(display, parent, &attrs)
+	if code := C.endTrapErrors(display, previous); status == 0 || code != 0 {
+		log.Printf("Warning: XGetWindowAttributes failed for parent window 0x%x (X error %d)", parentWindow, int(code))
+		return 320, 240 // Fallback to default size
+	}
+	width := int(attrs.width)
+	height := int(attrs.height)
+
+	// Reparent, then fill parent area exactly.
+	// Coordinates are relative to parent window.
+	// The parent may be destroyed between the two calls
+	previous = C.beginTrapErrors(display)
+	C.XReparentWindow(display, child, parent, 0, 0)
+	C.XMoveResizeWindow(display, child, 0, 0, C.uint(width), C.uint(height))
+	C.XMapWindow(display, child)
+	if code := C.endTrapErrors(display, previous); code != 0 {
+		log.Printf("Warning: embedding into parent window 0x%x failed (X error %d)", parentWindow, int(code))
+		return 320, 240
+	}
+
+	if DEBUG_MODE {
+		log.Printf("Embedded preview window (0x%x) into parent window (0x%x), size: %dx%d", uintptr(child), parentWindow, width, height)
+	}
+
+	// Resize GLFW window to match parent size
+	window.SetSize(width, height)
+	return width, height
+}
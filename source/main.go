@@ -7,7 +7,8 @@
 //
 // Rendering pipeline:
 //  1. Load shader JSON from embedded `shader.json`.
-//  2. Repair/minify shader code defensively (for malformed exports).
+//  2. Repair (`fixShaderCode`) and minify (`minifyShaderCode`) shader code
+//     defensively (for malformed exports).
 //  3. Build OpenGL program and draw a fullscreen quad each frame.
 //  4. Populate common shader uniforms (`iTime`, `iResolution`, etc.).
 package main
@@ -15,6 +16,8 @@ package main
 import (
 	_ "embed"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -26,6 +29,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -62,6 +66,7 @@ const (
 	CONFIG_WINDOW_TITLE       = "About"
 	WEBSITE_URL               = "https://www.fullscreensavers.com/?utm_source=About&utm_medium=auroraborealisbliss"
 	VISIT_WEBSITE_BUTTON_TEXT = "Visit website"
+	SETTINGS_BUTTON_TEXT      = "Settings"
 	COPYRIGHT_TEXT            = "© 2026 Aurora Borealis Bliss Screensaver contributors (MIT License)"
 	WEBSITE_TEXT              = "More free screensavers on https://www.fullscreensavers.com"
 	EMAIL_TEXT                = "Feel free to contact us: support@fullscreensavers.com"
@@ -121,10 +126,10 @@ func (l *dialogLayout) Layout(objects []fyne.CanvasObject, containerSize fyne.Si
 	logoImage := objects[1]
 	logoSize := logoImage.MinSize()
 	// Maximum height for logo: remaining space minus text lines and button
-	textLinesHeight := float32(0)
-	if len(objects) >= 6 {
-		textLinesHeight = 20 * 3 // 3 text lines (copyright, website, email) with spacing
-	}
+	// Text lines (copyright, website, email, version) are everything between
+	// logo (index 1) and button (last element), 20px each with spacing
+	textLinesCount := len(objects) - 3
+	textLinesHeight := float32(20 * textLinesCount)
 	maxAvailable := l.height - currentY - l.bottomPadding - l.spacing - 40 - textLinesHeight // 40px for button
 	if logoSize.Height > maxAvailable {
 		logoSize.Height = maxAvailable
@@ -140,9 +145,9 @@ func (l *dialogLayout) Layout(objects []fyne.CanvasObject, containerSize fyne.Si
 	logoImage.Move(fyne.NewPos((l.width-logoSize.Width)/2, currentY))
 	currentY += logoSize.Height + l.spacing
 
-	// Text lines (copyright, website, email) - indices 2, 3, 4
+	// Text lines (copyright, website, email, version) - indices 2 .. len-2
 	textSpacing := float32(5) // Smaller spacing between text lines
-	for i := 2; i <= 4 && i < len(objects); i++ {
+	for i := 2; i < len(objects)-1; i++ {
 		textLabel := objects[i]
 		textSize := fyne.NewSize(l.width-40, 20)
 		textLabel.Resize(textSize)
@@ -150,7 +155,7 @@ func (l *dialogLayout) Layout(objects []fyne.CanvasObject, containerSize fyne.Si
 		currentY += textSize.Height + textSpacing
 	}
 
-	// Button (last element) - use minimum size
+	// Buttons row (last element) - use minimum size
 	buttonIdx := len(objects) - 1
 	if buttonIdx >= 0 {
 		button := objects[buttonIdx]
@@ -302,6 +307,19 @@ func loadEmbeddedShader() (*ShaderData, error) {
 	if len(data) == 0 {
 		return nil, fmt.Errorf("embedded shader data is empty")
 	}
+	return parseShaderJSON(data)
+}
+
+// parseShaderJSON parses shader JSON (embedded or loaded with -shader), plain or gzip-compressed
+func parseShaderJSON(data []byte) (*ShaderData, error) {
+	data, err := decompressShaderData(data)
+	if err != nil {
+		return nil, err
+	}
+	data, err = decodeShaderText(data)
+	if err != nil {
+		return nil, err
+	}
 
 	// Preprocess JSON to fix common issues (unescaped newlines, etc.)
 	preprocessedData, err := preprocessJSON(data)
@@ -318,10 +336,63 @@ func loadEmbeddedShader() (*ShaderData, error) {
 	if len(shaderData.Passes) == 0 {
 		return nil, fmt.Errorf("shader file contains no passes")
 	}
+	validateShaderParams(shaderData.Metadata)
+	validateShaderSkipFixes(shaderData.Metadata)
 
 	return &shaderData, nil
 }
 
+// loadShader loads shader selected by configuration (-shader file or URL, or embedded)
+// and logs which one was loaded (always, so user logs show what was running).
+// Safe mode always uses the built-in fallback shader
+func loadShader(cfg *Config) (*ShaderData, error) {
+	source, shaderData, err := loadShaderSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Shader: %s", describeShader(source, shaderData))
+	return shaderData, nil
+}
+
+// loadShaderSource loads shader selected by configuration, returns where it came from
+func loadShaderSource(cfg *Config) (string, *ShaderData, error) {
+	if cfg.SafeMode {
+		return "fallback (safe mode)", fallbackShaderData(), nil
+	}
+	if isShaderURL(cfg.ShaderPath) {
+		shaderData, err := loadShaderURL(cfg.ShaderPath, cfg.AllowHTTP)
+		if err == nil {
+			return "URL " + cfg.ShaderPath, shaderData, nil
+		}
+		log.Printf("Warning: %v; using embedded shader", err)
+		shaderData, err = loadEmbeddedShader()
+		return "embedded (URL failed)", shaderData, err
+	}
+	if cfg.ShaderPath != "" {
+		shaderData, err := loadShaderFile(cfg.ShaderPath)
+		return "file " + cfg.ShaderPath, shaderData, err
+	}
+	shaderData, err := loadEmbeddedShader()
+	return "embedded", shaderData, err
+}
+
+// describeShader summarizes loaded shader for logs: source, code size, passes and title
+func describeShader(source string, shaderData *ShaderData) string {
+	size := 0
+	for _, pass := range shaderData.Passes {
+		size += len(pass.Code)
+	}
+	passes := "passes"
+	if len(shaderData.Passes) == 1 {
+		passes = "pass"
+	}
+	title := "untitled"
+	if shaderData.Metadata != nil && shaderData.Metadata.Title != "" {
+		title = strconv.Quote(shaderData.Metadata.Title)
+	}
+	return fmt.Sprintf("%s, %d bytes of code, %d %s, %s", source, size, len(shaderData.Passes), passes, title)
+}
+
 // removeComments removes all comments from shader code
 func removeComments(code string) string {
 	var result strings.Builder
@@ -371,13 +442,14 @@ func removeComments(code string) string {
 	return result.String()
 }
 
+// Start of a multi-declaration chain with an explicit type: "vec2 r = ...," or "float i = ...,"
+var chainInitPattern = regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+\w+\s*=`)
+
 // determineVariableType determines the type of a variable based on its declaration chain or usage
 func determineVariableType(varName string, code string, lines []string, lineIndex int) string {
 	// First, check if variable is part of a multi-declaration chain
 	// Look backwards to find the start of the chain where type is explicitly declared
-	// Pattern: "vec2 r = ...," or "float i = ...," etc.
-	typeDeclPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+\w+\s*=`)
-
+	// Pattern: "vec2 r = ...," or "float i = ...," etc. (chainInitPattern)
 	for j := lineIndex - 1; j >= 0 && j >= lineIndex-20; j-- {
 		prevLine := strings.TrimSpace(lines[j])
 		if prevLine == "" {
@@ -388,7 +460,7 @@ func determineVariableType(varName string, code string, lines []string, lineInde
 		if !strings.HasSuffix(prevLine, ",") {
 			// If line doesn't end with comma, check if it's the start of the chain
 			// Look for explicit type declaration like "vec2 r = ..."
-			if matches := typeDeclPattern.FindStringSubmatch(prevLine); matches != nil {
+			if matches := chainInitPattern.FindStringSubmatch(prevLine); matches != nil {
 				varType := matches[1]
 				// Return appropriate default value based on type
 				switch varType {
@@ -411,7 +483,7 @@ func determineVariableType(varName string, code string, lines []string, lineInde
 		}
 
 		// Line ends with comma, check if it's the start of the chain with explicit type
-		if matches := typeDeclPattern.FindStringSubmatch(prevLine); matches != nil {
+		if matches := chainInitPattern.FindStringSubmatch(prevLine); matches != nil {
 			varType := matches[1]
 			// Return appropriate default value based on type
 			switch varType {
@@ -445,7 +517,7 @@ func determineVariableType(varName string, code string, lines []string, lineInde
 	}
 
 	// Check for swizzle patterns
-	swizzlePattern := regexp.MustCompile(regexp.QuoteMeta(varName) + `\.([xyzw]{2,4})`)
+	swizzlePattern := swizzleAccessPattern(varName)
 	if matches := swizzlePattern.FindAllString(code, -1); len(matches) > 0 {
 		// Variable is used with swizzle, likely vec2 or vec4
 		// Check if used in accumulation
@@ -487,6 +559,73 @@ func determineVariableType(varName string, code string, lines []string, lineInde
 	return "vec2(0.0)"
 }
 
+// GLSL_ARRAY_SUFFIX matches an optional array size after a declared name,
+// so `const vec3 palette[4] = ...` counts as a declaration of palette
+const GLSL_ARRAY_SUFFIX = `(\s*\[[^\]]*\])?`
+
+// PATTERN_CACHE_MAX bounds cachedPattern; the cache is dropped when it is full
+const PATTERN_CACHE_MAX = 4096
+
+// Patterns built around a variable name, compiled once per expression
+var (
+	patternCacheMutex sync.Mutex
+	patternCache      = make(map[string]*regexp.Regexp)
+)
+
+// cachedPattern returns the compiled expr, compiling it on first use. The fix
+// passes build patterns per variable name and line (declarationPattern and
+// friends), mostly for the same few names, so compiling them every time
+// dominated fixShaderCode.
+func cachedPattern(expr string) *regexp.Regexp {
+	patternCacheMutex.Lock()
+	defer patternCacheMutex.Unlock()
+	if pattern, ok := patternCache[expr]; ok {
+		return pattern
+	}
+	if len(patternCache) >= PATTERN_CACHE_MAX {
+		clear(patternCache)
+	}
+	pattern := regexp.MustCompile(expr)
+	patternCache[expr] = pattern
+	return pattern
+}
+
+// clearPatternCache drops all patterns compiled by cachedPattern
+func clearPatternCache() {
+	patternCacheMutex.Lock()
+	defer patternCacheMutex.Unlock()
+	clear(patternCache)
+}
+
+// declarationPattern matches a scalar/vector declaration of name: "vec2 name =", "float name[3];"
+func declarationPattern(name string) *regexp.Regexp {
+	return cachedPattern(`\b(vec[234]|float|int|bool)\s+` + regexp.QuoteMeta(name) + GLSL_ARRAY_SUFFIX + `\s*[=;]`)
+}
+
+// matrixDeclarationPattern is declarationPattern also accepting matrix types
+func matrixDeclarationPattern(name string) *regexp.Regexp {
+	return cachedPattern(`\b(vec[234]|float|int|bool|mat[234])\s+` + regexp.QuoteMeta(name) + GLSL_ARRAY_SUFFIX + `\s*[=;]`)
+}
+
+// parameterPattern matches name declared as a function parameter: "out vec4 name)"
+func parameterPattern(name string) *regexp.Regexp {
+	return cachedPattern(`\b(out|in|inout)\s+(vec[234]|float|int|bool|mat[234])\s+` + regexp.QuoteMeta(name) + `\s*[,)]`)
+}
+
+// swizzleAccessPattern matches a multi-component swizzle of name ("name.xy"), capturing it
+func swizzleAccessPattern(name string) *regexp.Regexp {
+	return cachedPattern(regexp.QuoteMeta(name) + `\.([xyzw]{2,4})`)
+}
+
+var (
+	// Assignment without a type: "varName = expression;"
+	orphanedAssignmentPattern = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*([^;]+);`)
+	// Line starting with a type declaration
+	typedDeclarationPattern = regexp.MustCompile(`^\s*(vec[234]|float|int|bool|mat[234])\s+`)
+	// Identifier (also matches keywords, function names and swizzles)
+	identifierPattern = regexp.MustCompile(`\b([a-zA-Z_][a-zA-Z0-9_]*)`)
+)
+
 // removeOrphanedAssignments removes assignments that reference undeclared variables
 // Example: "vec2 p = bpos.zx;" where bpos is not declared
 // BUT: It should NOT remove lines with type declarations like "vec2 dg = tri2(bp*1.85)*.75;"
@@ -498,15 +637,13 @@ func removeOrphanedAssignments(code string) string {
 	for i, line := range lines {
 		// Check for assignment pattern WITHOUT type declaration: "varName = expression;" (no type before varName)
 		// This is an orphaned assignment - assignment without declaration
-		orphanedPattern := regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*([^;]+);`)
-		if matches := orphanedPattern.FindStringSubmatch(line); matches != nil {
+		if matches := orphanedAssignmentPattern.FindStringSubmatch(line); matches != nil {
 			varName := matches[1]
 			expression := matches[2]
 
 			// Skip if this line has a type declaration (e.g., "vec2 dg = ..." is NOT orphaned)
 			// Check if line starts with a type keyword
-			typePattern := regexp.MustCompile(`^\s*(vec[234]|float|int|bool|mat[234])\s+`)
-			if typePattern.MatchString(line) {
+			if typedDeclarationPattern.MatchString(line) {
 				// This is a type declaration, not an orphaned assignment - keep it
 				filteredLines = append(filteredLines, line)
 				continue
@@ -521,7 +658,7 @@ func removeOrphanedAssignments(code string) string {
 			// Check if variable is a function parameter (e.g., fragColor in mainImage)
 			// Look for function definitions that contain this variable as a parameter
 			beforeCode := strings.Join(lines[:i], "\n")
-			paramPattern := regexp.MustCompile(`\b(out|in|inout)\s+(vec[234]|float|int|bool|mat[234])\s+` + regexp.QuoteMeta(varName) + `\s*[,)]`)
+			paramPattern := parameterPattern(varName)
 			if paramPattern.MatchString(beforeCode) {
 				// Variable is a function parameter - keep it
 				filteredLines = append(filteredLines, line)
@@ -529,11 +666,10 @@ func removeOrphanedAssignments(code string) string {
 			}
 
 			// Check if variable is declared before this line
-			declPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool|mat[234])\s+` + regexp.QuoteMeta(varName) + `\s*[=;]`)
+			declPattern := matrixDeclarationPattern(varName)
 			if !declPattern.MatchString(beforeCode) {
 				// Check if expression references undeclared variables
-				varRefPattern := regexp.MustCompile(`\b([a-zA-Z_][a-zA-Z0-9_]*)`)
-				varRefs := varRefPattern.FindAllString(expression, -1)
+				varRefs := identifierPattern.FindAllString(expression, -1)
 
 				// Check if any referenced variable is not declared
 				isOrphaned := false
@@ -551,9 +687,9 @@ func removeOrphanedAssignments(code string) string {
 					}
 
 					// Check if variable is declared before this line
-					refDeclPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool|mat[234])\s+` + regexp.QuoteMeta(ref) + `\s*[=;]`)
+					refDeclPattern := matrixDeclarationPattern(ref)
 					// Also check if it's a function parameter
-					refParamPattern := regexp.MustCompile(`\b(out|in|inout)\s+(vec[234]|float|int|bool|mat[234])\s+` + regexp.QuoteMeta(ref) + `\s*[,)]`)
+					refParamPattern := parameterPattern(ref)
 					if !refDeclPattern.MatchString(beforeCode) && !refParamPattern.MatchString(beforeCode) {
 						// Variable is not declared - this is an orphaned assignment
 						isOrphaned = true
@@ -575,43 +711,27 @@ func removeOrphanedAssignments(code string) string {
 
 // fixMainImageFragColor removes duplicate fragColor declaration in mainImage
 // mainImage already has "out vec4 fragColor" as parameter, so we shouldn't redeclare it
+// (the parameter may have another name, like "out vec4 O")
 func fixMainImageFragColor(code string) string {
 	lines := strings.Split(code, "\n")
+	colorName := mainImageColorName(code)
 
-	// Find mainImage function
-	mainImageStart := -1
-	for i, line := range lines {
-		if strings.Contains(strings.TrimSpace(line), "void mainImage") {
-			mainImageStart = i
-			break
-		}
-	}
-
+	// Find mainImage function (without functions defined after it)
+	mainImageStart, mainImageEnd := mainImageRange(lines)
 	if mainImageStart == -1 {
 		return code // mainImage not found
 	}
 
-	// Find mainImage function end
-	braceCount := 0
-	mainImageEnd := len(lines)
-	for i := mainImageStart; i < len(lines); i++ {
-		line := lines[i]
-		braceCount += strings.Count(line, "{") - strings.Count(line, "}")
-		if braceCount == 0 && i > mainImageStart {
-			mainImageEnd = i + 1
-			break
-		}
-	}
-
 	// Look for duplicate fragColor declaration inside mainImage
 	for i := mainImageStart; i < mainImageEnd; i++ {
 		trimmed := strings.TrimSpace(lines[i])
 		// Check for "vec4 fragColor = ..." (not "out vec4 fragColor" which is parameter)
-		if strings.Contains(trimmed, "vec4 fragColor =") || strings.Contains(trimmed, "vec4 fragColor=") {
+		declaration := "vec4 " + colorName
+		if strings.Contains(trimmed, declaration+" =") || strings.Contains(trimmed, declaration+"=") {
 			// Replace with just assignment: "fragColor = ..."
 			// Extract assignment part
-			if idx := strings.Index(trimmed, "fragColor"); idx >= 0 {
-				assignment := trimmed[idx:]
+			if idx := strings.Index(trimmed, declaration); idx >= 0 {
+				assignment := trimmed[idx+len("vec4 "):]
 				lines[i] = strings.Repeat(" ", len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))) + assignment
 			}
 		}
@@ -621,38 +741,120 @@ func fixMainImageFragColor(code string) string {
 }
 
 // findFunctionScope finds which function a line belongs to
-// Returns: line index of function start, true if in mainImage
+// Returns: line index of function start, true if in mainImage (-1 for global scope).
+// Uses brace depth, so functions defined after mainImage get their own scope.
 func findFunctionScope(lines []string, lineIndex int) (int, bool) {
-	// Look backwards to find function definition
-	for i := lineIndex; i >= 0; i-- {
-		line := strings.TrimSpace(lines[i])
-		// Check for function definition
-		if strings.Contains(line, "void ") ||
-			(strings.Contains(line, "float ") && strings.Contains(line, "(")) ||
-			(strings.Contains(line, "vec") && strings.Contains(line, "(")) {
-			// Check if it's mainImage
-			if strings.Contains(line, "mainImage") {
-				return i, true
+	depth := 0
+	bodyStart := -1
+	for i := 0; i < lineIndex && i < len(lines); i++ {
+		for _, c := range lines[i] {
+			switch c {
+			case '{':
+				if depth == 0 {
+					bodyStart = i
+				}
+				depth++
+			case '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+	}
+	if depth == 0 {
+		// Line opening a function body belongs to that function
+		if lineIndex >= len(lines) || !strings.Contains(lines[lineIndex], "{") {
+			return -1, false
+		}
+		bodyStart = lineIndex
+	}
+
+	// Header is on the brace line, or on the line before a brace on its own line
+	header := bodyStart
+	for header > 0 && strings.HasPrefix(strings.TrimSpace(lines[header]), "{") {
+		header--
+	}
+	return header, strings.Contains(lines[header], "mainImage")
+}
+
+// mainImageRange returns lines [start, end) of mainImage definition, or -1, -1 if there is none.
+// Functions defined after mainImage are not part of the range.
+func mainImageRange(lines []string) (int, int) {
+	for i, line := range lines {
+		if !strings.Contains(line, "mainImage") || strings.HasSuffix(strings.TrimSpace(line), ";") {
+			continue // Not mentioned, or a prototype
+		}
+		if header, inMain := findFunctionScope(lines, i); !inMain || header != i {
+			continue
+		}
+		braceCount := 0
+		opened := false
+		for j := i; j < len(lines); j++ {
+			braceCount += strings.Count(lines[j], "{") - strings.Count(lines[j], "}")
+			opened = opened || strings.Contains(lines[j], "{")
+			if opened && braceCount <= 0 {
+				return i, j + 1
 			}
-			// It's another function
-			return i, false
 		}
+		return i, len(lines)
 	}
-	return -1, false
+	return -1, -1
 }
 
 // isVariableDeclaredInScope checks if a variable is declared in a specific scope
 func isVariableDeclaredInScope(code string, varName string, scopeStart int, scopeEnd int) bool {
 	// Check for type declaration: "vec2 varName", "float varName", etc.
-	declPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+` + regexp.QuoteMeta(varName) + `\s*[=;]`)
+	declPattern := declarationPattern(varName)
 	scopeCode := code[scopeStart:scopeEnd]
 	return declPattern.MatchString(scopeCode)
 }
 
-func fixShaderCode(code string) string {
+// fixShaderCode repairs common shader issues.
+// Passes named in skip are not run (see shader_fixes.go).
+func fixShaderCode(code string, skip map[string]bool) string {
 	// First, remove comments to make parsing easier
 	code = removeComments(code)
+	unrepaired := code
 
+	uninitializedVars := make(map[string]string) // var name -> default value
+	for _, fix := range shaderFixes {
+		if !skip[fix.name] {
+			code = runShaderFix(fix.name, code, uninitializedVars)
+		}
+	}
+
+	// Never emit braces unbalanced by the passes above (see shader_fixes.go)
+	if err := checkBraceBalance(code); err != nil {
+		if checkBraceBalance(unrepaired) == nil {
+			log.Printf("Warning: shader repair unbalanced braces (%v), using unrepaired code", err)
+			return unrepaired
+		}
+		log.Printf("Warning: shader has unbalanced braces: %v", err)
+	}
+
+	return code
+}
+
+var (
+	// Pattern 1: Variables in multi-declaration chains (e.g., ", w;", ", x;", ", y;")
+	// Match pattern: ", variableName;" where variableName is any identifier
+	chainVarPattern = regexp.MustCompile(`,\s+(\w+)\s*;`)
+	// Pattern 2: Standalone variable declarations (e.g., "w;", "x;", "y;")
+	// Match pattern: variableName; (with optional leading whitespace)
+	standaloneVarPattern = regexp.MustCompile(`^\s*(\w+)\s*;`)
+	// Pattern 3: Type declarations without initialization ("vec4 w;", "float a;")
+	uninitializedDeclPattern = regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+(\w+)\s*;`)
+	// Storage-qualified declarations ("layout(location = 1) out vec4 n;") take no initializer
+	storageDeclPattern = regexp.MustCompile(`^(layout\s*\([^)]*\)\s*)?(in|out|uniform)\s`)
+	// Type of a declaration on the same line as a chained variable ("float i = .2, a;")
+	chainTypePattern = regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+\w+`)
+	// Assignment without a type declaration: "varName = value;"
+	untypedAssignmentPattern = regexp.MustCompile(`^\s*(\w+)\s*=\s*([^;]+);`)
+)
+
+// fixUninitializedVars initializes declared but uninitialized variables.
+// Returns fixed code and initialized variables (name -> default value).
+func fixUninitializedVars(code string) (string, map[string]string) {
 	// Fix uninitialized variables that are used in loops or expressions
 	// Common patterns:
 	// 1. ", varName;" in multi-declaration chain
@@ -664,14 +866,6 @@ func fixShaderCode(code string) string {
 	// Track variables that are declared but not initialized
 	uninitializedVars := make(map[string]string) // var name -> default value
 
-	// Pattern 1: Variables in multi-declaration chains (e.g., ", w;", ", x;", ", y;")
-	// Match pattern: ", variableName;" where variableName is any identifier
-	chainVarPattern := regexp.MustCompile(`,\s+(\w+)\s*;`)
-
-	// Pattern 2: Standalone variable declarations (e.g., "w;", "x;", "y;")
-	// Match pattern: variableName; (with optional leading whitespace)
-	standaloneVarPattern := regexp.MustCompile(`^\s*(\w+)\s*;`)
-
 	// First pass: find and fix uninitialized variable declarations
 	for i, line := range lines {
 		trimmed := strings.TrimSpace(line)
@@ -688,8 +882,7 @@ func fixShaderCode(code string) string {
 			}
 			// First, try to extract type from the same line (e.g., "float i = .2, a;")
 			varType := ""
-			typeDeclPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+\w+`)
-			if typeMatch := typeDeclPattern.FindStringSubmatch(line); typeMatch != nil {
+			if typeMatch := chainTypePattern.FindStringSubmatch(line); typeMatch != nil {
 				// Type found in the same line, use it
 				switch typeMatch[1] {
 				case "vec2":
@@ -732,18 +925,12 @@ func fixShaderCode(code string) string {
 			if !isMainImage && funcStart >= 0 {
 				// Check if variable is declared in mainImage
 				// Find mainImage function
-				mainImageStart := -1
-				for j := 0; j < len(lines); j++ {
-					if strings.Contains(strings.TrimSpace(lines[j]), "mainImage") {
-						mainImageStart = j
-						break
-					}
-				}
+				mainImageStart, mainImageEnd := mainImageRange(lines)
 
 				if mainImageStart >= 0 {
 					// Check if variable is declared in mainImage
-					mainImageCode := strings.Join(lines[mainImageStart:], "\n")
-					declPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+` + regexp.QuoteMeta(varName) + `\s*[=;]`)
+					mainImageCode := strings.Join(lines[mainImageStart:mainImageEnd], "\n")
+					declPattern := declarationPattern(varName)
 					if declPattern.MatchString(mainImageCode) {
 						// Variable is declared in mainImage, don't initialize it here
 						// It should be initialized in mainImage, not in this function
@@ -758,17 +945,11 @@ func fixShaderCode(code string) string {
 			varIsDeclaredElsewhere := false
 
 			// Check if variable is declared in mainImage
-			mainImageStart := -1
-			for j := 0; j < len(lines); j++ {
-				if strings.Contains(strings.TrimSpace(lines[j]), "mainImage") {
-					mainImageStart = j
-					break
-				}
-			}
+			mainImageStart, mainImageEnd := mainImageRange(lines)
 
 			if mainImageStart >= 0 {
-				mainImageCode := strings.Join(lines[mainImageStart:], "\n")
-				declPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+` + regexp.QuoteMeta(varName) + `\s*[=;]`)
+				mainImageCode := strings.Join(lines[mainImageStart:mainImageEnd], "\n")
+				declPattern := declarationPattern(varName)
 				if declPattern.MatchString(mainImageCode) {
 					varIsDeclaredElsewhere = true
 				}
@@ -790,7 +971,7 @@ func fixShaderCode(code string) string {
 
 				if firstFuncLine >= 0 {
 					globalCode := strings.Join(lines[:firstFuncLine], "\n")
-					declPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+` + regexp.QuoteMeta(varName) + `\s*[=;]`)
+					declPattern := declarationPattern(varName)
 					if declPattern.MatchString(globalCode) {
 						varIsDeclaredElsewhere = true
 					}
@@ -825,13 +1006,12 @@ func fixShaderCode(code string) string {
 		// Pattern 3: type declarations without initialization
 		// Match patterns like "vec4 w;" or "float a;" (but not "vec4 w = ...;")
 		// Use regex to find type declarations
-		declPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+(\w+)\s*;`)
-		if matches := declPattern.FindStringSubmatch(trimmed); matches != nil {
+		if matches := uninitializedDeclPattern.FindStringSubmatch(trimmed); matches != nil {
 			varType := matches[1]
 			varName := matches[2]
 
 			// Skip if variable is already initialized (has "=" in declaration)
-			if strings.Contains(trimmed, varName+" =") {
+			if strings.Contains(trimmed, varName+" =") || storageDeclPattern.MatchString(trimmed) {
 				continue
 			}
 
@@ -843,17 +1023,11 @@ func fixShaderCode(code string) string {
 				varIsDeclaredElsewhere := false
 
 				// Check mainImage
-				mainImageStart := -1
-				for j := 0; j < len(lines); j++ {
-					if strings.Contains(strings.TrimSpace(lines[j]), "mainImage") {
-						mainImageStart = j
-						break
-					}
-				}
+				mainImageStart, mainImageEnd := mainImageRange(lines)
 
 				if mainImageStart >= 0 {
-					mainImageCode := strings.Join(lines[mainImageStart:], "\n")
-					declPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+` + regexp.QuoteMeta(varName) + `\s*[=;]`)
+					mainImageCode := strings.Join(lines[mainImageStart:mainImageEnd], "\n")
+					declPattern := declarationPattern(varName)
 					if declPattern.MatchString(mainImageCode) {
 						varIsDeclaredElsewhere = true
 					}
@@ -862,7 +1036,7 @@ func fixShaderCode(code string) string {
 				// Check global scope (before first function)
 				if !varIsDeclaredElsewhere && funcStart >= 0 {
 					globalCode := strings.Join(lines[:funcStart], "\n")
-					declPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+` + regexp.QuoteMeta(varName) + `\s*[=;]`)
+					declPattern := declarationPattern(varName)
 					if declPattern.MatchString(globalCode) {
 						varIsDeclaredElsewhere = true
 					}
@@ -925,8 +1099,7 @@ func fixShaderCode(code string) string {
 
 		// Pattern: "varName = value;" without type declaration
 		// Match: identifier followed by = but no type declaration before
-		assignPattern := regexp.MustCompile(`^\s*(\w+)\s*=\s*([^;]+);`)
-		if matches := assignPattern.FindStringSubmatch(line); matches != nil {
+		if matches := untypedAssignmentPattern.FindStringSubmatch(line); matches != nil {
 			varName := matches[1]
 			// Skip if it's a function call or reserved keyword
 			if varName == "if" || varName == "for" || varName == "while" || varName == "return" {
@@ -935,22 +1108,16 @@ func fixShaderCode(code string) string {
 
 			// Check if variable is declared before this line
 			beforeCode := strings.Join(lines[:i], "\n")
-			declPattern := regexp.MustCompile(`\b(vec[234]|float|int|bool)\s+` + regexp.QuoteMeta(varName) + `\s*[=;]`)
+			declPattern := declarationPattern(varName)
 			if !declPattern.MatchString(beforeCode) {
 				// Variable is not declared, check if we're in a function other than mainImage
 				funcStart, isMainImage := findFunctionScope(lines, i)
 				if !isMainImage && funcStart >= 0 {
 					// Check if variable is declared in mainImage
-					mainImageStart := -1
-					for j := 0; j < len(lines); j++ {
-						if strings.Contains(strings.TrimSpace(lines[j]), "mainImage") {
-							mainImageStart = j
-							break
-						}
-					}
+					mainImageStart, mainImageEnd := mainImageRange(lines)
 
 					if mainImageStart >= 0 {
-						mainImageCode := strings.Join(lines[mainImageStart:], "\n")
+						mainImageCode := strings.Join(lines[mainImageStart:mainImageEnd], "\n")
 						if declPattern.MatchString(mainImageCode) {
 							// Variable is declared in mainImage, remove this assignment
 							// It shouldn't be assigned here
@@ -1038,20 +1205,18 @@ func fixShaderCode(code string) string {
 	}
 
 	code = strings.Join(lines, "\n")
+	return code, uninitializedVars
+}
 
-	// Remove orphaned assignments (assignments without declarations that reference undeclared variables)
-	// Example: "vec2 p = bpos.zx;" where bpos is not declared
-	code = removeOrphanedAssignments(code)
-
-	// Fix mainImage function - remove duplicate fragColor declaration
-	code = fixMainImageFragColor(code)
+// Header of a for loop up to its closing parenthesis
+var forLoopPattern = regexp.MustCompile(`for\s*\([^)]*\)`)
 
-	// Second pass: ensure variables are initialized before use in loops
+// initializeLoopVars inserts initialization of uninitializedVars before loops that use them
+func initializeLoopVars(code string, uninitializedVars map[string]string) string {
 	// This handles cases where variable is declared but used in loop before initialization
 	if strings.Contains(code, "for(") {
 		// Find all for loops
-		loopPattern := regexp.MustCompile(`for\s*\([^)]*\)`)
-		loopMatches := loopPattern.FindAllStringIndex(code, -1)
+		loopMatches := forLoopPattern.FindAllStringIndex(code, -1)
 
 		// Process loops in reverse order to avoid index shifting
 		for idx := len(loopMatches) - 1; idx >= 0; idx-- {
@@ -1097,51 +1262,88 @@ func fixShaderCode(code string) string {
 	return code
 }
 
-// getMainShaderCode extracts main shader code from parsed shader data
-// Returns vertex and fragment shader code
-func getMainShaderCode(shaderData *ShaderData) (string, string, error) {
-	// Look for "image" type pass or use first pass
-	var mainPass *ShaderPass
+// selectImagePass returns the pass that renders to screen
+// Looks for "image" type pass, then guesses by structure, then uses first pass
+func selectImagePass(shaderData *ShaderData) *ShaderPass {
 	for i := range shaderData.Passes {
 		if shaderData.Passes[i].Type == "image" || shaderData.Passes[i].Name == "Image" {
-			mainPass = &shaderData.Passes[i]
-			break
+			return &shaderData.Passes[i]
 		}
 	}
 
+	// Some exports put buffers first and omit type/name markers
+	if pass := guessImagePass(shaderData); pass != nil {
+		return pass
+	}
+
 	// If not found, use first pass
-	if mainPass == nil {
-		mainPass = &shaderData.Passes[0]
+	return &shaderData.Passes[0]
+}
+
+// guessImagePass finds image pass when type/name markers are missing.
+// Image pass defines mainImage, doesn't read its own output (buffers do for feedback),
+// and no other pass reads from it. Returns nil if no pass matches.
+func guessImagePass(shaderData *ShaderData) *ShaderPass {
+	// Collect output IDs read by each pass
+	readBy := make(map[string][]int) // output id -> indices of passes reading it
+	for i, pass := range shaderData.Passes {
+		for _, input := range pass.Inputs {
+			if input.ID != "" {
+				readBy[input.ID] = append(readBy[input.ID], i)
+			}
+		}
 	}
 
-	// Fix common shader issues: initialize uninitialized variables
-	shaderCode := fixShaderCode(mainPass.Code)
+	var candidate *ShaderPass
+	for i := range shaderData.Passes {
+		pass := &shaderData.Passes[i]
+		if !strings.Contains(pass.Code, "mainImage") {
+			continue
+		}
 
-	// Debug: output processed shader code if debug mode is enabled
-	if DEBUG_MODE {
-		log.Printf("Processed shader code length: %d bytes", len(shaderCode))
-		log.Printf("\n=== PROCESSED SHADER CODE (after removing comments and initializing variables) ===\n%s\n=== END OF PROCESSED SHADER CODE ===\n", shaderCode)
+		isRead := false
+		for _, output := range pass.Outputs {
+			if len(readBy[output.ID]) > 0 {
+				isRead = true // read by others or by itself (feedback buffer)
+				break
+			}
+		}
+		if isRead {
+			continue
+		}
+
+		// Prefer the last match: image pass normally comes after buffers
+		candidate = pass
 	}
+	return candidate
+}
 
-	// Base vertex shader for fullscreen quad rendering.
-	vertexShader := `#version 330 core
-layout(location = 0) in vec2 aPos;
-layout(location = 1) in vec2 aTexCoord;
-out vec2 fragCoord;
+// processShaderCode runs repair pipeline on pass code of shader with metadata meta (may be nil)
+func processShaderCode(code string, meta *ShaderMetadata, cfg *Config) string {
+	// Line-based heuristics below expect "\n" line endings (see shader_file.go)
+	code = normalizeLineEndings(code)
 
-void main() {
-    fragCoord = aTexCoord;
-    gl_Position = vec4(aPos * 2.0 - 1.0, 0.0, 1.0);
-}` + "\x00"
+	// Fix common shader issues: initialize uninitialized variables
+	if !cfg.NoFix {
+		code = fixShaderCode(code, shaderSkipFixes(meta, cfg))
+	} else {
+		// Formatting steps below expect comment-free code
+		code = removeComments(code)
+	}
 
-	// Fragment shader from shader JSON.
-	// The shader entrypoint uses mainImage(out vec4 fragColor, in vec2 fragCoord)
-	// where fragCoord is pixel coordinates in screen space [0...iResolution.xy]
-	fragmentShaderTemplate := `#version 330 core
-in vec2 fragCoord;
-out vec4 fragColor;
+	// Not a heuristic: extra outputs never compile with the template
+	code = reconcileOutputs(code)
+
+	// Collapse redundant whitespace/blank lines (keeps line structure)
+	if cfg.MinifyShader {
+		code = minifyShaderCode(code)
+	}
+	return code
+}
 
-uniform vec3 iResolution;
+// shaderUniformDeclarations declares the uniforms set by shaderUniforms.upload
+// (shared by the image pass and custom vertex shaders)
+const shaderUniformDeclarations = `uniform vec3 iResolution;
 uniform float iTime;
 uniform float iTimeDelta;
 uniform int iFrame;
@@ -1157,13 +1359,80 @@ uniform sampler2D iChannel1;
 uniform sampler2D iChannel2;
 uniform sampler2D iChannel3;
 uniform float iFade;
+uniform vec2 iPixelSize; // Non-Shadertoy: 1.0 / iResolution.xy, for SDF antialiasing
+uniform float iScale;    // Non-Shadertoy: feature size multiplier from settings (1.0 = as authored)
+uniform int iFragCoordMode;    // Non-Shadertoy: 0 = pixel coordinates, 1 = aspect-corrected square
+uniform vec2 iFragCoordOffset; // Square mode: bottom-left corner of the centered square in pixels
+uniform int iFragCoordFlip;    // Non-Shadertoy: 1 = fragCoord.y measured from the top (-flip-coord)
+uniform int iDither;           // Non-Shadertoy: 0 = off, 1 = during fades, 2 = always (-dither)
+uniform vec3 iPalette[4];      // Non-Shadertoy: color(t) = [0] + [1] * sin([3] + [2] * t) (-palette)
+uniform vec2 iVignette;        // Non-Shadertoy: edge darkening strength (0 = off), start radius (-vignette)
+uniform float iBrightness;     // Non-Shadertoy: final color multiplier from settings (-brightness)
+`
+
+// passShaderCode returns repaired code of pass, with a single mainImage definition
+func passShaderCode(shaderData *ShaderData, pass *ShaderPass, cfg *Config) string {
+	shaderCode := processShaderCode(pass.Code, shaderData.Metadata, cfg)
+
+	// Two bodies never compile (see shader_main_image.go)
+	shaderCode, dropped := removeDuplicateMainImage(shaderCode)
+	if len(dropped) > 0 {
+		log.Printf("Warning: shader defines mainImage %d times; using the last definition, dropped the ones at processed code lines %v",
+			len(dropped)+1, dropped)
+	}
+
+	// Debug: output processed shader code if debug mode is enabled
+	if DEBUG_MODE {
+		log.Printf("Processed shader code length: %d bytes", len(shaderCode))
+		log.Printf("\n=== PROCESSED SHADER CODE (after removing comments, initializing variables and minifying) ===\n%s\n=== END OF PROCESSED SHADER CODE ===\n", shaderCode)
+	}
+	return shaderCode
+}
+
+// getMainShaderCode extracts main shader code from parsed shader data
+// Returns vertex and fragment shader code
+func getMainShaderCode(shaderData *ShaderData, cfg *Config) (string, string, error) {
+	mainPass := selectImagePass(shaderData)
+	shaderCode := passShaderCode(shaderData, mainPass, cfg)
+	if locations := outputLocations(shaderCode); len(locations) > 0 {
+		log.Printf("Warning: image pass writes render targets %v; only fragColor is displayed, the others are discarded", locations)
+	}
+
+	// Fullscreen quad, or the pass's own vertex stage (see shader_vertex.go)
+	vertexShader := vertexShaderCode(shaderData, mainPass)
 
+	// Fragment shader from shader JSON.
+	// The shader entrypoint uses mainImage(out vec4 fragColor, in vec2 fragCoord)
+	// where fragCoord is pixel coordinates in screen space [0...iResolution.xy];
+	// the call follows its actual signature (see shader_main_image.go)
+	fragmentShaderTemplate := `#version 330 core
+in vec2 fragCoord;
+layout(location = 0) out vec4 fragColor;
+
+` + shaderUniformDeclarations + shaderParamDeclarations(shaderParams(shaderData), shaderCode) + `
 ` + shaderCode + `
 
 void main() {
     vec2 fragCoordScreen = fragCoord * iResolution.xy;
-    mainImage(fragColor, fragCoordScreen);
-    fragColor.rgb *= iFade;
+    if (iFragCoordMode == 1) {
+        // iResolution is the centered square; the long axis extends past [0, iResolution]
+        fragCoordScreen = gl_FragCoord.xy - iFragCoordOffset;
+    }
+    if (iFragCoordFlip == 1) {
+        fragCoordScreen.y = iResolution.y - fragCoordScreen.y;
+    }
+    ` + mainImageCallStatement(shaderCode, "fragColor", "fragCoordScreen") + `
+    fragColor.rgb *= iFade * iBrightness;
+    if (iVignette.x > 0.0) {
+        // Distance from center: 0 in the middle, 1 in the corners
+        float edge = length(fragCoord - 0.5) * 1.41421356;
+        fragColor.rgb *= 1.0 - iVignette.x * smoothstep(iVignette.y, 1.0, edge);
+    }
+    if (iDither == 2 || (iDither == 1 && iFade > 0.0 && iFade < 1.0)) {
+        // Interleaved gradient noise, +-0.5 of an 8-bit step: breaks up bands of dimmed gradients
+        float noise = fract(52.9829189 * fract(dot(gl_FragCoord.xy, vec2(0.06711056, 0.00583715))));
+        fragColor.rgb = max(fragColor.rgb + (noise - 0.5) / 255.0, 0.0);
+    }
 }` + "\x00"
 
 	// Remove comments from wrapper before compilation
@@ -1246,14 +1515,21 @@ func (r *styledButtonRenderer) Objects() []fyne.CanvasObject {
 
 func (r *styledButtonRenderer) Destroy() {}
 
+// parseWindowHandle parses parent window handle passed after /p
+// Windows passes decimal HWND; X11 hosts may pass hex window id ("0x1a00003")
+func parseWindowHandle(s string) (uint64, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return strconv.ParseUint(s[2:], 16, 64)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
 // detectScreensaverMode determines operation mode from command line arguments
 // Windows screensaver arguments:
 //   - /s or no arguments = screensaver mode (fullscreen)
 //   - /c = configuration mode
-//   - /p <HWND> = preview mode
-func detectScreensaverMode() (ScreensaverMode, uintptr) {
-	args := os.Args[1:]
-
+//   - /p <HWND> = preview mode (on Linux/X11 <HWND> is the parent X11 window id)
+func detectScreensaverMode(args []string) (ScreensaverMode, uintptr) {
 	if len(args) == 0 {
 		return ModeScreensaver, 0
 	}
@@ -1273,12 +1549,12 @@ func detectScreensaverMode() (ScreensaverMode, uintptr) {
 			if strings.HasPrefix(argLower, "/p:") {
 				// Extract HWND from /p:12345 format
 				hwndStr := argLower[3:] // Skip "/p:"
-				if parsedHWND, err := strconv.ParseUint(hwndStr, 10, 64); err == nil {
+				if parsedHWND, err := parseWindowHandle(hwndStr); err == nil {
 					hwnd = uintptr(parsedHWND)
 				}
 			} else if i+1 < len(args) {
 				// Extract HWND from next argument /p 12345
-				if parsedHWND, err := strconv.ParseUint(args[i+1], 10, 64); err == nil {
+				if parsedHWND, err := parseWindowHandle(args[i+1]); err == nil {
 					hwnd = uintptr(parsedHWND)
 				}
 			}
@@ -1393,6 +1669,12 @@ func runConfigMode() {
 	emailText.TextSize = float32(ABOUT_TEXT_FONT_SIZE)
 	emailLabel := container.NewCenter(emailText)
 
+	// Version footer (smaller, same color as title)
+	versionText := canvas.NewText("Version "+versionString(), aboutTextColor)
+	versionText.Alignment = fyne.TextAlignCenter
+	versionText.TextSize = float32(ABOUT_TEXT_FONT_SIZE - 2)
+	versionLabel := container.NewCenter(versionText)
+
 	// Button to open website (use standard OS design)
 	visitButton := widget.NewButton(VISIT_WEBSITE_BUTTON_TEXT, func() {
 		// Open URL in browser using platform-specific function
@@ -1401,15 +1683,30 @@ func runConfigMode() {
 		}
 	})
 
+	// Button to open settings window
+	settingsButton := widget.NewButton(SETTINGS_BUTTON_TEXT, func() {
+		showSettingsWindow(myApp, appIconResource)
+	})
+	buttonsRow := container.NewHBox(visitButton, settingsButton)
+
+	// Link from shader metadata, confirmed before opening (see metadata_url.go)
+	if shaderURL := configuredShaderURL(); shaderURL != "" {
+		shaderButton := widget.NewButton(SHADER_PAGE_BUTTON_TEXT, func() {
+			confirmOpenMetadataURL(configWindow, shaderURL)
+		})
+		buttonsRow.Add(shaderButton)
+	}
+
 	// Use custom layout for precise position control
-	// Structure: 15px padding, title, 15px, logo, 15px, copyright, 5px, website, 5px, email, 15px, button, 15px padding
+	// Structure: 15px padding, title, 15px, logo, 15px, copyright, 5px, website, 5px, email, 5px, version, 15px, buttons, 15px padding
 	allElements := []fyne.CanvasObject{
 		aboutLabel,
 		logoImage,
 		copyrightLabel,
 		websiteLabel,
 		emailLabel,
-		visitButton,
+		versionLabel,
+		buttonsRow,
 	}
 
 	// Use equal spacing: topPadding and spacing between title and logo should be equal
@@ -1441,18 +1738,15 @@ func runConfigMode() {
 }
 
 // runPreviewMode starts preview mode
-func runPreviewMode(parentHWND uintptr) {
+func runPreviewMode(parentHWND uintptr, cfg *Config) {
 	// For preview create small window with OpenGL
 	if err := glfw.Init(); err != nil {
-		log.Fatalln("Error initializing GLFW:", err)
+		fatal(EXIT_GL, "Error initializing GLFW:", err)
 	}
 	defer glfw.Terminate()
 
 	glfw.WindowHint(glfw.Resizable, glfw.False)
-	glfw.WindowHint(glfw.ContextVersionMajor, 3)
-	glfw.WindowHint(glfw.ContextVersionMinor, 3)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	setGLContextHints()
 
 	// Build window title with command line arguments in debug mode
 	windowTitle := SCREENSAVER_NAME
@@ -1472,7 +1766,7 @@ func runPreviewMode(parentHWND uintptr) {
 	previewWidth, previewHeight := 320, 240 // Default preview size
 
 	// If parent HWND is provided, create window invisible to prevent flickering
-	if parentHWND != 0 && runtime.GOOS == "windows" {
+	if parentHWND != 0 && previewEmbeddingSupported {
 		// Create window invisible - it will be shown after embedding
 		glfw.WindowHint(glfw.Visible, glfw.False)
 	}
@@ -1480,11 +1774,11 @@ func runPreviewMode(parentHWND uintptr) {
 	// Create window (invisible if parentHWND is provided)
 	window, err := glfw.CreateWindow(previewWidth, previewHeight, windowTitle, nil, nil)
 	if err != nil {
-		log.Fatalln("Error creating preview window:", err)
+		fatal(EXIT_GL, "Error creating preview window:", err)
 	}
 
 	// If parent HWND is provided, ensure window is hidden and embed it
-	if parentHWND != 0 && runtime.GOOS == "windows" {
+	if parentHWND != 0 && previewEmbeddingSupported {
 		// Double-check: hide window immediately via Win32 API (hint might not be enough)
 		// This ensures window is hidden even if GLFW hint didn't work
 		hideWindow(window, windowTitle)
@@ -1494,13 +1788,27 @@ func runPreviewMode(parentHWND uintptr) {
 		time.Sleep(5 * time.Millisecond)
 		// Embed the window (it will be shown automatically after embedding)
 		previewWidth, previewHeight = embedWindowIntoParent(window, parentHWND, windowTitle)
+		if cfg.Wallpaper {
+			enableInputPassthrough(window, windowTitle)
+			if err := listenForWallpaperStop(func() { window.SetShouldClose(true) }); err != nil {
+				log.Printf("Wallpaper stop signal unavailable: %v", err)
+			}
+		}
 	}
 
 	window.MakeContextCurrent()
 
+	// Vertical sync (Settings -> Advanced, -vsync)
+	if cfg.VSync {
+		glfw.SwapInterval(1)
+	} else {
+		glfw.SwapInterval(0)
+	}
+
 	if err := gl.Init(); err != nil {
-		log.Fatalln("Error initializing OpenGL:", err)
+		fatal(EXIT_GL, "Error initializing OpenGL:", err)
 	}
+	logGLContext()
 
 	// Disable depth test for fullscreen quad
 	gl.Disable(gl.DEPTH_TEST)
@@ -1510,14 +1818,14 @@ func runPreviewMode(parentHWND uintptr) {
 
 	// Load shader from file
 	var program uint32
-	shaderData, err := loadEmbeddedShader()
+	shaderData, err := loadShader(cfg)
 	if err != nil {
-		log.Fatalf("Error loading shader: %v", err)
+		fatalf(EXIT_SHADER, "Error loading shader: %v", err)
 	}
 
-	vertexShader, fragmentShader, err := getMainShaderCode(shaderData)
+	vertexShader, fragmentShader, err := getMainShaderCode(shaderData, cfg)
 	if err != nil {
-		log.Fatalf("Error extracting shader code: %v", err)
+		fatalf(EXIT_SHADER, "Error extracting shader code: %v", err)
 	}
 
 	// Debug: output shader information
@@ -1532,154 +1840,114 @@ func runPreviewMode(parentHWND uintptr) {
 		}
 	}
 
-	program = newProgram(vertexShader, fragmentShader)
+	program = newShaderProgram(vertexShader, fragmentShader, cfg)
+
+	// Bind generated textures (noise) to channels the shader samples
+	channelTextures := setupChannelTextures(program, selectImagePass(shaderData))
+	buffers := setupPassChain(shaderData, cfg)
+	defer buffers.delete()
 
 	// Get shader uniform variable locations
-	iResolutionLoc := gl.GetUniformLocation(program, gl.Str("iResolution\x00"))
-	iTimeLoc := gl.GetUniformLocation(program, gl.Str("iTime\x00"))
-	iTimeDeltaLoc := gl.GetUniformLocation(program, gl.Str("iTimeDelta\x00"))
-	iFrameLoc := gl.GetUniformLocation(program, gl.Str("iFrame\x00"))
-	iFrameRateLoc := gl.GetUniformLocation(program, gl.Str("iFrameRate\x00"))
-	iMouseLoc := gl.GetUniformLocation(program, gl.Str("iMouse\x00"))
-	iDateLoc := gl.GetUniformLocation(program, gl.Str("iDate\x00"))
-	iSampleRateLoc := gl.GetUniformLocation(program, gl.Str("iSampleRate\x00"))
-	iChannelResolutionLoc := gl.GetUniformLocation(program, gl.Str("iChannelResolution\x00"))
-	iChannelTimeLoc := gl.GetUniformLocation(program, gl.Str("iChannelTime\x00"))
-	iFadeLoc := gl.GetUniformLocation(program, gl.Str("iFade\x00"))
-
-	// Debug: check for main uniforms
-	if DEBUG_MODE {
-		log.Printf("Uniform locations: iResolution=%d, iTime=%d, iTimeDelta=%d, iFrame=%d",
-			iResolutionLoc, iTimeLoc, iTimeDeltaLoc, iFrameLoc)
-		if iResolutionLoc < 0 {
-			log.Println("WARNING: iResolution uniform not found in shader!")
-		}
-		if iTimeLoc < 0 {
-			log.Println("WARNING: iTime uniform not found in shader!")
-		}
-	}
+	uniforms := getShaderUniforms(program)
+	uniforms.params = getParamUniforms(program, shaderData, cfg)
 
-	// Flag to signal graceful exit (show black screen before closing)
-	shouldExit := false
-	var exitStartTime time.Time
+	// Offscreen target for -render-size (nil = render at screen resolution)
+	initialWidth, initialHeight := window.GetFramebufferSize()
+	target := setupRenderTarget(cfg, initialWidth, initialHeight)
+	if target != nil {
+		defer target.delete()
+	}
 
+	// Timing, fade and exit state (see frame_state.go)
+	var state FrameState
+	state.noFade = cfg.NoFade
+	state.fixedStep = cfg.FixedStep
+	if cfg.SnapTime {
+		state.snapPeriod = refreshPeriod(primaryRefreshRate())
+	}
+	var redraw bool
+	installDeviceCallbacks(window, &redraw)
 	startTime := time.Now()
-	lastTime := startTime
-	frameCount := 0
+	state.begin(startTime, cfg.shaderTimeOrigin(startTime))
 
 	for !window.ShouldClose() {
-		currentTime := time.Now()
-		elapsed := currentTime.Sub(startTime).Seconds()
-		deltaTime := currentTime.Sub(lastTime).Seconds()
-		lastTime = currentTime
-		frameCount++
-
-		// Calculate fade value: fade-in over 1 second, fade-out over 0.5 seconds
-		var fadeValue float32 = 1.0
-		if elapsed < 1.0 {
-			// Fade-in: 0 to 1 over 1 second
-			fadeValue = float32(elapsed)
-		} else if shouldExit {
-			// Fade-out: 1 to 0 over 0.5 seconds
-			if exitStartTime.IsZero() {
-				exitStartTime = currentTime
-			}
-			exitElapsed := currentTime.Sub(exitStartTime).Seconds()
-			if exitElapsed < 0.5 {
-				fadeValue = float32(1.0 - exitElapsed/0.5)
-			} else {
-				fadeValue = 0.0
-			}
-		}
+		limitFrameRate(state.last, cfg.MaxFPS, &redraw)
+		redraw = false
+		steps := state.advance(time.Now(), nil)
 
 		// Use framebuffer size instead of window size for correct viewport
 		fbWidth, fbHeight := window.GetFramebufferSize()
-		width, height := window.GetSize()
+		if !drawableSize(fbWidth, fbHeight) {
+			// Transient zero size (resize, mode switch): skip the frame
+			glfw.WaitEventsTimeout(DEGENERATE_SIZE_WAIT)
+			if state.fadeOutDone() {
+				break
+			}
+			continue
+		}
 
-		// Set viewport based on framebuffer size
-		gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+		// Render to offscreen target if enabled, otherwise straight to window framebuffer
+		renderWidth, renderHeight := fbWidth, fbHeight
+		if target != nil {
+			if !cfg.hasRenderSize() {
+				// Render scale follows framebuffer size
+				target.resize(scaledSize(fbWidth, fbHeight, cfg.RenderScale))
+			}
+			target.bind()
+			renderWidth, renderHeight = target.width, target.height
+		} else {
+			// Set viewport based on framebuffer size
+			gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+		}
 
 		gl.ClearColor(0.0, 0.0, 0.0, 1.0)
 		gl.Clear(gl.COLOR_BUFFER_BIT)
 
-		gl.UseProgram(program)
+		// Set shader uniforms, after the buffer passes they read (see multipass.go)
+		state.width, state.height = renderWidth, renderHeight
+		frame := frameUniforms{
+			mouse:    noMouse,
+			scale:    cfg.Scale,
+			timeWrap: cfg.shaderTimeWrap(),
+			speed:    cfg.Speed,
+			channels: channelTextures,
 
-		// Set shader uniforms
-		if iResolutionLoc >= 0 {
-			// iResolution: .xy = viewport size, .z = aspect ratio (width/height)
-			// Use framebuffer size for correct resolution
-			aspectRatio := float32(fbWidth) / float32(fbHeight)
-			gl.Uniform3f(iResolutionLoc, float32(fbWidth), float32(fbHeight), aspectRatio)
-			if DEBUG_MODE && frameCount == 1 {
-				log.Printf("Setting iResolution to: %.0f x %.0f (aspect: %.3f)", float32(width), float32(height), aspectRatio)
-			}
-		}
-		if iTimeLoc >= 0 {
-			gl.Uniform1f(iTimeLoc, float32(elapsed))
-			if DEBUG_MODE && frameCount == 1 {
-				log.Printf("Setting iTime to: %.2f", float32(elapsed))
-			}
-		}
-		if iTimeDeltaLoc >= 0 {
-			gl.Uniform1f(iTimeDeltaLoc, float32(deltaTime))
-		}
-		if iFrameLoc >= 0 {
-			gl.Uniform1i(iFrameLoc, int32(frameCount))
-		}
-		if iFrameRateLoc >= 0 {
-			// Calculate FPS for iFrameRate
-			currentFPS := float32(1.0 / deltaTime)
-			if deltaTime <= 0 {
-				currentFPS = 60.0 // fallback
-			}
-			gl.Uniform1f(iFrameRateLoc, currentFPS)
-		}
-		// Mock mouse (no input in screensaver)
-		// iMouse.xy = current position, iMouse.zw = click position (should be < 0 if not pressed)
-		if iMouseLoc >= 0 {
-			gl.Uniform4f(iMouseLoc, 0.0, 0.0, -1.0, -1.0) // x, y, click x, click y (not pressed)
-		}
-		// Mock date
-		if iDateLoc >= 0 {
-			now := time.Now()
-			gl.Uniform4f(iDateLoc, float32(now.Year()), float32(now.Month()), float32(now.Day()), float32(elapsed))
-		}
-		if iSampleRateLoc >= 0 {
-			gl.Uniform1f(iSampleRateLoc, 44100.0) // Standard sample rate
-		}
-		// Mock channel resolution and time
-		if iChannelResolutionLoc >= 0 {
-			resolutions := []float32{float32(width), float32(height), 0.0, float32(width), float32(height), 0.0, float32(width), float32(height), 0.0, float32(width), float32(height), 0.0}
-			gl.Uniform3fv(iChannelResolutionLoc, 4, &resolutions[0])
-		}
-		if iChannelTimeLoc >= 0 {
-			times := []float32{float32(elapsed), float32(elapsed), float32(elapsed), float32(elapsed)}
-			gl.Uniform1fv(iChannelTimeLoc, 4, &times[0])
-		}
-		// Set fade uniform for smooth fade-in/fade-out
-		if iFadeLoc >= 0 {
-			gl.Uniform1f(iFadeLoc, fadeValue)
+			squareCoords: cfg.FragCoordMode == FRAGCOORD_SQUARE,
+			flipCoords:   cfg.FlipCoord,
+
+			dither:  cfg.Dither,
+			palette: cfg.Palette,
+			date:    cfg.dateAt(state.elapsed),
+
+			vignette:       cfg.Vignette,
+			vignetteRadius: cfg.VignetteRadius,
+			brightness:     cfg.Brightness,
 		}
+		frame.channels = buffers.renderSteps(&state, steps, frame, quad)
+		gl.UseProgram(program)
+		uniforms.upload(&state, frame)
 
 		// Draw fullscreen quad
+		advanceChannelTextures(frame.channels, uniforms.last.channelTime)
+		bindChannelTextures(frame.channels)
 		gl.BindVertexArray(quad.vao)
 		gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, gl.PtrOffset(0))
+		checkGLError("shader draw")
+		if target != nil {
+			target.blitToScreen(fbWidth, fbHeight, cfg.Letterbox)
+		}
 
 		window.SwapBuffers()
 		glfw.PollEvents()
 
 		// Exit loop if fade-out is complete
-		if shouldExit && !exitStartTime.IsZero() {
-			exitElapsed := currentTime.Sub(exitStartTime).Seconds()
-			if exitElapsed >= 0.5 {
-				// Fade-out complete, exit loop
-				break
-			}
+		if state.fadeOutDone() {
+			break
 		}
 	}
 
 	// Graceful exit: show black screen before closing
-	if shouldExit {
+	if state.exiting() {
 		// Get framebuffer size for viewport
 		fbWidth, fbHeight := window.GetFramebufferSize()
 
@@ -1709,19 +1977,31 @@ type FullscreenQuad struct {
 
 // ShaderInput represents one input channel/texture in shader JSON.
 type ShaderInput struct {
+	ID      string         `json:"id"`
+	Channel int            `json:"channel"`
+	Src     string         `json:"src,omitempty"`
+	Type    string         `json:"type,omitempty"`
+	Sampler *ShaderSampler `json:"sampler,omitempty"`
+}
+
+// ShaderOutput represents render target written by a shader pass.
+// Inputs of other passes reference it by ID.
+type ShaderOutput struct {
 	ID      string `json:"id"`
 	Channel int    `json:"channel"`
-	Src     string `json:"src,omitempty"`
-	Type    string `json:"type,omitempty"`
 }
 
 // ShaderPass represents one shader pass.
 type ShaderPass struct {
-	Index  int              `json:"index,omitempty"`
-	Code   string           `json:"code"`
-	Inputs []ShaderInput `json:"inputs,omitempty"`
-	Type   string           `json:"type,omitempty"`
-	Name   string           `json:"name,omitempty"`
+	Index   int            `json:"index,omitempty"`
+	Code    string         `json:"code"`
+	Inputs  []ShaderInput  `json:"inputs,omitempty"`
+	Outputs []ShaderOutput `json:"outputs,omitempty"`
+	Type    string         `json:"type,omitempty"`
+	Name    string         `json:"name,omitempty"`
+
+	// VertexCode replaces the fullscreen quad vertex shader (see shader_vertex.go)
+	VertexCode string `json:"vertex_code,omitempty"`
 }
 
 // ShaderData represents shader JSON file structure.
@@ -1740,7 +2020,12 @@ type ShaderMetadata struct {
 	ShaderID    string `json:"shader_id,omitempty"`
 	Title       string `json:"title,omitempty"`
 	Description string `json:"description,omitempty"`
+	Author      string `json:"author,omitempty"`
 	NumPasses   int    `json:"num_passes,omitempty"`
+	// Params are tweakable uniforms with settings dialog controls (see params.go)
+	Params []ShaderParam `json:"params,omitempty"`
+	// SkipFixes names repair passes known to break this shader (see shader_fixes.go)
+	SkipFixes []string `json:"skip_fixes,omitempty"`
 }
 
 // ShaderPerformance represents performance metrics in shader JSON.
@@ -1767,14 +2052,24 @@ const textFragmentShaderSource = `
 in vec2 TexCoord;
 out vec4 FragColor;
 uniform sampler2D textTexture;
-uniform vec3 textColor;
+uniform vec4 textColor;
 
 void main() {
     vec4 sampled = vec4(1.0, 1.0, 1.0, texture(textTexture, TexCoord).r);
-    FragColor = vec4(textColor, 1.0) * sampled;
+    FragColor = textColor * sampled;
 }` + "\x00"
 
+// compileShader compiles shader source, exits on failure
 func compileShader(source string, shaderType uint32) uint32 {
+	shader, err := tryCompileShader(source, shaderType)
+	if err != nil {
+		fatal(EXIT_SHADER, "Failed to compile shader")
+	}
+	return shader
+}
+
+// tryCompileShader compiles shader source, returns error with GPU log on failure
+func tryCompileShader(source string, shaderType uint32) (uint32, error) {
 	shader := gl.CreateShader(shaderType)
 	csources, free := gl.Strs(source)
 	gl.ShaderSource(shader, 1, csources, nil)
@@ -1794,18 +2089,12 @@ func compileShader(source string, shaderType uint32) uint32 {
 		}
 		errorLog := string(logBytes)
 		log.Printf("Error compiling %s shader:\n%s", shaderTypeStr, errorLog)
-		if DEBUG_MODE {
-			// Output full shader source code for debugging
-			log.Printf("Full shader source code:\n%s", source)
-			// Try to extract line number from error message
-			if strings.Contains(errorLog, ":") {
-				// Error messages often contain line numbers like "ERROR: 0:123: ..."
-				log.Printf("Check the line number in the error message above")
-			}
-		}
-		log.Fatalln("Failed to compile shader")
+		// Output source lines around reported errors (see shader_errors.go)
+		logShaderSource(source, errorLog)
+		gl.DeleteShader(shader)
+		return 0, fmt.Errorf("error compiling %s shader: %s", shaderTypeStr, strings.TrimRight(errorLog, "\x00\n"))
 	}
-	return shader
+	return shader, nil
 }
 
 // createFullscreenQuad creates fullscreen quad for fragment shader rendering.
@@ -1836,6 +2125,7 @@ func createFullscreenQuad() *FullscreenQuad {
 
 	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
 	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+	checkGLError("quad BufferData")
 
 	// Position (location 0)
 	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 4*4, gl.PtrOffset(0))
@@ -1853,14 +2143,40 @@ func createFullscreenQuad() *FullscreenQuad {
 	}
 }
 
+// newProgram compiles and links shader program, exits on failure
 func newProgram(vertexSrc, fragmentSrc string) uint32 {
-	vertexShader := compileShader(vertexSrc, gl.VERTEX_SHADER)
-	fragmentShader := compileShader(fragmentSrc, gl.FRAGMENT_SHADER)
+	program, err := buildProgram(vertexSrc, fragmentSrc)
+	if err != nil {
+		fatal(EXIT_SHADER, err)
+	}
+	return program
+}
+
+// buildProgram compiles and links shader program, returns error on failure
+func buildProgram(vertexSrc, fragmentSrc string) (uint32, error) {
+	return buildProgramWith(vertexSrc, fragmentSrc, nil)
+}
+
+// buildProgramWith is buildProgram that calls beforeLink (if set) to set program parameters before linking
+func buildProgramWith(vertexSrc, fragmentSrc string, beforeLink func(program uint32)) (uint32, error) {
+	vertexShader, err := tryCompileShader(vertexSrc, gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, err
+	}
+	fragmentShader, err := tryCompileShader(fragmentSrc, gl.FRAGMENT_SHADER)
+	if err != nil {
+		gl.DeleteShader(vertexShader)
+		return 0, err
+	}
 
 	program := gl.CreateProgram()
 	gl.AttachShader(program, vertexShader)
 	gl.AttachShader(program, fragmentShader)
+	if beforeLink != nil {
+		beforeLink(program)
+	}
 	gl.LinkProgram(program)
+	checkGLError("LinkProgram")
 
 	var status int32
 	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
@@ -1869,12 +2185,15 @@ func newProgram(vertexSrc, fragmentSrc string) uint32 {
 		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
 		logBytes := make([]byte, logLength)
 		gl.GetProgramInfoLog(program, logLength, nil, &logBytes[0])
-		log.Fatalln("Error linking shader program:", string(logBytes))
+		gl.DeleteProgram(program)
+		gl.DeleteShader(vertexShader)
+		gl.DeleteShader(fragmentShader)
+		return 0, fmt.Errorf("error linking shader program: %s", strings.TrimRight(string(logBytes), "\x00\n"))
 	}
 
 	gl.DeleteShader(vertexShader)
 	gl.DeleteShader(fragmentShader)
-	return program
+	return program, nil
 }
 
 type TextRenderer struct {
@@ -1884,12 +2203,43 @@ type TextRenderer struct {
 	texture    uint32
 	projection int32
 	textColor  int32
+	color      [4]float32 // RGBA text color, see SetColor
+	uploaded   string     // Lines currently in texture (see RenderLines)
+	sdf        *sdfText   // SDF glyph path (see text_sdf.go), nil for plain text only
 	width      int
 	height     int
 }
 
-func newTextRenderer(window *glfw.Window) *TextRenderer {
-	tr := &TextRenderer{}
+// TEXT_IMAGE_WIDTH/HEIGHT is the size of the text texture; longer text is truncated with TEXT_ELLIPSIS
+const (
+	TEXT_IMAGE_WIDTH  = 512
+	TEXT_IMAGE_HEIGHT = 64
+	TEXT_ELLIPSIS     = "..."
+)
+
+// fitText truncates text with ellipsis so it fits into the text texture
+func fitText(text string) string {
+	face := basicfont.Face7x13
+	if font.MeasureString(face, text).Ceil() <= TEXT_IMAGE_WIDTH {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := string(runes) + TEXT_ELLIPSIS
+		if font.MeasureString(face, candidate).Ceil() <= TEXT_IMAGE_WIDTH {
+			return candidate
+		}
+	}
+	return TEXT_ELLIPSIS
+}
+
+// newTextRenderer creates text renderer for window; sdf enables SDF glyphs (see text_sdf.go)
+func newTextRenderer(window *glfw.Window, sdf bool) *TextRenderer {
+	tr := &TextRenderer{color: [4]float32{1.0, 1.0, 1.0, 1.0}}
+	if sdf {
+		tr.sdf = newSDFText()
+	}
 
 	// Create shader program for text
 	tr.program = newProgram(textVertexShaderSource, textFragmentShaderSource)
@@ -1904,6 +2254,7 @@ func newTextRenderer(window *glfw.Window) *TextRenderer {
 	gl.BindVertexArray(vao)
 	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
 	gl.BufferData(gl.ARRAY_BUFFER, 6*4*4, nil, gl.DYNAMIC_DRAW)
+	checkGLError("text BufferData")
 
 	gl.EnableVertexAttribArray(0)
 	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 4*4, gl.PtrOffset(0))
@@ -1935,46 +2286,86 @@ func newTextRenderer(window *glfw.Window) *TextRenderer {
 	return tr
 }
 
+// SetColor sets color (and alpha) used by following Render calls
+func (tr *TextRenderer) SetColor(r, g, b, a float32) {
+	tr.color = [4]float32{r, g, b, a}
+}
+
+// TextWidth returns rendered width of text in pixels at given scale
+func (tr *TextRenderer) TextWidth(text string, scale float32) float32 {
+	width := font.MeasureString(basicfont.Face7x13, fitText(text)).Ceil()
+	return float32(width) * scale
+}
+
+// Render draws single line of text with top-left corner at x, y
 func (tr *TextRenderer) Render(text string, x, y float32, scale float32) {
+	tr.RenderLines([]string{text}, x, y, 0, scale)
+}
+
+// RenderLines draws lines lineHeight pixels apart (left-aligned at x, first line at y)
+// with one texture upload and one draw call. The texture is reused while lines don't change.
+func (tr *TextRenderer) RenderLines(lines []string, x, y, lineHeight, scale float32) {
+	// Blank lines draw nothing: skip rasterizing and uploading the texture
+	blank := true
+	fitted := make([]string, len(lines))
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			blank = false
+			fitted[i] = fitText(line)
+		}
+	}
+	if blank {
+		return
+	}
+	spacing := 0
+	if len(lines) > 1 && scale > 0 {
+		spacing = int(lineHeight/scale + 0.5)
+	}
+	if tr.sdf != nil && tr.sdf.atlas.covers(fitted) {
+		tr.renderSDF(fitted, x, y, spacing, scale)
+		return
+	}
+	imageHeight := TEXT_IMAGE_HEIGHT + spacing*(len(lines)-1)
+
 	// Disable depth testing for text so it's always visible on top
 	gl.Disable(gl.DEPTH_TEST)
 	gl.Enable(gl.BLEND)
 	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 
-	// Create image with text
-	img := image.NewRGBA(image.Rect(0, 0, 512, 64))
-	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 0}), image.Point{}, draw.Src)
+	key := fmt.Sprintf("%d\n%s", spacing, strings.Join(fitted, "\n"))
+	gl.BindTexture(gl.TEXTURE_2D, tr.texture)
+	if key != tr.uploaded {
+		// Create image with text
+		img := image.NewRGBA(image.Rect(0, 0, TEXT_IMAGE_WIDTH, imageHeight))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 0}), image.Point{}, draw.Src)
+
+		// Draw text
+		// Y position: basicfont.Face7x13 has Ascent of about 13 pixels
+		// Use 13 * 64 (fixed point) so text is in upper part of image
+		for i, line := range fitted {
+			d := &font.Drawer{
+				Dst:  img,
+				Src:  image.NewUniform(color.RGBA{255, 255, 255, 255}),
+				Face: basicfont.Face7x13,
+				Dot:  fixed.Point26_6{X: fixed.Int26_6(0), Y: fixed.I(13 + i*spacing)},
+			}
+			d.DrawString(line)
+		}
 
-	// Draw text
-	// Y position: basicfont.Face7x13 has Ascent of about 13 pixels
-	// Use 13 * 64 (fixed point) so text is in upper part of image
-	d := &font.Drawer{
-		Dst:  img,
-		Src:  image.NewUniform(color.RGBA{255, 255, 255, 255}),
-		Face: basicfont.Face7x13,
-		Dot:  fixed.Point26_6{X: fixed.Int26_6(0), Y: fixed.Int26_6(13 * 64)},
+		// Load texture
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RED, int32(img.Bounds().Dx()), int32(img.Bounds().Dy()), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+		checkGLError("text TexImage2D")
+		tr.uploaded = key
 	}
-	d.DrawString(text)
 
-	// Load texture
-	gl.BindTexture(gl.TEXTURE_2D, tr.texture)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RED, int32(img.Bounds().Dx()), int32(img.Bounds().Dy()), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	w := float32(TEXT_IMAGE_WIDTH) * scale
+	h := float32(imageHeight) * scale
 
-	w := float32(img.Bounds().Dx()) * scale
-	h := float32(img.Bounds().Dy()) * scale
-
-	// Set orthographic projection
-	// Invert Y so (0,0) is at top-left corner
-	projection := []float32{
-		2.0 / float32(tr.width), 0, 0, 0,
-		0, -2.0 / float32(tr.height), 0, 0, // minus for Y inversion
-		0, 0, -1, 0,
-		-1, 1, 0, 1, // offset: -1 on X, 1 on Y (instead of -1, -1)
-	}
+	projection := tr.projectionMatrix()
 
 	gl.UseProgram(tr.program)
 	gl.UniformMatrix4fv(tr.projection, 1, false, &projection[0])
-	gl.Uniform3f(tr.textColor, 1.0, 1.0, 1.0) // White text color
+	gl.Uniform4f(tr.textColor, tr.color[0], tr.color[1], tr.color[2], tr.color[3])
 
 	gl.BindVertexArray(tr.vao)
 
@@ -1996,6 +2387,7 @@ func (tr *TextRenderer) Render(text string, x, y float32, scale float32) {
 	gl.BindTexture(gl.TEXTURE_2D, tr.texture)
 
 	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	checkGLError("text draw")
 
 	gl.BindVertexArray(0)
 	gl.BindTexture(gl.TEXTURE_2D, 0)
@@ -2004,19 +2396,50 @@ func (tr *TextRenderer) Render(text string, x, y float32, scale float32) {
 	// gl.Enable(gl.DEPTH_TEST) - removed, as main shader doesn't use depth test
 }
 
-// runScreensaverMode starts fullscreen screensaver
-func runScreensaverMode() {
+// projectionMatrix returns orthographic projection of window pixels
+// with Y inverted so (0,0) is at top-left corner
+func (tr *TextRenderer) projectionMatrix() []float32 {
+	return []float32{
+		2.0 / float32(tr.width), 0, 0, 0,
+		0, -2.0 / float32(tr.height), 0, 0, // minus for Y inversion
+		0, 0, -1, 0,
+		-1, 1, 0, 1, // offset: -1 on X, 1 on Y (instead of -1, -1)
+	}
+}
+
+// limitFrameRate waits so that frames start no more often than maxFPS per second
+// lastFrameStart is the start time of the previous frame; maxFPS <= 0 disables the cap.
+// Events are processed while waiting; a window refresh (redraw set) ends the wait early.
+func limitFrameRate(lastFrameStart time.Time, maxFPS int, redraw *bool) {
+	if maxFPS <= 0 {
+		return
+	}
+	frameDuration := time.Second / time.Duration(maxFPS)
+	for !*redraw {
+		wait := frameDuration - time.Since(lastFrameStart)
+		if wait <= 0 {
+			return
+		}
+		glfw.WaitEventsTimeout(wait.Seconds())
+	}
+}
+
+// runScreensaverMode starts fullscreen screensaver; reload delivers reload requests.
+// Returns *firstFrameError if the first frame failed (see render_retry.go).
+func runScreensaverMode(cfg *Config, reload *reloadTrigger) error {
 	if err := glfw.Init(); err != nil {
-		log.Fatalln("Error initializing GLFW:", err)
+		fatal(EXIT_GL, "Error initializing GLFW:", err)
 	}
 	defer glfw.Terminate()
+	// Requests wake the render loop until GLFW terminates
+	defer reload.attach()()
 
 	glfw.WindowHint(glfw.Resizable, glfw.False)
-	glfw.WindowHint(glfw.ContextVersionMajor, 3)
-	glfw.WindowHint(glfw.ContextVersionMinor, 3)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
-	glfw.WindowHint(glfw.Samples, 4) // Enable multisampling with 4 samples for antialiasing
+	setGLContextHints()
+	// Offscreen render target is blitted to screen, which needs single-sampled window framebuffer
+	if cfg.Multisample && !cfg.usesRenderTarget() {
+		glfw.WindowHint(glfw.Samples, 4) // Enable multisampling with 4 samples for antialiasing
+	}
 
 	var window *glfw.Window
 	var err error
@@ -2033,59 +2456,136 @@ func runScreensaverMode() {
 		}
 	}
 
+	// Connected displays (see monitors.go)
+	var monitors monitorManager
 	if FULLSCREEN_MODE {
 		// Get primary monitor for fullscreen mode
-		monitor := glfw.GetPrimaryMonitor()
-		mode := monitor.GetVideoMode()
-		window, err = glfw.CreateWindow(mode.Width, mode.Height, windowTitle, monitor, nil)
+		monitors.refresh()
+		primary, ok := monitors.primary()
+		if !ok {
+			fatalf(EXIT_GL, "Error creating window: no display connected")
+		}
+		window, err = glfw.CreateWindow(primary.width, primary.height, windowTitle, lookupMonitor(primary.displayKey), nil)
 	} else {
 		// Windowed mode
 		window, err = glfw.CreateWindow(800, 600, windowTitle, nil, nil)
 	}
 
 	if err != nil {
-		log.Fatalln("Error creating window:", err)
+		fatal(EXIT_GL, "Error creating window:", err)
 	}
 	window.MakeContextCurrent()
 
-	// Flag to signal graceful exit (show black screen before closing)
-	shouldExit := false
-	var exitStartTime time.Time
+	// Vertical sync (Settings -> Advanced, -vsync)
+	if cfg.VSync {
+		glfw.SwapInterval(1)
+	} else {
+		glfw.SwapInterval(0)
+	}
+
+	// Timing, fade and exit state; input callbacks request graceful exit
+	// (show black screen before closing)
+	var state FrameState
+	state.noFade = cfg.NoFade
+	state.fixedStep = cfg.FixedStep
+	if cfg.SnapTime {
+		state.snapPeriod = refreshPeriod(primaryRefreshRate())
+	}
+	var redraw bool
+	installDeviceCallbacks(window, &redraw)
+	if FULLSCREEN_MODE {
+		monitors.install()
+	}
 
 	// Set handlers to exit program on any key or mouse button press
-	if EXIT_ON_KEY_PRESS {
+	// (interactive mode exits on Esc only, mouse drives iMouse)
+	var mouse mouseInput
+	var pause pauseState
+	var effects postEffects
+	var dumpRequested bool // U: dump uniforms of the next frame
+	if cfg.Interactive {
 		window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
-			if action == glfw.Press {
-				shouldExit = true
-				if exitStartTime.IsZero() {
-					exitStartTime = time.Now()
+			if action == glfw.Repeat {
+				// Holding [ or ] keeps seeking
+				pause.seekKey(key, mods, cfg.SeekStep)
+				return
+			}
+			if action != glfw.Press {
+				return
+			}
+			if pause.seekKey(key, mods, cfg.SeekStep) {
+				return
+			}
+			switch key {
+			case glfw.KeyEscape:
+				state.requestExit(time.Now())
+			case glfw.KeySpace:
+				pause.toggle(time.Now())
+			case glfw.KeyU:
+				dumpRequested = true
+			default:
+				// Post effects stay off in safe mode
+				if !cfg.SafeMode && effects.toggleKey(key) && DEBUG_MODE {
+					log.Printf("Post effects toggled: %s", effects)
 				}
 			}
 		})
+		installMouseCallbacks(window, &mouse)
 	}
 
-	if EXIT_ON_MOUSE_CLICK {
+	if EXIT_ON_KEY_PRESS && !cfg.Interactive {
+		window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+			if action == glfw.Press {
+				state.requestExit(time.Now())
+			}
+		})
+	}
+
+	if EXIT_ON_MOUSE_CLICK && !cfg.Interactive {
 		window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
 			if action == glfw.Press {
-				shouldExit = true
-				if exitStartTime.IsZero() {
-					exitStartTime = time.Now()
-				}
+				state.requestExit(time.Now())
+			}
+		})
+	}
+
+	if cfg.ExitOnMove && !cfg.Interactive {
+		movement := movementExit{threshold: float64(cfg.MoveThreshold), start: time.Now()}
+		window.SetCursorPosCallback(func(w *glfw.Window, xpos, ypos float64) {
+			if movement.moved(xpos, ypos, time.Now()) {
+				state.requestExit(time.Now())
 			}
 		})
 	}
 
 	// Hide mouse cursor if needed
-	if HIDE_MOUSE_CURSOR {
+	if cfg.HideCursor && !cfg.Interactive {
 		window.SetInputMode(glfw.CursorMode, glfw.CursorHidden)
+		// Show it again on every exit path (after the fade-out, before the window closes):
+		// some window managers keep it hidden until the pointer moves otherwise
+		defer window.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
 	}
 
 	if err := gl.Init(); err != nil {
-		log.Fatalln("Error initializing OpenGL:", err)
+		fatal(EXIT_GL, "Error initializing OpenGL:", err)
 	}
+	logGLContext()
 
 	// Enable multisampling for antialiasing
-	gl.Enable(gl.MULTISAMPLE)
+	// Offscreen render target is blitted to screen, which needs single-sampled window framebuffer
+	if cfg.Multisample && !cfg.usesRenderTarget() {
+		gl.Enable(gl.MULTISAMPLE)
+	}
+
+	// Black screen before anything loads the GPU (see start_delay.go); retries start at once
+	if cfg.StartDelay > 0 {
+		delay := time.Duration(cfg.StartDelay) * time.Millisecond
+		cfg.StartDelay = 0
+		if !waitStartDelay(window, &state, delay) {
+			log.Printf("Exit requested during start delay")
+			return nil
+		}
+	}
 
 	// Disable depth test for fullscreen quad
 	gl.Disable(gl.DEPTH_TEST)
@@ -2095,14 +2595,14 @@ func runScreensaverMode() {
 
 	// Load shader from file
 	var program uint32
-	shaderData, err := loadEmbeddedShader()
+	shaderData, err := loadShader(cfg)
 	if err != nil {
-		log.Fatalf("Error loading shader: %v", err)
+		fatalf(EXIT_SHADER, "Error loading shader: %v", err)
 	}
 
-	vertexShader, fragmentShader, err := getMainShaderCode(shaderData)
+	vertexShader, fragmentShader, err := getMainShaderCode(shaderData, cfg)
 	if err != nil {
-		log.Fatalf("Error extracting shader code: %v", err)
+		fatalf(EXIT_SHADER, "Error extracting shader code: %v", err)
 	}
 
 	// Debug: output shader information
@@ -2117,229 +2617,394 @@ func runScreensaverMode() {
 		}
 	}
 
-	program = newProgram(vertexShader, fragmentShader)
+	program = newShaderProgram(vertexShader, fragmentShader, cfg)
+
+	// Bind generated textures (noise) to channels the shader samples
+	channelTextures := setupChannelTextures(program, selectImagePass(shaderData))
+	buffers := setupPassChain(shaderData, cfg)
+	defer func() { buffers.delete() }()
 
 	// Get shader uniform variable locations
-	iResolutionLoc := gl.GetUniformLocation(program, gl.Str("iResolution\x00"))
-	iTimeLoc := gl.GetUniformLocation(program, gl.Str("iTime\x00"))
-	iTimeDeltaLoc := gl.GetUniformLocation(program, gl.Str("iTimeDelta\x00"))
-	iFrameLoc := gl.GetUniformLocation(program, gl.Str("iFrame\x00"))
-	iFrameRateLoc := gl.GetUniformLocation(program, gl.Str("iFrameRate\x00"))
-	iMouseLoc := gl.GetUniformLocation(program, gl.Str("iMouse\x00"))
-	iDateLoc := gl.GetUniformLocation(program, gl.Str("iDate\x00"))
-	iSampleRateLoc := gl.GetUniformLocation(program, gl.Str("iSampleRate\x00"))
-	iChannelResolutionLoc := gl.GetUniformLocation(program, gl.Str("iChannelResolution\x00"))
-	iChannelTimeLoc := gl.GetUniformLocation(program, gl.Str("iChannelTime\x00"))
-	iFadeLoc := gl.GetUniformLocation(program, gl.Str("iFade\x00"))
-
-	// Debug: check for main uniforms
-	if DEBUG_MODE {
-		log.Printf("Uniform locations: iResolution=%d, iTime=%d, iTimeDelta=%d, iFrame=%d",
-			iResolutionLoc, iTimeLoc, iTimeDeltaLoc, iFrameLoc)
-		if iResolutionLoc < 0 {
-			log.Println("WARNING: iResolution uniform not found in shader!")
-		}
-		if iTimeLoc < 0 {
-			log.Println("WARNING: iTime uniform not found in shader!")
-		}
+	uniforms := getShaderUniforms(program)
+	uniforms.params = getParamUniforms(program, shaderData, cfg)
+
+	// Offscreen target for -render-size (nil = render at screen resolution)
+	initialWidth, initialHeight := window.GetFramebufferSize()
+	target := setupRenderTarget(cfg, initialWidth, initialHeight)
+	if target != nil {
+		defer target.delete()
 	}
 
+	// Post effects pass (resources exist only while an effect is on)
+	var post postProcessor
+	defer post.delete()
+
 	// Create text renderer
-	textRenderer := newTextRenderer(window)
+	textRenderer := newTextRenderer(window, !cfg.SimpleText)
+	var watermark []string
+	if cfg.ShowWatermark {
+		watermark = watermarkLines(shaderData.Metadata)
+	}
+	if cfg.Clock.Enabled && DEBUG_MODE {
+		log.Printf("Clock overlay: %s", cfg.Clock)
+	}
 
-	// Variables for FPS
 	startTime := time.Now()
-	lastTime := time.Now()
-	frameCount := 0
-	fpsUpdateTime := lastTime
-	fps := 0.0
+	state.begin(startTime, cfg.shaderTimeOrigin(startTime))
 
-	// Variables for average frame time over last 5 seconds
-	type frameTimeEntry struct {
-		time  time.Time
-		delta float64
-	}
-	frameTimes := make([]frameTimeEntry, 0)
-	const frameTimeWindow = 5 * time.Second
+	// Render times for average over last RENDER_TIME_WINDOW (see frame_state.go)
+	var frameTimes renderTimes
+	firstFrame := true
+	freeze := newFreezeCycle(cfg)
+	schedule := newShaderSchedule(cfg.ShaderRate)
+	exitShot := newExitScreenshot(cfg)
+	defer exitShot.wait()
 
 	for !window.ShouldClose() {
-		currentTime := time.Now()
-		deltaTime := currentTime.Sub(lastTime).Seconds()
-		lastTime = currentTime
-
-		// Update FPS every second
-		frameCount++
-		if currentTime.Sub(fpsUpdateTime) >= time.Second {
-			fps = float64(frameCount) / currentTime.Sub(fpsUpdateTime).Seconds()
-			frameCount = 0
-			fpsUpdateTime = currentTime
-		}
-
-		elapsed := currentTime.Sub(startTime).Seconds()
-
-		// Calculate fade value: fade-in over 1 second, fade-out over 0.5 seconds
-		var fadeValue float32 = 1.0
-		if elapsed < 1.0 {
-			// Fade-in: 0 to 1 over 1 second
-			fadeValue = float32(elapsed)
-		} else if shouldExit {
-			// Fade-out: 1 to 0 over 0.5 seconds
-			if exitStartTime.IsZero() {
-				exitStartTime = currentTime
+		if freeze.enabled() && !state.exiting() {
+			// Power saving: iTime pauses while frozen (see freeze.go)
+			frozen, remaining := freeze.frozenAt(time.Since(startTime).Seconds())
+			if frozen != pause.paused {
+				pause.toggle(time.Now())
+				if DEBUG_MODE {
+					log.Printf("Animation frozen: %v", frozen)
+				}
 			}
-			exitElapsed := currentTime.Sub(exitStartTime).Seconds()
-			if exitElapsed < 0.5 {
-				fadeValue = float32(1.0 - exitElapsed/0.5)
+			if frozen {
+				// Keep the last frame on screen; input, refresh and the end of the period wake up
+				glfw.WaitEventsTimeout(remaining)
+				if monitors.changed {
+					monitors.refitFullscreen(window)
+					redraw = true
+				}
+				if !redraw && !state.exiting() {
+					continue
+				}
+			}
+		}
+		limitFrameRate(state.last, cfg.MaxFPS, &redraw)
+		redraw = false
+		currentTime := time.Now()
+
+		select {
+		case <-reload.requests:
+			if err := reloadShader(cfg, &program, &shaderData, &channelTextures, &buffers, &uniforms); err != nil {
+				log.Printf("Shader reload failed, keeping current shader: %v", err)
 			} else {
-				fadeValue = 0.0
+				log.Printf("Shader reloaded")
 			}
+		default:
 		}
+
 		// Use framebuffer size instead of window size for correct viewport
 		fbWidth, fbHeight := window.GetFramebufferSize()
 		width, height := window.GetSize()
+		if !drawableSize(fbWidth, fbHeight) || !drawableSize(width, height) {
+			// Transient zero size (fullscreen/windowed transition, mode switch): skip the
+			// frame, but keep time and the fade-out going
+			state.advance(currentTime, &pause)
+			glfw.WaitEventsTimeout(DEGENERATE_SIZE_WAIT)
+			if monitors.changed {
+				monitors.refitFullscreen(window)
+			}
+			if state.fadeOutDone() {
+				break
+			}
+			continue
+		}
 
-		// Set viewport based on framebuffer size
-		gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+		// First frame after an exit request goes to -exit-screenshot (see exit_screenshot.go)
+		capturing := exitShot.due(&state)
 
-		gl.ClearColor(0.0, 0.0, 0.0, 1.0)
-		gl.Clear(gl.COLOR_BUFFER_BIT)
+		// Reduced shader rate: other frames present the last shader frame again (see shader_rate.go)
+		if target == nil || schedule.due(currentTime, fbWidth, fbHeight) {
+			steps := state.advance(currentTime, &pause)
+			if capturing {
+				// Brightness at the moment of exit; fade-out starts with the next frame
+				state.fade = state.exitFade
+			}
 
-		// Start render time measurement (shader execution time)
-		renderStartTime := time.Now()
+			// Render to offscreen target if enabled, otherwise straight to window framebuffer
+			renderWidth, renderHeight := fbWidth, fbHeight
+			if target != nil {
+				if !cfg.hasRenderSize() {
+					// Render scale follows framebuffer size
+					target.resize(scaledSize(fbWidth, fbHeight, cfg.RenderScale))
+				}
+				target.bind()
+				renderWidth, renderHeight = target.width, target.height
+			} else {
+				// Set viewport based on framebuffer size
+				gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+			}
 
-		gl.UseProgram(program)
+			// With post effects on, shader renders into post texture first
+			postActive := post.sync(&effects, renderWidth, renderHeight)
+			if postActive {
+				post.begin()
+			}
+
+			gl.ClearColor(0.0, 0.0, 0.0, 1.0)
+			gl.Clear(gl.COLOR_BUFFER_BIT)
 
-		// Set shader uniforms
-		if iResolutionLoc >= 0 {
-			// iResolution: .xy = viewport size, .z = aspect ratio (width/height)
-			// Use framebuffer size for correct resolution
-			aspectRatio := float32(fbWidth) / float32(fbHeight)
-			gl.Uniform3f(iResolutionLoc, float32(fbWidth), float32(fbHeight), aspectRatio)
-			if DEBUG_MODE && frameCount == 1 {
-				log.Printf("Setting iResolution to: %.0f x %.0f (aspect: %.3f)", float32(width), float32(height), aspectRatio)
+			// Start render time measurement (shader execution time)
+			renderStartTime := time.Now()
+
+			// iMouse in render resolution
+			mouseValue := mouse.uniform()
+			if target != nil {
+				mouseValue = target.mapMouse(mouseValue, fbWidth, fbHeight, cfg.Letterbox)
 			}
-		}
-		if iTimeLoc >= 0 {
-			gl.Uniform1f(iTimeLoc, float32(elapsed))
-			if DEBUG_MODE && frameCount == 1 {
-				log.Printf("Setting iTime to: %.2f", float32(elapsed))
+
+			// Set shader uniforms, after the buffer passes they read (see multipass.go)
+			state.width, state.height = renderWidth, renderHeight
+			frame := frameUniforms{
+				mouse:    mouseValue,
+				scale:    cfg.Scale,
+				timeWrap: cfg.shaderTimeWrap(),
+				speed:    cfg.Speed,
+				channels: channelTextures,
+
+				squareCoords: cfg.FragCoordMode == FRAGCOORD_SQUARE,
+				flipCoords:   cfg.FlipCoord,
+
+				dither:  cfg.Dither,
+				palette: cfg.Palette,
+				date:    cfg.dateAt(state.elapsed),
+
+				vignette:       cfg.Vignette,
+				vignetteRadius: cfg.VignetteRadius,
+				brightness:     cfg.Brightness,
 			}
-		}
-		if iTimeDeltaLoc >= 0 {
-			gl.Uniform1f(iTimeDeltaLoc, float32(deltaTime))
-		}
-		if iFrameLoc >= 0 {
-			gl.Uniform1i(iFrameLoc, int32(frameCount))
-		}
-		if iFrameRateLoc >= 0 {
-			// Calculate FPS for iFrameRate
-			currentFPS := float32(1.0 / deltaTime)
-			if deltaTime <= 0 {
-				currentFPS = 60.0 // fallback
+			frame.channels = buffers.renderSteps(&state, steps, frame, quad)
+			gl.UseProgram(program)
+			uniforms.upload(&state, frame)
+			if dumpRequested {
+				dumpRequested = false
+				if err := uniforms.dumpUniforms(cfg.DumpUniformsPath); err != nil {
+					log.Printf("Error dumping uniforms: %v", err)
+				}
 			}
-			gl.Uniform1f(iFrameRateLoc, currentFPS)
-		}
-		// Mock mouse (no input in screensaver)
-		// iMouse.xy = current position, iMouse.zw = click position (should be < 0 if not pressed)
-		if iMouseLoc >= 0 {
-			gl.Uniform4f(iMouseLoc, 0.0, 0.0, -1.0, -1.0) // x, y, click x, click y (not pressed)
-		}
-		// Mock date
-		if iDateLoc >= 0 {
-			now := time.Now()
-			gl.Uniform4f(iDateLoc, float32(now.Year()), float32(now.Month()), float32(now.Day()), float32(elapsed))
-		}
-		if iSampleRateLoc >= 0 {
-			gl.Uniform1f(iSampleRateLoc, 44100.0) // Standard sample rate
-		}
-		// Mock channel resolution and time
-		if iChannelResolutionLoc >= 0 {
-			resolutions := []float32{float32(fbWidth), float32(fbHeight), 0.0, float32(fbWidth), float32(fbHeight), 0.0, float32(fbWidth), float32(fbHeight), 0.0, float32(fbWidth), float32(fbHeight), 0.0}
-			gl.Uniform3fv(iChannelResolutionLoc, 4, &resolutions[0])
-		}
-		if iChannelTimeLoc >= 0 {
-			times := []float32{float32(elapsed), float32(elapsed), float32(elapsed), float32(elapsed)}
-			gl.Uniform1fv(iChannelTimeLoc, 4, &times[0])
-		}
-		// Set fade uniform for smooth fade-in/fade-out
-		if iFadeLoc >= 0 {
-			gl.Uniform1f(iFadeLoc, fadeValue)
-		}
 
-		// Draw fullscreen quad
-		// Make sure program is still active before drawing
-		gl.UseProgram(program)
-		gl.BindVertexArray(quad.vao)
-		gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, gl.PtrOffset(0))
-
-		// Wait for all GPU commands to complete for accurate render time measurement
-		gl.Finish()
+			// Draw fullscreen quad
+			// Make sure program is still active before drawing
+			gl.UseProgram(program)
+			advanceChannelTextures(frame.channels, uniforms.last.channelTime)
+			bindChannelTextures(frame.channels)
+			if firstFrame {
+				// Only the draw itself decides a retry (setup errors like a uniform type mismatch are harmless)
+				checkGLError("frame setup")
+				pendingGLError()
+			}
+			gl.BindVertexArray(quad.vao)
+			gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, gl.PtrOffset(0))
+			if firstFrame {
+				// Wait for the draw, driver errors and resets show up here
+				gl.Finish()
+				if err := pendingGLError(); err != nil {
+					return &firstFrameError{err}
+				}
+				firstFrame = false
+			}
+			checkGLError("shader draw")
+			if postActive {
+				post.apply(quad, target, fbWidth, fbHeight)
+			}
 
-		// Finish render time measurement
-		renderEndTime := time.Now()
-		renderTime := renderEndTime.Sub(renderStartTime).Seconds()
+			// Wait for all GPU commands to complete for accurate render time measurement
+			gl.Finish()
 
-		// Add render time to history
-		frameTimes = append(frameTimes, frameTimeEntry{
-			time:  currentTime,
-			delta: renderTime,
-		})
+			// Finish render time measurement
+			renderEndTime := time.Now()
+			renderTime := renderEndTime.Sub(renderStartTime).Seconds()
 
-		// Remove entries older than 5 seconds
-		cutoffTime := currentTime.Add(-frameTimeWindow)
-		validStart := 0
-		for i, entry := range frameTimes {
-			if entry.time.After(cutoffTime) {
-				validStart = i
-				break
-			}
+			// Add render time to history (drops entries older than 5 seconds)
+			frameTimes.add(currentTime, renderTime)
 		}
-		if validStart > 0 {
-			frameTimes = frameTimes[validStart:]
+		if target != nil {
+			target.blitToScreen(fbWidth, fbHeight, cfg.Letterbox)
+		}
+		if capturing && !exitShot.overlay {
+			exitShot.capture(fbWidth, fbHeight)
+		}
+
+		// Message banner, under the debug overlay and fading together with the shader
+		if cfg.Banner.enabled() {
+			textRenderer.width = fbWidth
+			textRenderer.height = fbHeight
+			drawBanner(textRenderer, cfg.Banner, state.fade, float32(fbWidth)/float32(width))
 		}
 
 		// Display debug information if debug mode is enabled
 		if DEBUG_MODE {
-			// Calculate average frame time over last 5 seconds
-			avgFrameTime := 0.0
-			if len(frameTimes) > 0 {
-				sum := 0.0
-				for _, entry := range frameTimes {
-					sum += entry.delta
-				}
-				avgFrameTime = sum / float64(len(frameTimes)) * 1000.0 // in milliseconds
-			}
+			// Average frame time over last 5 seconds
+			avgFrameTime := frameTimes.average() * 1000.0 // in milliseconds
 			// Update size in TextRenderer for correct projection (use framebuffer size for projection)
 			textRenderer.width = fbWidth
 			textRenderer.height = fbHeight
 			// Render text (coordinates: x, y from top-left corner)
 			// Display window size, not framebuffer (window size is more important for user)
-			textRenderer.Render(fmt.Sprintf("Window: %dx%d, Framebuffer: %dx%d", width, height, fbWidth, fbHeight), 10, 2, 1.0)
-			textRenderer.Render(fmt.Sprintf("FPS: %.1f", fps), 10, 15, 1.0)
-			textRenderer.Render(fmt.Sprintf("Render Time: %.2f ms (avg 5s)", avgFrameTime), 10, 28, 1.0)
+			textRenderer.RenderLines([]string{
+				fmt.Sprintf("Window: %dx%d, Framebuffer: %dx%d", width, height, fbWidth, fbHeight),
+				fmt.Sprintf("FPS: %.1f", state.fps),
+				fmt.Sprintf("Render Time: %.2f ms (avg 5s)", avgFrameTime),
+			}, 10, 2, 13, 1.0)
+		}
+
+		// Shader credit watermark, fading together with the shader on exit
+		if len(watermark) > 0 && state.elapsed < cfg.WatermarkDuration {
+			textRenderer.width = fbWidth
+			textRenderer.height = fbHeight
+			alpha := watermarkAlpha(state.elapsed, cfg.WatermarkDuration) * state.fade
+			drawWatermark(textRenderer, watermark, cfg.WatermarkPosition, alpha, float32(fbWidth)/float32(width))
+		}
+
+		// Clock overlay, fading together with the shader
+		if cfg.Clock.Enabled {
+			textRenderer.width = fbWidth
+			textRenderer.height = fbHeight
+			drawClock(textRenderer, clockLines(time.Now(), cfg.Clock), cfg.Clock, state.fade, float32(fbWidth)/float32(width))
+		}
+		if capturing && exitShot.overlay {
+			exitShot.capture(fbWidth, fbHeight)
 		}
 
 		window.SwapBuffers()
 		glfw.PollEvents()
+		if monitors.changed {
+			monitors.refitFullscreen(window)
+		}
 
-		// Exit loop if fade-out is complete
-		if shouldExit && !exitStartTime.IsZero() {
-			exitElapsed := currentTime.Sub(exitStartTime).Seconds()
-			if exitElapsed >= 0.5 {
-				// Fade-out complete, exit loop
-				break
-			}
+		// Exit loop if fade-out is complete (-no-fade still draws the frame for -exit-screenshot)
+		if state.fadeOutDone() && !exitShot.due(&state) {
+			break
 		}
 	}
 
 	// Graceful exit: window is already black after fade-out, just close
-	if shouldExit {
+	// (without fade, show one black frame first so the desktop doesn't flash through)
+	if state.exiting() {
+		if state.noFade {
+			fbWidth, fbHeight := window.GetFramebufferSize()
+			gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+			gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+			gl.ClearColor(0.0, 0.0, 0.0, 1.0)
+			gl.Clear(gl.COLOR_BUFFER_BIT)
+			window.SwapBuffers()
+		}
 		window.SetShouldClose(true)
 		glfw.PollEvents()
 	}
+	return nil
 }
 
 func main() {
+	baseConfig := defaultConfig()
+	loadSettings().apply(&baseConfig)
+	cmdLine, err := parseCommandLine(os.Args[1:], baseConfig)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(EXIT_OK)
+		}
+		fatal(EXIT_USAGE, "Error parsing command line:", err)
+	}
+	cfg := &cmdLine.config
+
+	if cmdLine.showVersion {
+		fmt.Printf("%s %s\n", SCREENSAVER_NAME, versionString())
+		return
+	}
+
+	if cmdLine.printConfig {
+		if err := printConfig(os.Stdout, cfg, cmdLine.dumpFormat); err != nil {
+			fatal(EXIT_USAGE, "Error printing configuration:", err)
+		}
+		return
+	}
+
+	if DEBUG_MODE {
+		log.Printf("%s %s", SCREENSAVER_NAME, versionString())
+	}
+
+	if cmdLine.dumpShaderPath != "" {
+		shaderData, err := loadShader(cfg)
+		if err != nil {
+			fatalf(EXIT_SHADER, "Error loading shader: %v", err)
+		}
+		if err := dumpProcessedShader(shaderData, cfg, cmdLine.dumpShaderPath, cmdLine.dumpFormat); err != nil {
+			fatalf(EXIT_FAILURE, "Error dumping shader: %v", err)
+		}
+		return
+	}
+
+	if cmdLine.benchFix {
+		if err := runBenchFix(cfg, cmdLine.benchFixDir, cmdLine.benchRuns); err != nil {
+			fatalf(EXIT_FAILURE, "Error benchmarking shader repair: %v", err)
+		}
+		return
+	}
+
+	if cmdLine.exportGLSLPath != "" {
+		shaderData, err := loadShader(cfg)
+		if err != nil {
+			fatalf(EXIT_SHADER, "Error loading shader: %v", err)
+		}
+		if err := exportFragmentShader(shaderData, cfg, cmdLine.exportGLSLPath); err != nil {
+			fatalf(EXIT_FAILURE, "Error exporting shader: %v", err)
+		}
+		return
+	}
+
+	dumpFullShader = cmdLine.dumpFullShader
+
+	if cmdLine.softwareGL && requestSoftwareRendering() {
+		softwareRendering = true
+		log.Printf("Software rendering active (slow, for diagnostics only)")
+	}
+
+	if cmdLine.checkShader {
+		if err := runCheckShader(cfg); err != nil {
+			fatalf(exitCode(err, EXIT_SHADER), "Shader check failed: %v", err)
+		}
+		return
+	}
+
+	if cmdLine.selfTest {
+		if err := runSelfTest(cfg); err != nil {
+			os.Exit(exitCode(err, EXIT_FAILURE))
+		}
+		return
+	}
+
+	if cmdLine.frameDumpDir != "" {
+		if err := runFrameDump(cfg, cmdLine.frameDumpDir, cmdLine.frameDumpCount); err != nil {
+			fatalf(exitCode(err, EXIT_FAILURE), "Error dumping frames: %v", err)
+		}
+		return
+	}
+
+	if cmdLine.streamPath != "" {
+		if err := runStream(cfg, cmdLine.streamPath, cmdLine.streamFormat, cmdLine.streamFPS); err != nil {
+			fatalf(exitCode(err, EXIT_FAILURE), "Error streaming frames: %v", err)
+		}
+		return
+	}
+
+	if cmdLine.wallpaperStop {
+		if err := stopWallpaper(); err != nil {
+			fatal(EXIT_FAILURE, "Error stopping wallpaper:", err)
+		}
+		return
+	}
+
+	if cmdLine.resetSafeMode {
+		if err := resetSafeMode(); err != nil {
+			fatal(EXIT_FAILURE, "Error resetting safe mode:", err)
+		}
+		log.Println("Safe mode reset")
+		return
+	}
+
 	// If forced settings mode is enabled, start configuration dialog
 	if FORCE_SETTINGS_MODE {
 		runConfigMode()
@@ -2347,19 +3012,58 @@ func main() {
 	}
 
 	// Determine screensaver operation mode from command line arguments
-	mode, parentHWND := detectScreensaverMode()
+	mode, parentHWND := detectScreensaverMode(cmdLine.screensaverArgs)
+
+	// Locked session: exit on any input back to the lock screen, start nothing else
+	secureDesktop := isSecureDesktop()
+	if secureDesktop {
+		log.Printf("Running on secure desktop: interactive mode and settings dialog disabled")
+		cfg.Interactive = false
+	}
+
+	// Remote Desktop: GL is usually emulated, a full-rate shader shows a black or stuttering screen
+	if isRemoteSession() && !cmdLine.ignoreRemote {
+		cfg.applyRemoteSession()
+		log.Printf("Running in a Remote Desktop session: rendering at %.0f%% resolution and %d FPS (-ignore-remote to disable)",
+			cfg.RenderScale*100, cfg.MaxFPS)
+	}
+
+	if cfg.Wallpaper {
+		if err := runWallpaperMode(cfg); err != nil {
+			fatal(EXIT_FAILURE, "Error starting wallpaper mode:", err)
+		}
+		return
+	}
 
 	switch mode {
 	case ModeConfig:
 		// Configuration mode - show dialog
+		// (its links and file pickers would open behind the lock screen)
+		if secureDesktop {
+			return
+		}
 		runConfigMode()
 	case ModePreview:
 		// Preview mode - small window
-		runPreviewMode(parentHWND)
+		// Preview doesn't write crash marker (control panel kills preview processes),
+		// but honors safe mode set by a crashed fullscreen run
+		if isSafeModeActive() {
+			cfg.applySafeMode()
+		}
+		runPreviewMode(parentHWND, cfg)
 	case ModeScreensaver:
 		fallthrough
 	default:
 		// Screensaver mode - fullscreen mode
-		runScreensaverMode()
+		if beginRunBookkeeping() {
+			cfg.applySafeMode()
+		}
+		// SIGHUP (named event on Windows) reloads the shader (see shader_reload.go);
+		// one listener for all attempts
+		err := runScreensaverWithRetry(cfg, newReloadTrigger())
+		endRunBookkeeping(err == nil)
+		if err != nil {
+			os.Exit(EXIT_GL)
+		}
 	}
 }
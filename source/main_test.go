@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestSelectImagePass(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want int // index into passes
+	}{
+		{"buffers first, no markers", `{"passes":[
+			{"code":"void mainImage(out vec4 c, in vec2 p) { c = texture(iChannel0, p); }","inputs":[{"id":"b","channel":0}],"outputs":[{"id":"a","channel":0}]},
+			{"code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(1.0); }","outputs":[{"id":"b","channel":0}]},
+			{"code":"void mainImage(out vec4 c, in vec2 p) { c = texture(iChannel0, p); }","inputs":[{"id":"a","channel":0}]}]}`, 2},
+		{"image first, no markers", `{"passes":[
+			{"code":"void mainImage(out vec4 c, in vec2 p) { c = texture(iChannel0, p); }","inputs":[{"id":"a","channel":0}]},
+			{"code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(1.0); }","outputs":[{"id":"a","channel":0}]}]}`, 0},
+		{"feedback buffer after image", `{"passes":[
+			{"code":"void mainImage(out vec4 c, in vec2 p) { c = texture(iChannel0, p); }","inputs":[{"id":"a","channel":0}]},
+			{"code":"void mainImage(out vec4 c, in vec2 p) { c = texture(iChannel0, p) * 0.9; }","inputs":[{"id":"a","channel":0}],"outputs":[{"id":"a","channel":0}]}]}`, 0},
+		{"common code without mainImage", `{"passes":[
+			{"code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(f()); }"},
+			{"code":"float f() { return 1.0; }"}]}`, 0},
+		{"type marker wins", `{"passes":[
+			{"code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(1.0); }"},
+			{"type":"image","code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(0.0); }","outputs":[{"id":"a","channel":0}],"inputs":[{"id":"a","channel":0}]}]}`, 1},
+		{"nothing matches", `{"passes":[
+			{"code":"float f() { return 1.0; }","outputs":[{"id":"a","channel":0}]},
+			{"code":"float g() { return 1.0; }","inputs":[{"id":"a","channel":0}]}]}`, 0},
+	}
+	for _, tt := range tests {
+		data, err := parseShaderJSON([]byte(tt.json))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := selectImagePass(data); got != &data.Passes[tt.want] {
+			t.Errorf("%s: selected pass %q, want pass %d", tt.name, got.Code, tt.want)
+		}
+	}
+}
+
+func TestFitText(t *testing.T) {
+	short := "FPS: 60.0"
+	if got := fitText(short); got != short {
+		t.Errorf("short text changed to %q", got)
+	}
+	long := strings.Repeat("Window: 3840x2160, Framebuffer: 3840x2160 ", 4)
+	fitted := fitText(long)
+	if !strings.HasSuffix(fitted, TEXT_ELLIPSIS) {
+		t.Errorf("long text not truncated with ellipsis: %q", fitted)
+	}
+	if width := font.MeasureString(basicfont.Face7x13, fitted).Ceil(); width > TEXT_IMAGE_WIDTH {
+		t.Errorf("truncated text is %d px wide (limit %d)", width, TEXT_IMAGE_WIDTH)
+	}
+}
@@ -0,0 +1,70 @@
+// Shader page link in the About dialog.
+//
+// The `url` in shader metadata comes from whoever wrote the shader file, not
+// from us, so it is treated more strictly than WEBSITE_URL: only http(s) links
+// are offered (no mailto or other schemes), and clicking shows the full
+// encoded URL in a confirmation dialog before the browser is started.
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+const SHADER_PAGE_BUTTON_TEXT = "Shader page"
+
+// checkMetadataURL validates a metadata URL; only http(s) links are accepted
+func checkMetadataURL(raw string) (string, error) {
+	encoded, err := checkOpenURL(raw)
+	if err != nil {
+		return "", err
+	}
+	if u, _ := url.Parse(encoded); u == nil || (!strings.EqualFold(u.Scheme, "http") && !strings.EqualFold(u.Scheme, "https")) {
+		return "", fmt.Errorf("refusing to open %q: only http and https shader links are opened", raw)
+	}
+	return encoded, nil
+}
+
+// configuredShaderURL returns the checked metadata URL of the shader chosen in
+// settings ("" if it has none or can't be loaded)
+func configuredShaderURL() string {
+	settings := loadSettings()
+	var shaderData *ShaderData
+	var err error
+	if settings.Shader != "" {
+		shaderData, err = loadShaderFile(settings.Shader)
+	} else {
+		shaderData, err = loadEmbeddedShader()
+	}
+	if err != nil || shaderData.Metadata.URL == "" {
+		return ""
+	}
+	encoded, err := checkMetadataURL(shaderData.Metadata.URL)
+	if err != nil {
+		log.Printf("Warning: shader link not offered: %v", err)
+		return ""
+	}
+	return encoded
+}
+
+// confirmOpenMetadataURL asks before opening a metadata URL in the browser
+func confirmOpenMetadataURL(parent fyne.Window, raw string) {
+	encoded, err := checkMetadataURL(raw)
+	if err != nil {
+		log.Printf("Error opening URL: %v", err)
+		return
+	}
+	dialog.ShowConfirm("Open shader page?", "This link comes from the shader file:\n"+encoded, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := openURL(encoded); err != nil {
+			log.Printf("Error opening URL: %v", err)
+		}
+	}, parent)
+}
@@ -0,0 +1,135 @@
+// Displays connected or disconnected while the screensaver runs.
+//
+// When the monitor of a fullscreen window goes away (undocking a laptop,
+// switching a KVM), GLFW turns the window into a decorated window at its old
+// position, which may now be off screen or on a display it doesn't cover. The
+// fullscreen loop therefore puts the window back in fullscreen on the current
+// primary monitor after every display change. The window and its GL context
+// are kept, so shader, textures and iTime carry on; the render loop picks up
+// the new framebuffer size on the next frame.
+//
+// GLFW monitor handles are freed when their display is disconnected, so none
+// are kept across frames: monitorManager holds a snapshot of names, positions
+// and video modes, and handles are looked up again right before each use.
+// Identical monitors report the same name, so a display is identified by its
+// name together with its position on the desktop.
+package main
+
+import (
+	"log"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// displayKey identifies a connected display (name alone is shared by identical monitors)
+type displayKey struct {
+	name string
+	x, y int // Position on the virtual desktop
+}
+
+// monitorKey returns the key of a GLFW monitor
+func monitorKey(monitor *glfw.Monitor) displayKey {
+	x, y := monitor.GetPos()
+	return displayKey{monitor.GetName(), x, y}
+}
+
+// displayInfo is a snapshot of one connected display
+type displayInfo struct {
+	displayKey
+	width, height, refreshRate int
+}
+
+// monitorManager tracks connected displays without holding GLFW monitor handles
+type monitorManager struct {
+	displays []displayInfo // Primary first
+	changed  bool          // A display was connected or disconnected since the last refresh
+}
+
+// install makes the manager follow display hot-plug events
+func (m *monitorManager) install() {
+	glfw.SetMonitorCallback(func(monitor *glfw.Monitor, event glfw.PeripheralEvent) {
+		// The handle is still valid during the callback, not after it
+		m.handleEvent(monitorKey(monitor), event == glfw.Connected)
+	})
+}
+
+// handleEvent records a hot-plug event; a disconnected display is dropped at
+// once, so it can't be used before the next refresh
+func (m *monitorManager) handleEvent(key displayKey, connected bool) {
+	state := "disconnected"
+	if connected {
+		state = "connected"
+	}
+	log.Printf("Display %q at %d,%d %s", key.name, key.x, key.y, state)
+	m.changed = true
+	if connected {
+		return
+	}
+	var kept []displayInfo
+	for _, display := range m.displays {
+		if display.displayKey != key {
+			kept = append(kept, display)
+		}
+	}
+	m.displays = kept
+}
+
+// refresh takes a new snapshot of connected displays
+func (m *monitorManager) refresh() {
+	m.changed = false
+	m.displays = nil
+	for _, monitor := range glfw.GetMonitors() {
+		mode := monitor.GetVideoMode()
+		if mode == nil {
+			continue
+		}
+		m.displays = append(m.displays, displayInfo{monitorKey(monitor), mode.Width, mode.Height, mode.RefreshRate})
+	}
+}
+
+// primary returns the primary display of the snapshot (false without displays)
+func (m *monitorManager) primary() (displayInfo, bool) {
+	if len(m.displays) == 0 {
+		return displayInfo{}, false
+	}
+	return m.displays[0], true
+}
+
+// primaryRefreshRate returns the refresh rate of the primary display in Hz (0 if unknown)
+func primaryRefreshRate() int {
+	var m monitorManager
+	m.refresh()
+	primary, _ := m.primary()
+	return primary.refreshRate
+}
+
+// lookupMonitor returns the current GLFW handle of the display (nil if gone)
+func lookupMonitor(key displayKey) *glfw.Monitor {
+	for _, monitor := range glfw.GetMonitors() {
+		if monitorKey(monitor) == key {
+			return monitor
+		}
+	}
+	return nil
+}
+
+// refitFullscreen moves window to the primary monitor's full size unless it is
+// already fullscreen there (no-op without monitors)
+func (m *monitorManager) refitFullscreen(window *glfw.Window) {
+	m.refresh()
+	primary, ok := m.primary()
+	monitor := lookupMonitor(primary.displayKey)
+	if !ok || monitor == nil {
+		log.Printf("Warning: no display connected, keeping window as is")
+		return
+	}
+	if current := window.GetMonitor(); current != nil && monitorKey(current) == primary.displayKey {
+		if width, height := window.GetSize(); width == primary.width && height == primary.height {
+			return
+		}
+	}
+	window.SetMonitor(monitor, 0, 0, primary.width, primary.height, primary.refreshRate)
+	if DEBUG_MODE {
+		log.Printf("Fullscreen window moved to %q (%dx%d)", primary.name, primary.width, primary.height)
+	}
+}
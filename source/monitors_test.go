@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+// TestDisplayHotplug checks that disconnected displays leave the monitor snapshot at once,
+// and that of two identical monitors only the disconnected one does
+func TestDisplayHotplug(t *testing.T) {
+	left := displayKey{"DELL U2720Q", 0, 0}
+	right := displayKey{"DELL U2720Q", 3840, 0}
+	laptop := displayKey{"Laptop", 0, 2160}
+	monitors := monitorManager{displays: []displayInfo{{left, 3840, 2160, 60}, {right, 3840, 2160, 60}, {laptop, 1920, 1080, 60}}}
+	monitors.handleEvent(left, false)
+	if primary, ok := monitors.primary(); !monitors.changed || !ok || primary.displayKey != right {
+		t.Fatalf("after disconnect: primary %+v (%v), changed %v", primary.displayKey, ok, monitors.changed)
+	}
+	monitors.changed = false
+	monitors.handleEvent(displayKey{"Projector", -1024, 0}, true) // Not usable before the next refresh
+	monitors.handleEvent(right, false)
+	monitors.handleEvent(laptop, false)
+	if primary, ok := monitors.primary(); !monitors.changed || ok {
+		t.Errorf("all displays disconnected, still using %q", primary.name)
+	}
+}
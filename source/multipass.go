@@ -0,0 +1,369 @@
+// Multi-pass shaders: Buffer A-D passes rendered into textures.
+//
+// Shadertoy exports can carry buffer passes besides the image pass. A pass
+// input whose `id` names the output `id` of a buffer pass samples that
+// buffer. Each buffer the image pass reads (directly or through other
+// buffers) gets its own program and renders every frame, before the image
+// pass, into a float texture of the image pass's render size. Buffers render
+// after the buffers they read, so those reads see the current frame; a buffer
+// reading itself (feedback) sees its own previous frame, as each buffer has
+// two textures swapped after drawing. Buffers reading each other in a cycle
+// keep their file order, the later one is a frame behind. Buffers start
+// cleared to zero and are cleared again when the render size changes.
+//
+// A buffer pass declaring `layout(location = N) out` variables (see
+// shader_outputs.go) renders into a color attachment per location; an output
+// whose `channel` is N exposes attachment N (0 = fragColor) to its readers.
+// Reading an attachment the pass does not write gives a black channel.
+//
+// Buffer passes get the uniforms of the image pass, but not its fade,
+// vignette, dither and fragCoord modes: their output is state, not colors.
+// If a buffer pass fails to compile, the image pass renders without any
+// buffers (their channels stay black).
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// bufferPass is a compiled buffer pass with its two output textures
+type bufferPass struct {
+	name     string
+	program  uint32
+	uniforms shaderUniforms
+	channels [CHANNEL_COUNT]channelTexture // Static channel textures (placeholders where buffers are read)
+	sources  [CHANNEL_COUNT]bufferSource   // Buffer read by each channel (nil pass = none)
+	samplers [CHANNEL_COUNT]ShaderSampler
+	targets  [2]*renderTarget // [0] = latest frame, [1] = drawn next
+}
+
+// bufferSource is the attachment of a compiled buffer pass read by a channel
+type bufferSource struct {
+	pass       *bufferPass
+	attachment int
+}
+
+// passChain renders the buffer passes of a shader for its image pass
+type passChain struct {
+	buffers  []*bufferPass               // Render order
+	sources  [CHANNEL_COUNT]bufferSource // Buffer read by each image pass channel
+	samplers [CHANNEL_COUNT]ShaderSampler
+}
+
+// bufferOutput is a buffer pass output: pass index in shaderData.Passes (-1 = none)
+// and its color attachment
+type bufferOutput struct {
+	pass       int
+	attachment int
+}
+
+// isBufferPass reports whether pass renders into a buffer
+func isBufferPass(pass *ShaderPass) bool {
+	return strings.EqualFold(pass.Type, "buffer") || (pass.Type == "" && strings.HasPrefix(pass.Name, "Buf"))
+}
+
+// bufferOutputs maps output IDs of buffer passes (other than image) to the pass and attachment
+func bufferOutputs(shaderData *ShaderData, image *ShaderPass) map[string]bufferOutput {
+	outputs := make(map[string]bufferOutput)
+	for i := range shaderData.Passes {
+		pass := &shaderData.Passes[i]
+		if pass == image || !isBufferPass(pass) {
+			continue
+		}
+		for _, output := range pass.Outputs {
+			if output.ID != "" {
+				outputs[output.ID] = bufferOutput{pass: i, attachment: max(output.Channel, 0)}
+			}
+		}
+	}
+	return outputs
+}
+
+// passBufferSources returns the buffer output (pass -1 = none) read by each channel of pass
+func passBufferSources(pass *ShaderPass, outputs map[string]bufferOutput) [CHANNEL_COUNT]bufferOutput {
+	sources := [CHANNEL_COUNT]bufferOutput{{pass: -1}, {pass: -1}, {pass: -1}, {pass: -1}}
+	for _, input := range pass.Inputs {
+		if input.Channel < 0 || input.Channel >= CHANNEL_COUNT || input.ID == "" {
+			continue
+		}
+		if source, ok := outputs[input.ID]; ok {
+			sources[input.Channel] = source
+		}
+	}
+	return sources
+}
+
+// bufferRenderOrder returns indices of the buffer passes the image pass reads, each
+// after the buffers it reads; a cycle is broken at the earliest pass in the file
+func bufferRenderOrder(shaderData *ShaderData, image *ShaderPass) []int {
+	outputs := bufferOutputs(shaderData, image)
+	needed := make(map[int]bool)
+	var visit func(pass *ShaderPass)
+	visit = func(pass *ShaderPass) {
+		for _, source := range passBufferSources(pass, outputs) {
+			if source.pass >= 0 && !needed[source.pass] {
+				needed[source.pass] = true
+				visit(&shaderData.Passes[source.pass])
+			}
+		}
+	}
+	visit(image)
+
+	var order []int
+	done := make(map[int]bool)
+	for len(order) < len(needed) {
+		next, fallback := -1, -1
+		for i := range shaderData.Passes {
+			if !needed[i] || done[i] {
+				continue
+			}
+			if fallback < 0 {
+				fallback = i
+			}
+			ready := true
+			for _, source := range passBufferSources(&shaderData.Passes[i], outputs) {
+				if source.pass >= 0 && source.pass != i && !done[source.pass] {
+					ready = false
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			next = fallback
+		}
+		done[next] = true
+		order = append(order, next)
+	}
+	return order
+}
+
+// bufferFragmentShader wraps repaired buffer pass code; fragColor is stored as is
+func bufferFragmentShader(shaderData *ShaderData, code string) string {
+	return removeComments(`#version 330 core
+in vec2 fragCoord;
+layout(location = 0) out vec4 fragColor;
+
+`+shaderUniformDeclarations+shaderParamDeclarations(shaderParams(shaderData), code)+`
+`+code+`
+
+void main() {
+    `+mainImageCallStatement(code, "fragColor", "fragCoord * iResolution.xy")+`
+}`) + "\x00"
+}
+
+// newPassChain compiles the buffer passes read by the image pass (nil if there are none)
+func newPassChain(shaderData *ShaderData, cfg *Config) (*passChain, error) {
+	image := selectImagePass(shaderData)
+	order := bufferRenderOrder(shaderData, image)
+	if len(order) == 0 {
+		return nil, nil
+	}
+	outputs := bufferOutputs(shaderData, image)
+	c := &passChain{samplers: channelSamplers(image)}
+	compiled := make(map[int]*bufferPass)
+	for _, index := range order {
+		pass := &shaderData.Passes[index]
+		b, err := newBufferPass(shaderData, pass, cfg)
+		if err != nil {
+			c.delete()
+			return nil, fmt.Errorf("buffer pass %s: %v", b.name, err)
+		}
+		compiled[index] = b
+		c.buffers = append(c.buffers, b)
+	}
+	link := func(sources [CHANNEL_COUNT]bufferOutput) [CHANNEL_COUNT]bufferSource {
+		var result [CHANNEL_COUNT]bufferSource
+		for channel, source := range sources {
+			if source.pass < 0 {
+				continue
+			}
+			b := compiled[source.pass]
+			if source.attachment >= b.attachments() {
+				log.Printf("Warning: buffer pass %s has no render target %d; channel %d stays black", b.name, source.attachment, channel)
+				continue
+			}
+			result[channel] = bufferSource{pass: b, attachment: source.attachment}
+		}
+		return result
+	}
+	for i, index := range order {
+		c.buffers[i].sources = link(passBufferSources(&shaderData.Passes[index], outputs))
+	}
+	c.sources = link(passBufferSources(image, outputs))
+	if DEBUG_MODE {
+		var names []string
+		for _, b := range c.buffers {
+			names = append(names, b.name)
+		}
+		log.Printf("Buffer passes: %s", strings.Join(names, ", "))
+	}
+	return c, nil
+}
+
+// newBufferPass compiles pass and creates its (1x1 until the first frame) targets,
+// with an attachment up to the highest output location of the code.
+// The returned pass carries its name also on error.
+func newBufferPass(shaderData *ShaderData, pass *ShaderPass, cfg *Config) (*bufferPass, error) {
+	b := &bufferPass{name: pass.Name, samplers: channelSamplers(pass)}
+	if b.name == "" {
+		b.name = "#" + strconv.Itoa(pass.Index)
+	}
+	code := passShaderCode(shaderData, pass, cfg)
+	program, err := buildShaderProgram(vertexShaderCode(shaderData, pass), bufferFragmentShader(shaderData, code), cfg)
+	if err != nil {
+		return b, err
+	}
+	count := 1
+	if locations := outputLocations(code); len(locations) > 0 {
+		count = locations[len(locations)-1] + 1
+	}
+	for i := range b.targets {
+		target, err := newRenderTargetAttachments(1, 1, gl.LINEAR, gl.RGBA32F, count)
+		if err != nil {
+			gl.DeleteProgram(program)
+			b.delete()
+			return b, err
+		}
+		b.targets[i] = target
+		target.clear()
+	}
+	b.program = program
+	b.channels = setupChannelTextures(program, pass)
+	b.uniforms = getShaderUniforms(program)
+	b.uniforms.params = getParamUniforms(program, shaderData, cfg)
+	return b, nil
+}
+
+// attachments returns the number of color attachments the pass renders into
+func (b *bufferPass) attachments() int {
+	return len(b.targets[0].attachments) + 1
+}
+
+// clear sets all texels of the target (all attachments) to zero
+func (rt *renderTarget) clear() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, rt.fbo)
+	gl.ClearColor(0.0, 0.0, 0.0, 0.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// resize gives both targets the render size, clearing them when it changes
+func (b *bufferPass) resize(width, height int) {
+	for _, target := range b.targets {
+		if target.width != width || target.height != height {
+			target.resize(width, height)
+			target.clear()
+		}
+	}
+}
+
+// bufferChannels returns channels with the latest frame of the buffers in sources
+// bound instead, filtered and wrapped as samplers ask (buffers have no mipmaps)
+func bufferChannels(channels [CHANNEL_COUNT]channelTexture, sources [CHANNEL_COUNT]bufferSource, samplers [CHANNEL_COUNT]ShaderSampler) [CHANNEL_COUNT]channelTexture {
+	for channel, source := range sources {
+		if source.pass == nil {
+			continue
+		}
+		target := source.pass.targets[0]
+		texture := target.colorTexture(source.attachment)
+		minFilter, magFilter, wrap, mipmap := samplers[channel].glParams()
+		if mipmap {
+			minFilter = magFilter
+		}
+		gl.BindTexture(gl.TEXTURE_2D, texture)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, wrap)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, wrap)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, minFilter)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, magFilter)
+		channels[channel] = channelTexture{texture: texture, width: target.width, height: target.height}
+	}
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return channels
+}
+
+// render draws the buffer passes for frame s at its render size and returns the
+// image pass channels (f.channels) with the buffers it reads bound. The current
+// framebuffer and viewport are restored; nil-safe.
+func (c *passChain) render(s *FrameState, f frameUniforms, quad *FullscreenQuad) [CHANNEL_COUNT]channelTexture {
+	if c == nil {
+		return f.channels
+	}
+	var framebuffer int32
+	var viewport [4]int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &framebuffer)
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+
+	buffer := f
+	buffer.squareCoords, buffer.flipCoords = false, false
+	for _, b := range c.buffers {
+		b.resize(max(s.width, 1), max(s.height, 1))
+		b.targets[1].bind()
+		gl.UseProgram(b.program)
+		buffer.channels = bufferChannels(b.channels, b.sources, b.samplers)
+		b.uniforms.upload(s, buffer)
+		advanceChannelTextures(buffer.channels, b.uniforms.last.channelTime)
+		bindChannelTextures(buffer.channels)
+		gl.BindVertexArray(quad.vao)
+		gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, gl.PtrOffset(0))
+		b.targets[0], b.targets[1] = b.targets[1], b.targets[0]
+	}
+	checkGLError("buffer passes")
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(framebuffer))
+	gl.Viewport(viewport[0], viewport[1], viewport[2], viewport[3])
+	return bufferChannels(f.channels, c.sources, c.samplers)
+}
+
+// renderSteps is render for the steps of frame s (see FrameState.advance), each
+// step with its own iTime and iFrame; the latest FIXED_STEP_RENDERS_MAX at most.
+// Without steps the buffers keep their contents. Nil-safe.
+func (c *passChain) renderSteps(s *FrameState, steps int, f frameUniforms, quad *FullscreenQuad) [CHANNEL_COUNT]channelTexture {
+	if c == nil {
+		return f.channels
+	}
+	channels := bufferChannels(f.channels, c.sources, c.samplers)
+	for step := max(steps-FIXED_STEP_RENDERS_MAX, 0); step < steps; step++ {
+		channels = c.render(s.atStep(step, steps), f, quad)
+	}
+	return channels
+}
+
+// delete frees programs, textures and targets of the buffer pass
+func (b *bufferPass) delete() {
+	if b.program != 0 {
+		gl.DeleteProgram(b.program)
+	}
+	deleteChannelTextures(b.channels)
+	for _, target := range b.targets {
+		if target != nil {
+			target.delete()
+		}
+	}
+}
+
+// delete frees all buffer passes (nil-safe)
+func (c *passChain) delete() {
+	if c == nil {
+		return
+	}
+	for _, b := range c.buffers {
+		b.delete()
+	}
+}
+
+// setupPassChain is newPassChain logging a failure, after which the image pass renders alone
+func setupPassChain(shaderData *ShaderData, cfg *Config) *passChain {
+	chain, err := newPassChain(shaderData, cfg)
+	if err != nil {
+		log.Printf("Warning: %v; rendering without buffer passes", err)
+	}
+	return chain
+}
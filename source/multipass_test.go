@@ -0,0 +1,117 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// bufferPassSample is listed image first and B before A: Buffer A adds 0.25 to its
+// previous frame, Buffer B halves A and the image pass shows B. Buffer C is read by
+// nobody, Buffer D reads C and itself.
+const bufferPassSample = `{"passes":[
+ {"type":"image","name":"Image","inputs":[{"id":"b","channel":0,"type":"buffer"}],
+  "code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(texture(iChannel0, p / iResolution.xy).rgb, 1.0); }"},
+ {"type":"buffer","name":"Buffer B","inputs":[{"id":"a","channel":1,"type":"buffer"}],"outputs":[{"id":"b","channel":0}],
+  "code":"void mainImage(out vec4 c, in vec2 p) { c = texture(iChannel1, p / iResolution.xy) * 0.5; }"},
+ {"type":"buffer","name":"Buffer A","inputs":[{"id":"a","channel":0,"type":"buffer"}],"outputs":[{"id":"a","channel":0}],
+  "code":"void mainImage(out vec4 c, in vec2 p) { c = texture(iChannel0, p / iResolution.xy) + 0.25; }"},
+ {"type":"buffer","name":"Buffer C","outputs":[{"id":"c","channel":0}],"code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(1.0); }"},
+ {"type":"buffer","name":"Buffer D","inputs":[{"id":"c","channel":0},{"id":"d","channel":1}],"outputs":[{"id":"d","channel":0}],
+  "code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(1.0); }"}]}`
+
+// TestBufferOrder checks which buffer passes render and in what order
+func TestBufferOrder(t *testing.T) {
+	data, err := parseShaderJSON([]byte(bufferPassSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order := bufferRenderOrder(data, selectImagePass(data)); !reflect.DeepEqual(order, []int{2, 1}) {
+		t.Errorf("render order %v, want [2 1] (Buffer A, Buffer B)", order)
+	}
+	// Buffers reading each other: file order, and no endless loop
+	data.Passes[2].Inputs = append(data.Passes[2].Inputs, ShaderInput{ID: "b", Channel: 1, Type: "buffer"})
+	if order := bufferRenderOrder(data, selectImagePass(data)); !reflect.DeepEqual(order, []int{1, 2}) {
+		t.Errorf("render order with a cycle %v, want [1 2]", order)
+	}
+	// A pass shown as image is no buffer, also when it reads itself
+	if order := bufferRenderOrder(data, &data.Passes[4]); !reflect.DeepEqual(order, []int{3}) {
+		t.Errorf("render order for Buffer D %v, want [3]", order)
+	}
+	if order := bufferRenderOrder(data, &data.Passes[3]); len(order) != 0 {
+		t.Errorf("Buffer C reads no buffers, got order %v", order)
+	}
+}
+
+// TestBufferPasses renders bufferPassSample for two frames: A holds 0.5, B (rendered
+// after A) 0.25, which the image pass shows
+func TestBufferPasses(t *testing.T) {
+	data, err := parseShaderJSON([]byte(bufferPassSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultConfig()
+	const size = 16
+	requireGL(t)
+	onMainThread(func() {
+		vertexShader, fragmentShader, err := getMainShaderCode(data, &cfg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		program, err := buildProgram(vertexShader, fragmentShader)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer gl.DeleteProgram(program)
+		renderer, err := newFrameRenderer(program, data, &cfg, size, size)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer renderer.delete()
+		if renderer.buffers == nil || len(renderer.buffers.buffers) != 2 {
+			t.Error("buffer passes not set up")
+			return
+		}
+		renderer.renderFrame(1, SELFTEST_TIME_STEP)
+		renderer.renderFrame(2, SELFTEST_TIME_STEP)
+		pixels := make([]byte, size*size*4)
+		if err := readPixelsSync(renderer.target.fbo, size, size, pixels); err != nil {
+			t.Error(err)
+			return
+		}
+		// Some slack for dithering
+		if center := int(pixels[(size/2*size+size/2)*4]); center < 60 || center > 68 {
+			t.Errorf("image pass shows %d, want 64 (0.25)", center)
+		}
+		if err := pendingGLError(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// mrtSample has Buffer A write 0.25 to fragColor and 0.5 to location 1; the image
+// pass shows the output exposing attachment 1
+const mrtSample = `{"passes":[
+ {"type":"image","name":"Image","inputs":[{"id":"n","channel":0,"type":"buffer"}],
+  "code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(texture(iChannel0, p / iResolution.xy).rgb, 1.0); }"},
+ {"type":"buffer","name":"Buffer A","outputs":[{"id":"a","channel":0},{"id":"n","channel":1}],
+  "code":"layout(location = 1) out vec4 normal;\nvoid mainImage(out vec4 c, in vec2 p) { c = vec4(0.25); normal = vec4(0.5); }"}]}`
+
+// TestBufferRenderTargets renders mrtSample and checks the image pass reads location 1
+func TestBufferRenderTargets(t *testing.T) {
+	data, err := parseShaderJSON([]byte(mrtSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultConfig()
+	const size = 16
+	pixels := renderShaderPixels(t, data, &cfg, size)
+	// Some slack for dithering
+	if center := int(pixels[(size/2*size+size/2)*4]); center < 124 || center > 132 {
+		t.Errorf("image pass shows %d, want 128 (0.5 from render target 1)", center)
+	}
+}
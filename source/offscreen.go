@@ -0,0 +1,122 @@
+// Offscreen rendering shared by -selftest and -framedump.
+//
+// Both create a hidden window only to get a GL context, then draw the shader
+// into a renderTarget with deterministic time (frame N is at N * time step),
+// so the output depends only on the shader and the driver.
+package main
+
+import (
+	"image"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// createOffscreenContext initializes GLFW and makes a hidden window's GL context current.
+// Errors are *glContextError. Caller must call glfw.Terminate (safe even on error).
+func createOffscreenContext(width, height int) (*glfw.Window, error) {
+	if err := glfw.Init(); err != nil {
+		return nil, &glContextError{err}
+	}
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	setGLContextHints()
+	window, err := glfw.CreateWindow(width, height, SCREENSAVER_NAME, nil, nil)
+	if err != nil {
+		return nil, &glContextError{err}
+	}
+	window.MakeContextCurrent()
+	if err := gl.Init(); err != nil {
+		window.Destroy()
+		return nil, &glContextError{err}
+	}
+	return window, nil
+}
+
+// frameRenderer draws frames of a linked shader program into an offscreen target
+type frameRenderer struct {
+	program  uint32
+	quad     *FullscreenQuad
+	uniforms shaderUniforms
+	channels [CHANNEL_COUNT]channelTexture
+	buffers  *passChain // Buffer passes read by the image pass (nil = none)
+	target   *renderTarget
+	cfg      *Config
+}
+
+// newFrameRenderer prepares textures, uniforms and a width x height target for program
+func newFrameRenderer(program uint32, shaderData *ShaderData, cfg *Config, width, height int) (*frameRenderer, error) {
+	target, err := newRenderTarget(width, height, gl.LINEAR)
+	if err != nil {
+		return nil, err
+	}
+	r := &frameRenderer{
+		program:  program,
+		quad:     createFullscreenQuad(),
+		uniforms: getShaderUniforms(program),
+		channels: setupChannelTextures(program, selectImagePass(shaderData)),
+		buffers:  setupPassChain(shaderData, cfg),
+		target:   target,
+		cfg:      cfg,
+	}
+	r.uniforms.params = getParamUniforms(program, shaderData, cfg)
+	gl.Disable(gl.DEPTH_TEST)
+	return r, nil
+}
+
+// renderFrame draws frame (1-based) at time frame * timeStep seconds
+func (r *frameRenderer) renderFrame(frame int, timeStep float64) {
+	r.target.bind()
+	gl.ClearColor(0.0, 0.0, 0.0, 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	state := fixedFrameState(frame, timeStep, r.target.width, r.target.height)
+	// Fixed date keeps frames reproducible; -date overrides it
+	date := r.cfg.dateAt(state.shaderElapsed)
+	if date.IsZero() {
+		date = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	uniforms := frameUniforms{
+		mouse:    noMouse,
+		scale:    r.cfg.Scale,
+		timeWrap: r.cfg.shaderTimeWrap(),
+		speed:    r.cfg.Speed,
+		channels: r.channels,
+		date:     date,
+
+		squareCoords: r.cfg.FragCoordMode == FRAGCOORD_SQUARE,
+		flipCoords:   r.cfg.FlipCoord,
+
+		dither:  r.cfg.Dither,
+		palette: r.cfg.Palette,
+
+		vignette:       r.cfg.Vignette,
+		vignetteRadius: r.cfg.VignetteRadius,
+		brightness:     r.cfg.Brightness,
+	}
+	uniforms.channels = r.buffers.render(&state, uniforms, r.quad)
+	gl.UseProgram(r.program)
+	r.uniforms.upload(&state, uniforms)
+	advanceChannelTextures(uniforms.channels, r.uniforms.last.channelTime)
+	bindChannelTextures(uniforms.channels)
+	gl.BindVertexArray(r.quad.vao)
+	gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, gl.PtrOffset(0))
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// readPixels returns current target contents as opaque top-down image (blocking readback)
+func (r *frameRenderer) readPixels() (*image.RGBA, error) {
+	width, height := r.target.width, r.target.height
+	pixels := make([]byte, width*height*4)
+	if err := readPixelsSync(r.target.fbo, width, height, pixels); err != nil {
+		return nil, err
+	}
+	return pixelsToImage(pixels, width, height), nil
+}
+
+// delete frees target, quad and buffer passes (channel textures live until context is destroyed)
+func (r *frameRenderer) delete() {
+	r.target.delete()
+	r.buffers.delete()
+	gl.DeleteVertexArrays(1, &r.quad.vao)
+	gl.DeleteBuffers(1, &r.quad.vbo)
+}
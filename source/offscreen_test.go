@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// mainThread runs GL calls of tests on the thread locked in init
+var mainThread = make(chan func())
+
+func TestMain(m *testing.M) {
+	code := make(chan int)
+	go func() {
+		code <- m.Run()
+	}()
+	for {
+		select {
+		case f := <-mainThread:
+			f()
+		case c := <-code:
+			glfw.Terminate()
+			os.Exit(c)
+		}
+	}
+}
+
+// onMainThread runs f on the main thread and waits for it
+func onMainThread(f func()) {
+	done := make(chan struct{})
+	mainThread <- func() {
+		defer close(done)
+		f()
+	}
+	<-done
+}
+
+var (
+	glWindow *glfw.Window
+	glErr    error
+)
+
+// requireGL makes the shared offscreen context current or skips the test
+func requireGL(t *testing.T) {
+	t.Helper()
+	onMainThread(func() {
+		if glWindow != nil || glErr != nil {
+			return
+		}
+		// glfw only logs platform errors (no display) and panics on the next call
+		defer func() {
+			if r := recover(); r != nil {
+				glErr = fmt.Errorf("%v", r)
+			}
+		}()
+		glWindow, glErr = createOffscreenContext(SELFTEST_WIDTH, SELFTEST_HEIGHT)
+	})
+	if glErr != nil {
+		t.Skipf("no GL context: %v", glErr)
+	}
+}
+
+// renderShaderPixels compiles shaderData like the screensaver and returns RGBA
+// pixels of frame 1 at size x size
+func renderShaderPixels(t *testing.T, shaderData *ShaderData, cfg *Config, size int) []byte {
+	t.Helper()
+	requireGL(t)
+	var pixels []byte
+	var err error
+	onMainThread(func() {
+		pixels, err = renderShaderFrame(shaderData, cfg, size)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pixels
+}
+
+func renderShaderFrame(shaderData *ShaderData, cfg *Config, size int) ([]byte, error) {
+	vertexShader, fragmentShader, err := getMainShaderCode(shaderData, cfg)
+	if err != nil {
+		return nil, err
+	}
+	program, err := buildProgram(vertexShader, fragmentShader)
+	if err != nil {
+		return nil, err
+	}
+	defer gl.DeleteProgram(program)
+	renderer, err := newFrameRenderer(program, shaderData, cfg, size, size)
+	if err != nil {
+		return nil, err
+	}
+	defer renderer.delete()
+	renderer.renderFrame(1, SELFTEST_TIME_STEP)
+	pixels := make([]byte, size*size*4)
+	if err := readPixelsSync(renderer.target.fbo, size, size, pixels); err != nil {
+		return nil, err
+	}
+	return pixels, pendingGLError()
+}
@@ -0,0 +1,35 @@
+// URL checks shared by the platform openURL implementations.
+//
+// openURL hands its argument to ShellExecuteW or open/xdg-open, which would
+// just as happily start a program, open a local file or run a custom URL
+// scheme handler. URLs are therefore parsed first: only http(s) URLs with a
+// host and mailto: links are opened, and the URL is passed on in its
+// re-encoded form, so spaces and other unsafe characters arrive percent-encoded
+// (already encoded parts, like %2F in a path, are kept as they are).
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// checkOpenURL validates raw for openURL and returns it safely encoded
+func checkOpenURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %v", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", fmt.Errorf("URL %q has no host", raw)
+		}
+	case "mailto":
+	default:
+		return "", fmt.Errorf("refusing to open %q: only http, https and mailto links are opened", raw)
+	}
+	// Query is kept verbatim by url.Parse; encode what a shell or browser could misread
+	u.RawQuery = strings.NewReplacer(" ", "%20", "\"", "%22", "<", "%3C", ">", "%3E", "`", "%60").Replace(u.RawQuery)
+	return u.String(), nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+// TestURLOpening checks that links are encoded before opening and other schemes are refused
+func TestURLOpening(t *testing.T) {
+	for raw, want := range map[string]string{
+		"https://www.shadertoy.com/results?query=aurora borealis&sort=popular": "https://www.shadertoy.com/results?query=aurora%20borealis&sort=popular",
+		"https://example.com/shaders/my%20aurora%2Fv2.json":                    "https://example.com/shaders/my%20aurora%2Fv2.json",
+		"https://example.com/a b/c":                                            "https://example.com/a%20b/c",
+		"mailto:author@example.com":                                            "mailto:author@example.com",
+	} {
+		got, err := checkOpenURL(raw)
+		if err != nil || got != want {
+			t.Errorf("%q opened as %q, %v (expected %q)", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"file:///etc/passwd", "C:\\Windows\\System32\\calc.exe", "javascript:alert(1)", "https:///path", "calc.exe", "-a Calculator"} {
+		if _, err := checkOpenURL(raw); err == nil {
+			t.Errorf("%q accepted", raw)
+		}
+	}
+	// Shader metadata links: http(s) only
+	if got, err := checkMetadataURL("https://www.shadertoy.com/view/XtGGRt"); err != nil || got != "https://www.shadertoy.com/view/XtGGRt" {
+		t.Errorf("metadata link opened as %q, %v", got, err)
+	}
+	if _, err := checkMetadataURL("mailto:author@example.com"); err == nil {
+		t.Errorf("mailto metadata link accepted")
+	}
+}
@@ -0,0 +1,70 @@
+// Bundled aurora color palettes (iPalette).
+//
+// A palette is a cosine gradient: color(t) = a + b * sin(d + c*t), with the
+// four vec3 terms uploaded as `iPalette[0..3]` = a, b, c, d. The built-in
+// shader samples it for the aurora bands (t = band index 0..35); other shaders
+// can use it the same way or ignore it. The default "aurora" palette holds the
+// constants the built-in shader used before palettes, so its look is unchanged.
+// Chosen on Settings -> Basic or with -palette, saved in the settings file.
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PALETTE_SIZE is the number of vec3 terms in iPalette
+const PALETTE_SIZE = 4
+
+const (
+	PALETTE_AURORA = "aurora"
+	PALETTE_SOLAR  = "solar"
+	PALETTE_POLAR  = "polar"
+)
+
+// palette is one bundled gradient
+type palette struct {
+	name   string
+	label  string // Shown in the settings dialog
+	colors [PALETTE_SIZE][3]float32
+}
+
+// palettes lists bundled palettes; the first one is the default
+var palettes = []palette{
+	{PALETTE_AURORA, "Aurora green", [PALETTE_SIZE][3]float32{
+		{0.5, 0.5, 0.5}, {0.5, 0.5, 0.5}, {0.03, 0.03, 0.03}, {12.0, 2.7, 0.7},
+	}},
+	{PALETTE_SOLAR, "Solar red", [PALETTE_SIZE][3]float32{
+		{0.5, 0.5, 0.5}, {0.5, 0.5, 0.5}, {0.03, 0.03, 0.03}, {1.3, 3.6, 4.4},
+	}},
+	{PALETTE_POLAR, "Polar blue", [PALETTE_SIZE][3]float32{
+		{0.5, 0.5, 0.5}, {0.5, 0.5, 0.5}, {0.03, 0.03, 0.03}, {4.4, 2.4, 1.4},
+	}},
+}
+
+// findPalette returns bundled palette by name, the default one for unknown names
+func findPalette(name string) (palette, bool) {
+	for _, p := range palettes {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return palettes[0], false
+}
+
+// paletteNames returns names of bundled palettes ("aurora, solar, polar")
+func paletteNames() string {
+	var names []string
+	for _, p := range palettes {
+		names = append(names, p.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// validatePalette checks -palette value
+func validatePalette(name string) error {
+	if _, ok := findPalette(name); !ok {
+		return fmt.Errorf("unknown palette %q (expected %s)", name, paletteNames())
+	}
+	return nil
+}
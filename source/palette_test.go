@@ -0,0 +1,36 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestPalettes compares the default palette with the gradient the built-in shader had
+// before iPalette, and checks that the built-in shader samples it
+func TestPalettes(t *testing.T) {
+	p, ok := findPalette(PALETTE_AURORA)
+	if !ok || palettes[0].name != PALETTE_AURORA {
+		t.Fatalf("default palette %q missing", PALETTE_AURORA)
+	}
+	phase := [3]float64{-10, -0.7, 1.3}
+	for i := 0; i < 35; i++ {
+		for c := 0; c < 3; c++ {
+			want := math.Sin(2-phase[c]+float64(i)*0.03)*0.5 + 0.5
+			got := float64(p.colors[0][c]) + float64(p.colors[1][c])*math.Sin(float64(p.colors[3][c])+float64(p.colors[2][c])*float64(i))
+			if math.Abs(got-want) > 1e-5 {
+				t.Fatalf("band %d channel %d: %.6f, built-in gradient %.6f", i, c, got, want)
+			}
+		}
+	}
+	if validatePalette("rainbow") == nil {
+		t.Errorf("unknown palette accepted")
+	}
+	shaderData, err := loadEmbeddedShader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(selectImagePass(shaderData).Code, "iPalette") {
+		t.Errorf("built-in shader doesn't use iPalette")
+	}
+}
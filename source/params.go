@@ -0,0 +1,185 @@
+// Shader parameters declared in metadata.
+//
+// A shader can expose author-defined knobs in its metadata:
+//
+//	"params": [
+//	  {"name": "uIntensity", "type": "float", "default": 1.0, "min": 0.0, "max": 2.0, "label": "Aurora intensity"}
+//	]
+//
+// Invalid parameters are dropped with a warning when the shader is parsed.
+// Each valid parameter is declared as a uniform in the fragment template,
+// gets a control on the Settings -> Parameters tab and is uploaded every
+// frame. Chosen values are saved per shader (keyed by shader_id, else title);
+// values outside [min, max] are clamped, missing ones use the default.
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+const (
+	PARAM_FLOAT = "float"
+	PARAM_INT   = "int"
+	PARAM_BOOL  = "bool"
+)
+
+// ShaderParam represents a tweakable uniform declared in shader metadata.
+type ShaderParam struct {
+	Name    string  `json:"name"`
+	Type    string  `json:"type,omitempty"` // float (default), int or bool
+	Default float64 `json:"default,omitempty"`
+	Min     float64 `json:"min,omitempty"`
+	Max     float64 `json:"max,omitempty"`
+	Label   string  `json:"label,omitempty"`
+}
+
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Built-in uniforms are named iSomething; params must not shadow them
+var builtinUniformPattern = regexp.MustCompile(`^i[A-Z]`)
+
+// validate checks name and type, fills defaults; returns error for unusable param
+func (p *ShaderParam) validate() error {
+	if !paramNamePattern.MatchString(p.Name) || strings.HasPrefix(p.Name, "gl_") {
+		return fmt.Errorf("invalid uniform name %q", p.Name)
+	}
+	if builtinUniformPattern.MatchString(p.Name) {
+		return fmt.Errorf("name %q is reserved for built-in uniforms", p.Name)
+	}
+	if p.Type == "" {
+		p.Type = PARAM_FLOAT
+	}
+	switch p.Type {
+	case PARAM_FLOAT, PARAM_INT:
+		if p.Min == 0 && p.Max == 0 {
+			p.Max = 1 // Range omitted: 0..1
+		}
+		if p.Max <= p.Min {
+			return fmt.Errorf("%s: max must be greater than min", p.Name)
+		}
+	case PARAM_BOOL:
+		p.Min, p.Max = 0, 1
+	default:
+		return fmt.Errorf("%s: unsupported type %q (expected float, int or bool)", p.Name, p.Type)
+	}
+	p.Default = p.clamp(p.Default)
+	return nil
+}
+
+// clamp limits value to parameter range (rounded for int and bool)
+func (p ShaderParam) clamp(value float64) float64 {
+	value = clampFloat(value, p.Min, p.Max)
+	if p.Type == PARAM_INT || p.Type == PARAM_BOOL {
+		value = math.Round(value)
+	}
+	return value
+}
+
+// label returns display name for settings dialog
+func (p ShaderParam) label() string {
+	if p.Label != "" {
+		return p.Label
+	}
+	return p.Name
+}
+
+// validateShaderParams drops invalid and duplicate params from metadata (logging why)
+func validateShaderParams(meta *ShaderMetadata) {
+	if meta == nil {
+		return
+	}
+	var params []ShaderParam
+	seen := make(map[string]bool)
+	for _, param := range meta.Params {
+		if err := param.validate(); err != nil {
+			log.Printf("Warning: ignoring shader parameter: %v", err)
+			continue
+		}
+		if seen[param.Name] {
+			log.Printf("Warning: ignoring duplicate shader parameter %s", param.Name)
+			continue
+		}
+		seen[param.Name] = true
+		params = append(params, param)
+	}
+	meta.Params = params
+}
+
+// shaderParams returns params of shader (nil-safe)
+func shaderParams(shaderData *ShaderData) []ShaderParam {
+	if shaderData == nil || shaderData.Metadata == nil {
+		return nil
+	}
+	return shaderData.Metadata.Params
+}
+
+// shaderParamsKey identifies shader in saved parameter values
+func shaderParamsKey(meta *ShaderMetadata) string {
+	if meta == nil {
+		return ""
+	}
+	if meta.ShaderID != "" {
+		return meta.ShaderID
+	}
+	return meta.Title
+}
+
+// shaderParamDeclarations returns uniform declarations for params the code doesn't declare itself
+func shaderParamDeclarations(params []ShaderParam, code string) string {
+	var result strings.Builder
+	for _, param := range params {
+		declared := cachedPattern(`\buniform\s+\w+\s+` + param.Name + `\b`)
+		if declared.MatchString(code) {
+			continue
+		}
+		fmt.Fprintf(&result, "uniform %s %s;\n", param.Type, param.Name)
+	}
+	return result.String()
+}
+
+// paramUniform is a shader parameter resolved for upload
+type paramUniform struct {
+	name      string
+	location  int32
+	paramType string
+	value     float64
+}
+
+// getParamUniforms looks up param locations and resolves values (saved in cfg, else default)
+func getParamUniforms(program uint32, shaderData *ShaderData, cfg *Config) []paramUniform {
+	saved := cfg.ShaderParams[shaderParamsKey(shaderData.Metadata)]
+	var result []paramUniform
+	for _, param := range shaderParams(shaderData) {
+		value := param.Default
+		if savedValue, ok := saved[param.Name]; ok {
+			value = param.clamp(savedValue)
+		}
+		location := gl.GetUniformLocation(program, gl.Str(param.Name+"\x00"))
+		if location < 0 {
+			if DEBUG_MODE {
+				log.Printf("Shader parameter %s is not used by shader", param.Name)
+			}
+			continue
+		}
+		result = append(result, paramUniform{param.Name, location, param.Type, value})
+	}
+	return result
+}
+
+// uploadParamUniforms sets parameter uniforms (program must be in use)
+func uploadParamUniforms(params []paramUniform) {
+	for _, param := range params {
+		switch param.paramType {
+		case PARAM_INT, PARAM_BOOL:
+			gl.Uniform1i(param.location, int32(param.value))
+		default:
+			gl.Uniform1f(param.location, float32(param.value))
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+import "testing"
+
+func TestShaderParamDeclarations(t *testing.T) {
+	params := []ShaderParam{{Name: "uSpeed", Type: PARAM_FLOAT}, {Name: "uBands", Type: PARAM_INT}}
+	code := "uniform  float uSpeed;\nfloat uBandsScale = 1.0;\n"
+	for range 2 { // Second run uses cached patterns
+		if got, want := shaderParamDeclarations(params, code), "uniform int uBands;\n"; got != want {
+			t.Errorf("declarations %q, expected %q", got, want)
+		}
+	}
+}
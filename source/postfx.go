@@ -0,0 +1,279 @@
+// Optional post-process effects: bloom, FXAA and tint.
+//
+// All effects are off by default. In interactive mode B, F and T toggle them
+// on the fly, so they can be compared on the running shader. While any effect
+// is on, the shader renders into an offscreen texture and a post pass draws
+// it to the output (window, or the `-render-size` target which is then
+// blitted as usual). The post pass is compiled with one #define per enabled
+// effect, so each toggle rebuilds the program; turning the last effect off
+// deletes the texture and program and the shader renders directly again.
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+const (
+	EFFECT_BLOOM = "bloom"
+	EFFECT_FXAA  = "fxaa"
+	EFFECT_TINT  = "tint"
+)
+
+// Color multiplier of the tint effect (slightly cool, keeps greens)
+var postTintColor = [3]float32{0.8, 1.0, 0.9}
+
+// postEffects is the effect state toggled by key callback and read by render loop
+type postEffects struct {
+	bloom bool
+	fxaa  bool
+	tint  bool
+}
+
+// any reports whether post pass is needed
+func (e postEffects) any() bool {
+	return e.bloom || e.fxaa || e.tint
+}
+
+// String lists enabled effects ("bloom,tint"), "off" if none
+func (e postEffects) String() string {
+	var names []string
+	if e.bloom {
+		names = append(names, EFFECT_BLOOM)
+	}
+	if e.fxaa {
+		names = append(names, EFFECT_FXAA)
+	}
+	if e.tint {
+		names = append(names, EFFECT_TINT)
+	}
+	if len(names) == 0 {
+		return "off"
+	}
+	return strings.Join(names, ",")
+}
+
+// toggleKey flips effect bound to key; returns false if key isn't an effect hotkey
+func (e *postEffects) toggleKey(key glfw.Key) bool {
+	switch key {
+	case glfw.KeyB:
+		e.bloom = !e.bloom
+	case glfw.KeyF:
+		e.fxaa = !e.fxaa
+	case glfw.KeyT:
+		e.tint = !e.tint
+	default:
+		return false
+	}
+	return true
+}
+
+// defines returns GLSL #define lines selecting enabled effects
+func (e postEffects) defines() string {
+	var result strings.Builder
+	if e.bloom {
+		result.WriteString("#define BLOOM\n")
+	}
+	if e.fxaa {
+		result.WriteString("#define FXAA\n")
+	}
+	if e.tint {
+		result.WriteString("#define TINT\n")
+	}
+	return result.String()
+}
+
+const postVertexShader = `#version 330 core
+layout(location = 0) in vec2 aPos;
+layout(location = 1) in vec2 aTexCoord;
+out vec2 uv;
+
+void main() {
+    uv = aTexCoord;
+    gl_Position = vec4(aPos * 2.0 - 1.0, 0.0, 1.0);
+}` + "\x00"
+
+// Fragment shader body; "#version" and effect defines are prepended by buildPostProgram
+const postFragmentShader = `
+in vec2 uv;
+out vec4 fragColor;
+
+uniform sampler2D uSource;
+uniform vec2 uResolution;
+uniform vec3 uTint;
+
+const vec3 LUMA = vec3(0.299, 0.587, 0.114);
+
+float luma(vec2 p) {
+    return dot(texture(uSource, p).rgb, LUMA);
+}
+
+#ifdef FXAA
+// Simplified FXAA: blend along the local edge direction
+vec3 fxaa(vec2 p) {
+    vec2 px = 1.0 / uResolution;
+    float lNW = luma(p + vec2(-1.0, -1.0) * px);
+    float lNE = luma(p + vec2(1.0, -1.0) * px);
+    float lSW = luma(p + vec2(-1.0, 1.0) * px);
+    float lSE = luma(p + vec2(1.0, 1.0) * px);
+    float lM = luma(p);
+    float lMin = min(lM, min(min(lNW, lNE), min(lSW, lSE)));
+    float lMax = max(lM, max(max(lNW, lNE), max(lSW, lSE)));
+
+    vec2 dir = vec2(-((lNW + lNE) - (lSW + lSE)), (lNW + lSW) - (lNE + lSE));
+    float reduce = max((lNW + lNE + lSW + lSE) * 0.03125, 1.0 / 128.0);
+    float rcpMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + reduce);
+    dir = clamp(dir * rcpMin, -8.0, 8.0) * px;
+
+    vec3 a = 0.5 * (texture(uSource, p + dir * (1.0 / 3.0 - 0.5)).rgb +
+                    texture(uSource, p + dir * (2.0 / 3.0 - 0.5)).rgb);
+    vec3 b = a * 0.5 + 0.25 * (texture(uSource, p - dir * 0.5).rgb +
+                               texture(uSource, p + dir * 0.5).rgb);
+    float lB = dot(b, LUMA);
+    return (lB < lMin || lB > lMax) ? a : b;
+}
+#endif
+
+#ifdef BLOOM
+// Single-pass bloom: bright parts of a sparse ring blur added back
+vec3 bloom(vec2 p) {
+    vec2 px = 1.0 / uResolution;
+    vec3 sum = vec3(0.0);
+    float total = 0.0;
+    for (int ring = 1; ring <= 3; ring++) {
+        float radius = float(ring) * 4.0;
+        float weight = 1.0 / float(ring);
+        for (int i = 0; i < 8; i++) {
+            float angle = float(i) * 0.785398 + float(ring);
+            vec3 c = texture(uSource, p + vec2(cos(angle), sin(angle)) * radius * px).rgb;
+            sum += max(c - 0.6, 0.0) * weight;
+            total += weight;
+        }
+    }
+    return sum / total * 2.0;
+}
+#endif
+
+void main() {
+#ifdef FXAA
+    vec3 color = fxaa(uv);
+#else
+    vec3 color = texture(uSource, uv).rgb;
+#endif
+#ifdef BLOOM
+    color += bloom(uv);
+#endif
+#ifdef TINT
+    color *= uTint;
+#endif
+    fragColor = vec4(color, 1.0);
+}`
+
+// buildPostProgram compiles post pass for enabled effects
+func buildPostProgram(effects postEffects) (uint32, error) {
+	fragment := "#version 330 core\n" + effects.defines() + postFragmentShader + "\x00"
+	return buildProgram(postVertexShader, fragment)
+}
+
+// postProcessor owns offscreen texture and program of the post pass
+type postProcessor struct {
+	effects    postEffects // Effects the current program was built for
+	target     *renderTarget
+	program    uint32
+	source     int32
+	resolution int32
+	tint       int32
+}
+
+// sync creates, rebuilds or deletes post pass resources for effects at render size.
+// Returns true if shader output should go through the post pass this frame.
+// On failure logs the error and turns all effects off.
+func (pp *postProcessor) sync(effects *postEffects, width, height int) bool {
+	if !effects.any() {
+		pp.delete()
+		return false
+	}
+
+	if pp.program == 0 || pp.effects != *effects {
+		if pp.program != 0 {
+			gl.DeleteProgram(pp.program)
+			pp.program = 0
+		}
+		program, err := buildPostProgram(*effects)
+		if err != nil {
+			log.Printf("Error building post effects (%s): %v", effects, err)
+			*effects = postEffects{}
+			pp.delete()
+			return false
+		}
+		pp.program = program
+		pp.effects = *effects
+		pp.source = gl.GetUniformLocation(program, gl.Str("uSource\x00"))
+		pp.resolution = gl.GetUniformLocation(program, gl.Str("uResolution\x00"))
+		pp.tint = gl.GetUniformLocation(program, gl.Str("uTint\x00"))
+		if DEBUG_MODE {
+			log.Printf("Post effects: %s", effects)
+		}
+	}
+
+	if pp.target == nil {
+		target, err := newRenderTarget(width, height, gl.LINEAR)
+		if err != nil {
+			log.Printf("Error creating post effects target: %v", err)
+			*effects = postEffects{}
+			pp.delete()
+			return false
+		}
+		pp.target = target
+	} else {
+		pp.target.resize(width, height)
+	}
+	return true
+}
+
+// begin binds offscreen texture the shader renders into
+func (pp *postProcessor) begin() {
+	pp.target.bind()
+}
+
+// apply draws processed image into output (nil = window framebuffer of fbWidth x fbHeight)
+func (pp *postProcessor) apply(quad *FullscreenQuad, output *renderTarget, fbWidth, fbHeight int) {
+	if output != nil {
+		output.bind()
+	} else {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+	}
+
+	gl.UseProgram(pp.program)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, pp.target.texture)
+	if pp.source >= 0 {
+		gl.Uniform1i(pp.source, 0)
+	}
+	if pp.resolution >= 0 {
+		gl.Uniform2f(pp.resolution, float32(pp.target.width), float32(pp.target.height))
+	}
+	if pp.tint >= 0 {
+		gl.Uniform3f(pp.tint, postTintColor[0], postTintColor[1], postTintColor[2])
+	}
+	gl.BindVertexArray(quad.vao)
+	gl.DrawElements(gl.TRIANGLES, 6, gl.UNSIGNED_INT, gl.PtrOffset(0))
+	checkGLError("post effects draw")
+}
+
+// delete frees texture and program (safe to call repeatedly)
+func (pp *postProcessor) delete() {
+	if pp.target != nil {
+		pp.target.delete()
+		pp.target = nil
+	}
+	if pp.program != 0 {
+		gl.DeleteProgram(pp.program)
+		pp.program = 0
+	}
+	pp.effects = postEffects{}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestPreprocessJSON checks preprocessJSON leaves valid shader files unchanged,
+// including escapes (\n, \", \\) that must not be escaped again
+func TestPreprocessJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"embedded shader", string(shaderJSONData)},
+		{"escaped newline", `{"metadata":{"title":"Sample"},"passes":[{"type":"image","code":"void mainImage(out vec4 c, in vec2 p) {\n    c = vec4(1.0);\n}"}]}`},
+		{"quotes, slashes and tabs", `{"metadata":{"title":"Quote \"and\" slash \\"},"passes":[{"type":"image","code":"// tab\there\r\nvoid mainImage(out vec4 c, in vec2 p) { c = vec4(0.0); }"}]}`},
+		{"unicode escape", `{"passes":[{"type":"image","name":"Unicode \u00e9 \\n","code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(p, 0.0, 1.0); }"}]}`},
+	}
+	for _, tt := range tests {
+		sample := []byte(tt.json)
+		if !json.Valid(sample) {
+			t.Errorf("%s: sample is not valid JSON", tt.name)
+			continue
+		}
+		processed, err := preprocessJSON(sample)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(processed, sample) {
+			t.Errorf("%s: changed by preprocessJSON:\n%s", tt.name, processed)
+			continue
+		}
+		var original, reparsed ShaderData
+		if err := json.Unmarshal(sample, &original); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if err := json.Unmarshal(processed, &reparsed); err != nil {
+			t.Errorf("%s: after preprocessJSON: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(original, reparsed) {
+			t.Errorf("%s: parses differently after preprocessJSON", tt.name)
+		}
+	}
+}
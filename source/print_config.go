@@ -0,0 +1,159 @@
+// Effective configuration dump (`-print-config`).
+//
+// Settings come in layers: release defaults, then the saved settings file,
+// then command line flags (and safe mode after a crashed fullscreen run).
+// `-print-config` prints the result of all layers as `key=value` lines, or as
+// a JSON object with `-format json`, and exits, so users can see what will
+// actually take effect. Keys follow the settings file names where they exist.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+const CONFIG_FORMAT_JSON = "json"
+
+// configEntry is one printed configuration value
+type configEntry struct {
+	key   string
+	value any
+}
+
+// effectiveEntries returns configuration values in print order.
+// safeMode is the flag a fullscreen run would pick up.
+func (c *Config) effectiveEntries(safeMode bool) []configEntry {
+	shader := c.ShaderPath
+	if shader == "" {
+		shader = "embedded"
+	}
+	renderSize := "screen"
+	if c.hasRenderSize() {
+		renderSize = fmt.Sprintf("%dx%d", c.RenderWidth, c.RenderHeight)
+	}
+	settingsFile, err := settingsPath()
+	if err != nil {
+		settingsFile = ""
+	}
+
+	entries := []configEntry{
+		{"settings_file", settingsFile},
+		{"safe_mode", c.SafeMode || safeMode},
+		{"shader", shader},
+		{"allow_http", c.AllowHTTP},
+		{"no_fix", c.NoFix},
+		{"skip_fixes", fixListString(c.SkipFixes)},
+		{"minify", c.MinifyShader},
+		{"max_fps", c.MaxFPS},
+		{"shader_rate", c.ShaderRate},
+		{"fixed_step", c.FixedStep},
+		{"snap_time", c.SnapTime},
+		{"freeze_after", c.FreezeAfter},
+		{"freeze_for", c.FreezeFor},
+		{"vsync", c.VSync},
+		{"multisample", c.Multisample},
+		{"render_scale", c.RenderScale},
+		{"render_size", renderSize},
+		{"letterbox", c.Letterbox},
+		{"filter", c.UpscaleFilter},
+		{"scale", c.Scale},
+		{"speed", c.Speed},
+		{"brightness", c.Brightness},
+		{"time_wrap", c.shaderTimeWrap()},
+		{"wall_clock", c.WallClock},
+		{"date", dateString(c.DateBase)},
+		{"fragcoord", c.FragCoordMode},
+		{"flip_coord", c.FlipCoord},
+		{"no_fade", c.NoFade},
+		{"start_delay", c.StartDelay},
+		{"dither", c.Dither},
+		{"palette", c.Palette},
+		{"vignette", c.Vignette},
+		{"vignette_radius", c.VignetteRadius},
+		{"hide_cursor", c.HideCursor},
+		{"interactive", c.Interactive},
+		{"seek_step", c.SeekStep},
+		{"exit_on_move", c.ExitOnMove},
+		{"move_threshold", c.MoveThreshold},
+		{"simple_text", c.SimpleText},
+		{"watermark", c.ShowWatermark},
+		{"watermark_pos", c.WatermarkPosition},
+		{"watermark_duration", c.WatermarkDuration},
+		{"clock", c.Clock.Enabled},
+		{"clock_format", c.Clock.String()},
+		{"clock_size", c.Clock.Size},
+		{"clock_color", c.Clock.Color},
+		{"banner", c.Banner.Text},
+		{"banner_pos", c.Banner.Position},
+		{"banner_size", c.Banner.Size},
+		{"banner_color", c.Banner.Color},
+		{"banner_opacity", c.Banner.Opacity},
+		{"exit_screenshot", c.ExitScreenshotDir},
+		{"exit_screenshot_overlay", c.ExitScreenshotOverlay},
+	}
+
+	// Saved shader parameters: params.<shader key>.<name>
+	shaderKeys := make([]string, 0, len(c.ShaderParams))
+	for key := range c.ShaderParams {
+		shaderKeys = append(shaderKeys, key)
+	}
+	sort.Strings(shaderKeys)
+	for _, key := range shaderKeys {
+		names := make([]string, 0, len(c.ShaderParams[key]))
+		for name := range c.ShaderParams[key] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			entries = append(entries, configEntry{"params." + key + "." + name, c.ShaderParams[key][name]})
+		}
+	}
+	return entries
+}
+
+// dateString formats -date base for printing ("now" = real clock)
+func dateString(date time.Time) string {
+	if date.IsZero() {
+		return "now"
+	}
+	return date.Format(time.RFC3339)
+}
+
+// printConfig writes effective configuration to w as key=value lines or, with format "json", a JSON object
+func printConfig(w io.Writer, c *Config, format string) error {
+	entries := c.effectiveEntries(isSafeModeActive())
+	switch format {
+	case SHADER_FORMAT_NONE:
+		for _, entry := range entries {
+			if _, err := fmt.Fprintf(w, "%s=%v\n", entry.key, entry.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case CONFIG_FORMAT_JSON:
+		// Built by hand to keep print order (a map would sort keys)
+		var buf bytes.Buffer
+		buf.WriteString("{\n")
+		for i, entry := range entries {
+			key, _ := json.Marshal(entry.key)
+			value, err := json.Marshal(entry.value)
+			if err != nil {
+				return fmt.Errorf("%s: %v", entry.key, err)
+			}
+			separator := ","
+			if i == len(entries)-1 {
+				separator = ""
+			}
+			fmt.Fprintf(&buf, "  %s: %s%s\n", key, value, separator)
+		}
+		buf.WriteString("}\n")
+		_, err := w.Write(buf.Bytes())
+		return err
+	default:
+		return fmt.Errorf("unknown config format %q (expected json)", format)
+	}
+}
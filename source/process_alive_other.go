@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+// Process liveness check for stale run markers (see safemode.go).
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// processAlive reports whether a process with this ID is running (signal 0 only
+// checks for existence; EPERM means it exists but belongs to another user)
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
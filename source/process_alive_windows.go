@@ -0,0 +1,24 @@
+//go:build windows
+// +build windows
+
+// Process liveness check for stale run markers (see safemode.go).
+package main
+
+import "syscall"
+
+// STILL_ACTIVE is the exit code GetExitCodeProcess reports for a running process
+const STILL_ACTIVE = 259
+
+// processAlive reports whether a process with this ID is running
+func processAlive(pid int) bool {
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(handle)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == STILL_ACTIVE
+}
@@ -0,0 +1,188 @@
+// Shader program binary cache.
+//
+// Repairing is fast, but compiling and linking a large shader can take a
+// noticeable part of a second on some drivers, at every launch. When the
+// driver supports GL_ARB_get_program_binary (core since GL 4.1) with at least
+// one binary format, the linked main shader program is saved in the user
+// cache dir under `AuroraBorealisBliss/programs/<key>.bin`, where key is a
+// SHA-256 of the processed vertex and fragment source plus GL vendor,
+// renderer and version. A changed shader, setting or driver therefore misses
+// and compiles normally. A binary the driver rejects (driver updated without
+// changing its version string) is deleted and the program rebuilt. Safe mode
+// never uses the cache. Only the PROGRAM_CACHE_MAX_ENTRIES most recently used
+// are kept (a cache hit refreshes the file's modification time).
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+const (
+	PROGRAM_CACHE_DIR         = "programs"
+	PROGRAM_CACHE_MAX_ENTRIES = 8
+	PROGRAM_BINARY_EXTENSION  = "GL_ARB_get_program_binary"
+)
+
+// programBinarySupported reports whether current context can save and load program binaries
+func programBinarySupported(info glContextInfo) bool {
+	supported := info.major > 4 || (info.major == 4 && info.minor >= 1)
+	if !supported {
+		var count int32
+		gl.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+		for i := int32(0); i < count && !supported; i++ {
+			supported = gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i))) == PROGRAM_BINARY_EXTENSION
+		}
+	}
+	if !supported {
+		return false
+	}
+	// Some drivers (macOS) expose the functions without any binary format
+	var formats int32
+	gl.GetIntegerv(gl.NUM_PROGRAM_BINARY_FORMATS, &formats)
+	return formats > 0
+}
+
+// programCacheKey identifies program built from sources by current driver
+func programCacheKey(vertexSrc, fragmentSrc string, info glContextInfo) string {
+	hash := sha256.New()
+	for _, part := range []string{info.vendor, info.renderer, info.version, vertexSrc, fragmentSrc} {
+		fmt.Fprintf(hash, "%d:%s\n", len(part), part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// programCachePath returns cache file of key (creates cache directory)
+func programCachePath(key string) (string, error) {
+	base, err := appCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, PROGRAM_CACHE_DIR)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, key+".bin"), nil
+}
+
+// loadProgramBinary creates program from cache file (binary format as uint32 LE, then binary)
+func loadProgramBinary(path string) (uint32, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) <= 4 {
+		return 0, fmt.Errorf("truncated cache file")
+	}
+	format := binary.LittleEndian.Uint32(data)
+	program := gl.CreateProgram()
+	gl.ProgramBinary(program, format, gl.Ptr(data[4:]), int32(len(data)-4))
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		gl.DeleteProgram(program)
+		pendingGLError()
+		return 0, fmt.Errorf("driver rejected cached binary")
+	}
+	return program, nil
+}
+
+// saveProgramBinary writes linked program to cache file
+func saveProgramBinary(program uint32, path string) error {
+	var length int32
+	gl.GetProgramiv(program, gl.PROGRAM_BINARY_LENGTH, &length)
+	if length <= 0 {
+		return fmt.Errorf("driver returned no binary")
+	}
+	data := make([]byte, 4+length)
+	var format uint32
+	gl.GetProgramBinary(program, length, &length, &format, gl.Ptr(data[4:]))
+	if err := pendingGLError(); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(data, format)
+	if err := os.WriteFile(path, data[:4+length], 0644); err != nil {
+		return err
+	}
+	pruneProgramCache(filepath.Dir(path))
+	return nil
+}
+
+// pruneProgramCache keeps the PROGRAM_CACHE_MAX_ENTRIES most recently used files
+func pruneProgramCache(dir string) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.bin"))
+	if err != nil || len(files) <= PROGRAM_CACHE_MAX_ENTRIES {
+		return
+	}
+	modTime := func(path string) time.Time {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+	sort.Slice(files, func(i, j int) bool { return modTime(files[i]).After(modTime(files[j])) })
+	for _, path := range files[PROGRAM_CACHE_MAX_ENTRIES:] {
+		os.Remove(path)
+	}
+}
+
+// newShaderProgram builds the main shader program, loading it from the binary cache when possible.
+// Exits on compile/link failure like newProgram.
+func newShaderProgram(vertexSrc, fragmentSrc string, cfg *Config) uint32 {
+	program, err := buildShaderProgram(vertexSrc, fragmentSrc, cfg)
+	if err != nil {
+		fatal(EXIT_SHADER, err)
+	}
+	return program
+}
+
+// buildShaderProgram is newShaderProgram returning compile/link errors instead of exiting
+func buildShaderProgram(vertexSrc, fragmentSrc string, cfg *Config) (uint32, error) {
+	info := getGLContextInfo()
+	if cfg.SafeMode || !programBinarySupported(info) {
+		return buildProgram(vertexSrc, fragmentSrc)
+	}
+	path, err := programCachePath(programCacheKey(vertexSrc, fragmentSrc, info))
+	if err != nil {
+		return buildProgram(vertexSrc, fragmentSrc)
+	}
+
+	start := time.Now()
+	if program, err := loadProgramBinary(path); err == nil {
+		// Least recently used entries are pruned, not the oldest written
+		if err := os.Chtimes(path, start, start); err != nil && DEBUG_MODE {
+			log.Printf("Warning: program cache %s: %v", filepath.Base(path), err)
+		}
+		if DEBUG_MODE {
+			log.Printf("Shader program loaded from cache in %.1f ms", float64(time.Since(start).Microseconds())/1000.0)
+		}
+		return program, nil
+	} else if !os.IsNotExist(err) {
+		log.Printf("Warning: program cache %s: %v, rebuilding", filepath.Base(path), err)
+		os.Remove(path)
+	}
+
+	program, err := buildProgramWith(vertexSrc, fragmentSrc, func(program uint32) {
+		gl.ProgramParameteri(program, gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if DEBUG_MODE {
+		log.Printf("Shader program compiled in %.1f ms", float64(time.Since(start).Microseconds())/1000.0)
+	}
+	if err := saveProgramBinary(program, path); err != nil && DEBUG_MODE {
+		log.Printf("Program binary not cached: %v", err)
+	}
+	return program, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestPruneProgramCache checks that pruning keeps the most recently used entries:
+// the first written entry, used again last, survives
+func TestPruneProgramCache(t *testing.T) {
+	dir := t.TempDir()
+	written := time.Now().Add(-time.Hour)
+	var paths []string
+	for i := 0; i <= PROGRAM_CACHE_MAX_ENTRIES; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%02d.bin", i))
+		if err := os.WriteFile(path, []byte{1, 2, 3, 4, 5}, 0644); err != nil {
+			t.Fatal(err)
+		}
+		at := written.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	// Cache hit on the first entry (see buildShaderProgram)
+	if err := os.Chtimes(paths[0], time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	pruneProgramCache(dir)
+	if !fileExists(paths[0]) {
+		t.Error("recently used entry was pruned")
+	}
+	if fileExists(paths[1]) {
+		t.Error("least recently used entry was kept")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.bin")); len(files) != PROGRAM_CACHE_MAX_ENTRIES {
+		t.Errorf("%d entries kept, want %d", len(files), PROGRAM_CACHE_MAX_ENTRIES)
+	}
+}
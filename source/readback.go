@@ -0,0 +1,116 @@
+// Framebuffer readback shared by capture features (`-framedump`, `-stream`).
+//
+// gl.ReadPixels into client memory stalls until the GPU has finished the
+// frame. readbackFramebuffer instead starts an asynchronous read into one of
+// two pixel buffer objects and maps the other one, which holds the previous
+// frame and is normally complete by then, so the CPU converts and writes
+// frame N-1 while the GPU renders frame N. Results lag one frame behind;
+// flush returns the last one. `-selftest` compares both paths ("readback").
+package main
+
+import (
+	"fmt"
+	"image"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// pixelReadback reads RGBA frames of a fixed size through two PBOs
+type pixelReadback struct {
+	pbos   [2]uint32
+	width  int
+	height int
+	reads  int // Reads started since last flush
+}
+
+// newPixelReadback allocates two PBOs for width x height RGBA frames
+func newPixelReadback(width, height int) *pixelReadback {
+	p := &pixelReadback{width: width, height: height}
+	gl.GenBuffers(2, &p.pbos[0])
+	for _, pbo := range p.pbos {
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, pbo)
+		gl.BufferData(gl.PIXEL_PACK_BUFFER, p.size(), nil, gl.STREAM_READ)
+	}
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	return p
+}
+
+// size returns bytes per frame
+func (p *pixelReadback) size() int {
+	return p.width * p.height * 4
+}
+
+// readbackFramebuffer starts reading fbo and calls use with pixels of the previous read
+// (GL order: RGBA rows bottom to top). use is not called on the first read after flush;
+// pixels are only valid during the call.
+func (p *pixelReadback) readbackFramebuffer(fbo uint32, use func(pixels []byte) error) error {
+	index := p.reads % 2
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fbo)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, p.pbos[index])
+	gl.ReadPixels(0, 0, int32(p.width), int32(p.height), gl.RGBA, gl.UNSIGNED_BYTE, gl.PtrOffset(0))
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	if err := pendingGLError(); err != nil {
+		return fmt.Errorf("reading pixels: %v", err)
+	}
+	p.reads++
+	if p.reads == 1 {
+		return nil
+	}
+	return p.mapBuffer(1-index, use)
+}
+
+// flush calls use with pixels of the last read, if any, and starts over
+func (p *pixelReadback) flush(use func(pixels []byte) error) error {
+	if p.reads == 0 {
+		return nil
+	}
+	index := (p.reads - 1) % 2
+	p.reads = 0
+	return p.mapBuffer(index, use)
+}
+
+// mapBuffer maps PBO index for reading and passes its contents to use
+func (p *pixelReadback) mapBuffer(index int, use func(pixels []byte) error) error {
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, p.pbos[index])
+	defer gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	ptr := gl.MapBufferRange(gl.PIXEL_PACK_BUFFER, 0, p.size(), gl.MAP_READ_BIT)
+	if ptr == nil {
+		return fmt.Errorf("mapping pixel buffer: %v", pendingGLError())
+	}
+	err := use(unsafe.Slice((*byte)(ptr), p.size()))
+	gl.UnmapBuffer(gl.PIXEL_PACK_BUFFER)
+	return err
+}
+
+// delete frees both buffers
+func (p *pixelReadback) delete() {
+	gl.DeleteBuffers(2, &p.pbos[0])
+}
+
+// readPixelsSync reads fbo into pixels with a blocking gl.ReadPixels (GL row order)
+func readPixelsSync(fbo uint32, width, height int, pixels []byte) error {
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, fbo)
+	gl.ReadPixels(0, 0, int32(width), int32(height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	if err := pendingGLError(); err != nil {
+		return fmt.Errorf("reading pixels: %v", err)
+	}
+	return nil
+}
+
+// pixelsToImage converts GL pixels (rows bottom to top) to an opaque top-down image
+func pixelsToImage(pixels []byte, width, height int) *image.RGBA {
+	// Alpha is ignored on screen, so force opaque
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	stride := width * 4
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*stride : (y+1)*stride]
+		copy(row, pixels[(height-1-y)*stride:(height-y)*stride])
+		for x := 3; x < stride; x += 4 {
+			row[x] = 255
+		}
+	}
+	return img
+}
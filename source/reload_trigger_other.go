@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// listenForReload calls notify on every SIGHUP (see shader_reload.go)
+func listenForReload(notify func()) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			notify()
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,36 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// RELOAD_EVENT_NAME is the named event that requests a reload (see shader_reload.go)
+const RELOAD_EVENT_NAME = `Local\AuroraBorealisBlissReload`
+
+var procCreateEventW = syscall.NewLazyDLL("kernel32.dll").NewProc("CreateEventW")
+
+// listenForReload calls notify every time RELOAD_EVENT_NAME is set
+func listenForReload(notify func()) error {
+	name, err := syscall.UTF16PtrFromString(RELOAD_EVENT_NAME)
+	if err != nil {
+		return err
+	}
+	// Auto-reset event: each Set wakes the waiter once
+	handle, _, callErr := procCreateEventW.Call(0, 0, 0, uintptr(unsafe.Pointer(name)))
+	if handle == 0 {
+		return callErr
+	}
+	go func() {
+		for {
+			if _, err := syscall.WaitForSingleObject(syscall.Handle(handle), syscall.INFINITE); err != nil {
+				return
+			}
+			notify()
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+// isRemoteSession: Remote Desktop sessions are only detected on Windows.
+func isRemoteSession() bool {
+	return false
+}
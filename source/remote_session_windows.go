@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+// Remote Desktop detection.
+//
+// In an RDP session OpenGL usually runs on Microsoft's software renderer (or
+// a slow remoted GPU), so a fullscreen shader shows as a black or stuttering
+// screen. The session is detected with GetSystemMetrics(SM_REMOTESESSION) and
+// rendering is reduced to a low-rate, low-resolution picture (see
+// Config.applyRemoteSession); `-ignore-remote` keeps full rendering.
+package main
+
+// SM_REMOTESESSION is the GetSystemMetrics index that is non-zero in a Remote Desktop session
+const SM_REMOTESESSION = 0x1000
+
+var procGetSystemMetrics = user32.NewProc("GetSystemMetrics")
+
+// isRemoteSession reports whether the process runs in a Remote Desktop session
+func isRemoteSession() bool {
+	remote, _, _ := procGetSystemMetrics.Call(SM_REMOTESESSION)
+	return remote != 0
+}
@@ -0,0 +1,73 @@
+// Automatic retry after a failed first frame.
+//
+// On marginal hardware the first draw after linking sometimes fails with a GL
+// error or a driver reset, which otherwise leaves a black screen until the
+// next login. Fullscreen mode checks the first frame and, if it failed, closes
+// the window and starts over: once with reduced settings (no MSAA, half
+// resolution), then once more in safe mode with the built-in fallback shader.
+// Attempts are a fixed list, so a failing fallback can't loop forever.
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// RETRY_RENDER_SCALE is render scale of the reduced-settings attempt
+const RETRY_RENDER_SCALE = 0.5
+
+// firstFrameError reports GL failure of the first rendered frame
+type firstFrameError struct {
+	err error
+}
+
+func (e *firstFrameError) Error() string {
+	return fmt.Sprintf("first frame failed: %v", e.err)
+}
+
+// renderAttempt is one step of the retry sequence
+type renderAttempt struct {
+	name     string
+	apply    func(cfg *Config)
+	safeMode bool // Attempt switches to safe mode (pointless if already on)
+}
+
+// renderAttempts are tried in order until one renders its first frame
+var renderAttempts = []renderAttempt{
+	{"configured settings", func(cfg *Config) {}, false},
+	{"reduced settings", (*Config).applyReducedSettings, false},
+	{"fallback shader", (*Config).applySafeMode, true},
+}
+
+// applyReducedSettings disables MSAA and halves render resolution
+func (c *Config) applyReducedSettings() {
+	c.Multisample = false
+	if c.hasRenderSize() {
+		c.RenderWidth = max(c.RenderWidth/2, 1)
+		c.RenderHeight = max(c.RenderHeight/2, 1)
+		return
+	}
+	c.RenderScale = min(c.RenderScale, RETRY_RENDER_SCALE)
+}
+
+// runScreensaverWithRetry runs fullscreen mode, retrying with safer settings on first-frame failure.
+// All attempts share reload. Returns error of the last attempt if all failed.
+func runScreensaverWithRetry(cfg *Config, reload *reloadTrigger) error {
+	var err error
+	for i, attempt := range renderAttempts {
+		if attempt.safeMode && cfg.SafeMode {
+			break
+		}
+		attempt.apply(cfg)
+		if i > 0 {
+			log.Printf("Retrying with %s (attempt %d of %d)", attempt.name, i+1, len(renderAttempts))
+		}
+		err = runScreensaverMode(cfg, reload)
+		if err == nil {
+			return nil
+		}
+		log.Printf("Error: %v (%s)", err, attempt.name)
+	}
+	log.Printf("Giving up: first frame failed with all attempts")
+	return err
+}
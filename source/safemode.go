@@ -0,0 +1,158 @@
+// Crash detection and safe mode.
+//
+// Fullscreen mode writes a marker file into the user cache directory at start
+// and removes it on clean exit. If the marker is still present on the next
+// launch and the process it names is gone, the previous run crashed (or was
+// killed), so we enable safe mode: built-in fallback shader, no MSAA, capped
+// frame rate. A marker of a still-running instance is not a crash. Safe mode
+// lasts until a run in safe mode exits cleanly, so a bad shader or GPU quirk
+// can't crash the screensaver again at every login, but one logoff or killed
+// process doesn't keep it on forever. `-reset-safe-mode` and the settings
+// dialog clear it at once.
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	APP_DIR_NAME      = "AuroraBorealisBliss"
+	RUN_MARKER_FILE   = "running.lock"
+	SAFE_MODE_FILE    = "safe_mode"
+	SAFE_MODE_MAX_FPS = 30
+)
+
+// fallbackShaderCode is a minimal aurora-like shader used in safe mode.
+// It avoids loops and heavy math so it runs on any GL 3.3 driver.
+const fallbackShaderCode = `void mainImage(out vec4 fragColor, in vec2 fragCoord) {
+    vec2 uv = fragCoord / iResolution.xy;
+    float band = sin(uv.x * 6.0 + iTime * 0.3) * 0.08 + 0.65;
+    float glow = exp(-abs(uv.y - band) * 12.0);
+    vec3 sky = mix(vec3(0.02, 0.02, 0.08), vec3(0.05, 0.05, 0.15), uv.y);
+    vec3 aurora = vec3(0.1, 0.9, 0.5) * glow * 0.6;
+    fragColor = vec4(sky + aurora, 1.0);
+}`
+
+// fallbackShaderData returns shader data with the built-in fallback shader
+func fallbackShaderData() *ShaderData {
+	return &ShaderData{
+		Metadata: &ShaderMetadata{Title: "Fallback", NumPasses: 1},
+		Passes: []ShaderPass{
+			{Type: "image", Name: "Image", Code: fallbackShaderCode},
+		},
+	}
+}
+
+// appCacheDir returns (and creates) application directory in the user cache dir
+func appCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, APP_DIR_NAME)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// isSafeModeActive reports whether safe mode flag is set
+func isSafeModeActive() bool {
+	dir, err := appCacheDir()
+	if err != nil {
+		return false
+	}
+	return fileExists(filepath.Join(dir, SAFE_MODE_FILE))
+}
+
+// beginRunBookkeeping writes run marker and returns true if safe mode should be used.
+// A marker left over from previous run means that run did not exit cleanly.
+func beginRunBookkeeping() bool {
+	dir, err := appCacheDir()
+	if err != nil {
+		if DEBUG_MODE {
+			log.Printf("Warning: cache dir unavailable, crash detection disabled: %v", err)
+		}
+		return false
+	}
+
+	markerPath := filepath.Join(dir, RUN_MARKER_FILE)
+	safeModePath := filepath.Join(dir, SAFE_MODE_FILE)
+
+	if markerLeftByCrash(markerPath) {
+		log.Printf("Previous run did not exit cleanly, enabling safe mode")
+		if err := os.WriteFile(safeModePath, []byte("1"), 0644); err != nil && DEBUG_MODE {
+			log.Printf("Warning: could not write safe mode flag: %v", err)
+		}
+	}
+
+	pid := []byte(strconv.Itoa(os.Getpid()))
+	if err := os.WriteFile(markerPath, pid, 0644); err != nil && DEBUG_MODE {
+		log.Printf("Warning: could not write run marker: %v", err)
+	}
+
+	return fileExists(safeModePath)
+}
+
+// markerLeftByCrash reports whether the run marker at path belongs to a process
+// that is gone (an unreadable marker counts as a crash)
+func markerLeftByCrash(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileExists(path)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid == os.Getpid() {
+		return true
+	}
+	if processAlive(pid) {
+		log.Printf("Another instance (PID %d) is running, not treating its run marker as a crash", pid)
+		return false
+	}
+	return true
+}
+
+// endRunBookkeeping removes run marker on exit; clean also clears safe mode, which
+// only lasts until a run in it succeeds
+func endRunBookkeeping(clean bool) {
+	dir, err := appCacheDir()
+	if err != nil {
+		return
+	}
+	os.Remove(filepath.Join(dir, RUN_MARKER_FILE))
+	if !clean {
+		return
+	}
+	safeModePath := filepath.Join(dir, SAFE_MODE_FILE)
+	if fileExists(safeModePath) {
+		if err := os.Remove(safeModePath); err != nil {
+			log.Printf("Warning: could not clear safe mode flag: %v", err)
+		} else {
+			log.Printf("Clean exit in safe mode, next run starts normally")
+		}
+	}
+}
+
+// resetSafeMode clears safe mode flag and stale run marker
+func resetSafeMode() error {
+	dir, err := appCacheDir()
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{SAFE_MODE_FILE, RUN_MARKER_FILE} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
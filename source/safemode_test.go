@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestMarkerLeftByCrash(t *testing.T) {
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		marker string
+		crash  bool
+	}{
+		{"running instance", strconv.Itoa(os.Getppid()), false},
+		{"exited process", strconv.Itoa(exited.Process.Pid), true},
+		{"garbage", "not a pid", true},
+		{"empty", "", true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), RUN_MARKER_FILE)
+		if err := os.WriteFile(path, []byte(tt.marker), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := markerLeftByCrash(path); got != tt.crash {
+			t.Errorf("%s: markerLeftByCrash = %v, want %v", tt.name, got, tt.crash)
+		}
+	}
+}
+
+func TestSafeModeLastsUntilCleanRun(t *testing.T) {
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache) // os.UserCacheDir on Linux
+	t.Setenv("HOME", cache)           // macOS
+	t.Setenv("LocalAppData", cache)   // Windows
+	dir, err := appCacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, RUN_MARKER_FILE), []byte("not a pid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !beginRunBookkeeping() {
+		t.Fatal("leftover marker didn't enable safe mode")
+	}
+	endRunBookkeeping(false)
+	if !isSafeModeActive() {
+		t.Fatal("failed run in safe mode cleared it")
+	}
+	if !beginRunBookkeeping() {
+		t.Fatal("safe mode not kept for the run after a failed one")
+	}
+	endRunBookkeeping(true)
+	if isSafeModeActive() {
+		t.Fatal("clean run in safe mode didn't clear it")
+	}
+	if beginRunBookkeeping() {
+		t.Fatal("run after a clean safe mode run still in safe mode")
+	}
+	endRunBookkeeping(true)
+}
@@ -0,0 +1,150 @@
+// Shadertoy sampler settings of channel inputs.
+//
+// Shadertoy exports describe how each input is sampled:
+//
+//	"sampler": {"filter": "mipmap", "wrap": "repeat", "vflip": "true", "srgb": "false", "internal": "byte"}
+//
+// Exports write booleans as strings, hand-written files usually as JSON
+// booleans; both are accepted. Everything is optional: a missing, malformed
+// or unknown value keeps the default (linear filter, repeat wrap, no flip,
+// linear color), which is how channel textures were uploaded before. Our own
+// `fit` and `aspect` fields are described in texture_fit.go.
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+const (
+	SAMPLER_FILTER_NEAREST = "nearest"
+	SAMPLER_FILTER_LINEAR  = "linear"
+	SAMPLER_FILTER_MIPMAP  = "mipmap"
+	SAMPLER_WRAP_REPEAT    = "repeat"
+	SAMPLER_WRAP_CLAMP     = "clamp"
+)
+
+// samplerBool is a boolean written either as true/false or as "true"/"false"
+type samplerBool bool
+
+// UnmarshalJSON accepts JSON booleans and strings; anything else is false
+func (b *samplerBool) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = samplerBool(v)
+	case string:
+		parsed, _ := strconv.ParseBool(strings.TrimSpace(v))
+		*b = samplerBool(parsed)
+	default:
+		*b = false
+	}
+	return nil
+}
+
+// ShaderSampler describes how a channel input is sampled (Shadertoy "sampler" object)
+type ShaderSampler struct {
+	Filter   string      `json:"filter,omitempty"` // "nearest", "linear" or "mipmap"
+	Wrap     string      `json:"wrap,omitempty"`   // "repeat" or "clamp"
+	VFlip    samplerBool `json:"vflip,omitempty"`  // Flip image vertically on upload
+	SRGB     samplerBool `json:"srgb,omitempty"`   // Texture data is sRGB, sampled as linear
+	Internal string      `json:"internal,omitempty"`
+	Fit      string      `json:"fit,omitempty"`    // "stretch", "pad" or "crop" to Aspect (see texture_fit.go)
+	Aspect   float64     `json:"aspect,omitempty"` // Target width / height for Fit
+}
+
+// UnmarshalJSON keeps defaults when the sampler object is malformed instead of failing the whole file
+func (s *ShaderSampler) UnmarshalJSON(data []byte) error {
+	type plain ShaderSampler
+	var parsed plain
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		log.Printf("Warning: ignoring invalid sampler %s: %v", data, err)
+		*s = ShaderSampler{}
+		return nil
+	}
+	*s = ShaderSampler(parsed)
+	return nil
+}
+
+// defaultSampler returns normalized sampler of inputs without sampler object
+func defaultSampler() ShaderSampler {
+	return ShaderSampler{Filter: SAMPLER_FILTER_LINEAR, Wrap: SAMPLER_WRAP_REPEAT}
+}
+
+// normalized returns sampler with defaults for missing/unknown values (nil = all defaults).
+// Result only holds fields that affect uploads, so equal results can share a texture.
+func (s *ShaderSampler) normalized() ShaderSampler {
+	result := defaultSampler()
+	if s == nil {
+		return result
+	}
+	switch filter := strings.ToLower(s.Filter); filter {
+	case SAMPLER_FILTER_NEAREST, SAMPLER_FILTER_LINEAR, SAMPLER_FILTER_MIPMAP:
+		result.Filter = filter
+	case "":
+	default:
+		if DEBUG_MODE {
+			log.Printf("Unknown sampler filter %q, using %s", s.Filter, result.Filter)
+		}
+	}
+	switch wrap := strings.ToLower(s.Wrap); wrap {
+	case SAMPLER_WRAP_REPEAT, SAMPLER_WRAP_CLAMP:
+		result.Wrap = wrap
+	case "":
+	default:
+		if DEBUG_MODE {
+			log.Printf("Unknown sampler wrap %q, using %s", s.Wrap, result.Wrap)
+		}
+	}
+	result.VFlip = s.VFlip
+	result.SRGB = s.SRGB
+	// Stretch is left empty, so samplers differing only in an unused aspect share a texture
+	switch fit := strings.ToLower(s.Fit); fit {
+	case SAMPLER_FIT_PAD, "letterbox", SAMPLER_FIT_CROP:
+		if s.Aspect >= 1/SAMPLER_ASPECT_MAX && s.Aspect <= SAMPLER_ASPECT_MAX {
+			result.Fit, result.Aspect = fit, s.Aspect
+			if fit == "letterbox" {
+				result.Fit = SAMPLER_FIT_PAD
+			}
+		} else if DEBUG_MODE {
+			log.Printf("Sampler aspect %g out of range, using %s", s.Aspect, SAMPLER_FIT_STRETCH)
+		}
+	case SAMPLER_FIT_STRETCH, "":
+	default:
+		if DEBUG_MODE {
+			log.Printf("Unknown sampler fit %q, using %s", s.Fit, SAMPLER_FIT_STRETCH)
+		}
+	}
+	return result
+}
+
+// glParams returns GL texture parameters of a normalized sampler
+func (s ShaderSampler) glParams() (minFilter, magFilter, wrap int32, mipmap bool) {
+	minFilter, magFilter = gl.LINEAR, gl.LINEAR
+	switch s.Filter {
+	case SAMPLER_FILTER_NEAREST:
+		minFilter, magFilter = gl.NEAREST, gl.NEAREST
+	case SAMPLER_FILTER_MIPMAP:
+		minFilter, mipmap = gl.LINEAR_MIPMAP_LINEAR, true
+	}
+	wrap = gl.REPEAT
+	if s.Wrap == SAMPLER_WRAP_CLAMP {
+		wrap = gl.CLAMP_TO_EDGE
+	}
+	return minFilter, magFilter, wrap, mipmap
+}
+
+// internalFormat returns GL internal format for RGBA8 data of a normalized sampler
+func (s ShaderSampler) internalFormat() int32 {
+	if s.SRGB {
+		return gl.SRGB8_ALPHA8
+	}
+	return gl.RGBA8
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+// samplerExportSample is a pass in Shadertoy export format: string booleans, a JSON-boolean
+// sampler, an input without sampler and a malformed sampler, which must not fail the file
+const samplerExportSample = `{"passes":[{"type":"image","code":"void mainImage(out vec4 c, in vec2 p) { c = texture(iChannel0, p); }",
+"inputs":[
+ {"id":"4dXGzr","filepath":"/media/a/0a40562379b63dfb89227e6d172f39fdce9022cba76623f1054a2c83d6c0ba5d.png","type":"texture","channel":0,
+  "sampler":{"filter":"mipmap","wrap":"repeat","vflip":"true","srgb":"false","internal":"byte"},"published":1},
+ {"id":"XdX3Rn","type":"texture","channel":1,"sampler":{"filter":"nearest","wrap":"clamp","vflip":false,"srgb":true}},
+ {"id":"4sf3Rn","type":"noise","channel":2},
+ {"id":"XsfGRn","type":"texture","channel":3,"sampler":"linear"}]}]}`
+
+// TestSamplers checks sampler objects of a Shadertoy export parse tolerantly
+func TestSamplers(t *testing.T) {
+	shaderData, err := parseShaderJSON([]byte(samplerExportSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := channelSamplers(&shaderData.Passes[0])
+	want := [CHANNEL_COUNT]ShaderSampler{
+		{Filter: SAMPLER_FILTER_MIPMAP, Wrap: SAMPLER_WRAP_REPEAT, VFlip: true},
+		{Filter: SAMPLER_FILTER_NEAREST, Wrap: SAMPLER_WRAP_CLAMP, SRGB: true},
+		defaultSampler(),
+		defaultSampler(),
+	}
+	for channel := range got {
+		if got[channel] != want[channel] {
+			t.Errorf("iChannel%d sampler %+v, expected %+v", channel, got[channel], want[channel])
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 1, 2))
+	img.Pix[0] = 255
+	if flipped := flipVertical(img); flipped.Pix[0] != 0 || flipped.Pix[4] != 255 {
+		t.Errorf("vflip didn't swap rows")
+	}
+}
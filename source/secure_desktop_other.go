@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+// isSecureDesktop: only Windows starts screensavers on a separate secure desktop.
+func isSecureDesktop() bool {
+	return false
+}
@@ -0,0 +1,53 @@
+//go:build windows
+// +build windows
+
+// Secure desktop detection.
+//
+// When the session is locked (or "On resume, display logon screen" is set),
+// Windows may start the screensaver on the Winlogon desktop instead of the
+// user's "Default" desktop. There the process must behave like a plain
+// screensaver: exit on any input so Windows returns to the lock screen, and
+// never start other processes (browser, dialogs), which would either fail or
+// show up behind the lock screen.
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// UOI_NAME is GetUserObjectInformation index of the object name
+const UOI_NAME = 2
+
+// INTERACTIVE_DESKTOP_NAME is the desktop of an unlocked user session
+const INTERACTIVE_DESKTOP_NAME = "Default"
+
+var (
+	procGetThreadDesktop          = user32.NewProc("GetThreadDesktop")
+	procGetUserObjectInformationW = user32.NewProc("GetUserObjectInformationW")
+	procGetCurrentThreadId        = syscall.NewLazyDLL("kernel32.dll").NewProc("GetCurrentThreadId")
+)
+
+// desktopName returns name of the desktop the calling thread runs on ("" if unknown)
+func desktopName() string {
+	threadID, _, _ := procGetCurrentThreadId.Call()
+	desktop, _, _ := procGetThreadDesktop.Call(threadID)
+	if desktop == 0 {
+		return ""
+	}
+	var name [256]uint16
+	var needed uint32
+	ok, _, _ := procGetUserObjectInformationW.Call(desktop, UOI_NAME,
+		uintptr(unsafe.Pointer(&name[0])), uintptr(len(name)*2), uintptr(unsafe.Pointer(&needed)))
+	if ok == 0 {
+		return ""
+	}
+	return syscall.UTF16ToString(name[:])
+}
+
+// isSecureDesktop reports whether the process runs on a desktop other than the user's
+// (Winlogon desktop of a locked session)
+func isSecureDesktop() bool {
+	name := desktopName()
+	return name != "" && name != INTERACTIVE_DESKTOP_NAME
+}
@@ -0,0 +1,181 @@
+// Headless self-test (`-selftest`).
+//
+// Runs the render pipeline without showing a window: create a GL context,
+// load, repair and compile the shader (embedded or `-shader`), render a few
+// frames into an offscreen target, check for GL errors and time blocking
+// against PBO readback. Each step prints OK/FAIL with its duration; the exit
+// code is non-zero on failure. Meant for post-install checks on machines with
+// a GL 3.3 context; checks that need no GL are unit tests (`go test`).
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+const (
+	SELFTEST_FRAMES = 10
+	SELFTEST_WIDTH  = 640
+	SELFTEST_HEIGHT = 360
+
+	SELFTEST_TIME_STEP = 1.0 / 60.0
+)
+
+// selfTestStep runs fn and prints its result with timing, returns its error
+func selfTestStep(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	duration := float64(time.Since(start).Microseconds()) / 1000.0
+	if err != nil {
+		fmt.Printf("FAIL %-16s %v (%.1f ms)\n", name, err, duration)
+		return err
+	}
+	fmt.Printf("OK   %-16s (%.1f ms)\n", name, duration)
+	return nil
+}
+
+// runSelfTest exercises render pipeline offscreen, returns the error of the failed
+// step (see exitCode) or nil
+func runSelfTest(cfg *Config) error {
+	start := time.Now()
+	err := selfTestPipeline(cfg)
+	total := float64(time.Since(start).Microseconds()) / 1000.0
+	if err == nil {
+		fmt.Printf("Self-test OK (%.1f ms)\n", total)
+	} else {
+		fmt.Printf("Self-test FAILED (%.1f ms)\n", total)
+	}
+	return err
+}
+
+// selfTestPipeline runs self-test steps in order, stops at first failure
+func selfTestPipeline(cfg *Config) error {
+	// glfw.Terminate is safe to call even if Init failed
+	defer glfw.Terminate()
+
+	// Results of the steps, freed after the last one that ran
+	var (
+		window                       *glfw.Window
+		shaderData                   *ShaderData
+		vertexShader, fragmentShader string
+		program                      uint32
+		renderer                     *frameRenderer
+	)
+	defer func() {
+		if renderer != nil {
+			renderer.delete()
+		}
+		if program != 0 {
+			gl.DeleteProgram(program)
+		}
+		if window != nil {
+			window.Destroy()
+		}
+	}()
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"GL context", func() error {
+			var err error
+			window, err = createOffscreenContext(SELFTEST_WIDTH, SELFTEST_HEIGHT)
+			if err != nil {
+				return err
+			}
+			info := getGLContextInfo()
+			fmt.Printf("     OpenGL %s (%s)\n", info.version, info.renderer)
+			if !info.meetsRequirement() {
+				return &glContextError{fmt.Errorf("OpenGL %d.%d required, got %s", GL_REQUIRED_MAJOR, GL_REQUIRED_MINOR, info.version)}
+			}
+			return nil
+		}},
+		{"load shader", func() error {
+			var err error
+			if shaderData, err = loadShader(cfg); err != nil {
+				return &shaderError{err}
+			}
+			return nil
+		}},
+		{"process shader", func() error {
+			var err error
+			if vertexShader, fragmentShader, err = getMainShaderCode(shaderData, cfg); err != nil {
+				return &shaderError{err}
+			}
+			return nil
+		}},
+		{"compile shader", func() error {
+			var err error
+			if program, err = buildProgram(vertexShader, fragmentShader); err != nil {
+				return &shaderError{err}
+			}
+			return nil
+		}},
+		{"render frames", func() error {
+			var err error
+			renderer, err = newFrameRenderer(program, shaderData, cfg, SELFTEST_WIDTH, SELFTEST_HEIGHT)
+			if err != nil {
+				return err
+			}
+			for frame := 1; frame <= SELFTEST_FRAMES; frame++ {
+				renderer.renderFrame(frame, SELFTEST_TIME_STEP)
+			}
+			gl.Finish()
+			return pendingGLError()
+		}},
+		{"readback", func() error {
+			return checkReadback(renderer)
+		}},
+	}
+	for _, step := range steps {
+		if err := selfTestStep(step.name, step.fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkReadback renders frames with blocking and PBO readback, prints time per frame of
+// both and checks they return the same pixels
+func checkReadback(renderer *frameRenderer) error {
+	width, height := renderer.target.width, renderer.target.height
+	syncPixels := make([]byte, width*height*4)
+	start := time.Now()
+	for frame := 1; frame <= SELFTEST_FRAMES; frame++ {
+		renderer.renderFrame(frame, SELFTEST_TIME_STEP)
+		if err := readPixelsSync(renderer.target.fbo, width, height, syncPixels); err != nil {
+			return err
+		}
+	}
+	syncTime := time.Since(start)
+
+	readback := newPixelReadback(width, height)
+	defer readback.delete()
+	asyncPixels := make([]byte, 0, width*height*4)
+	keep := func(pixels []byte) error {
+		asyncPixels = append(asyncPixels[:0], pixels...)
+		return nil
+	}
+	start = time.Now()
+	for frame := 1; frame <= SELFTEST_FRAMES; frame++ {
+		renderer.renderFrame(frame, SELFTEST_TIME_STEP)
+		if err := readback.readbackFramebuffer(renderer.target.fbo, keep); err != nil {
+			return err
+		}
+	}
+	if err := readback.flush(keep); err != nil {
+		return err
+	}
+	asyncTime := time.Since(start)
+
+	perFrame := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000.0 / SELFTEST_FRAMES }
+	fmt.Printf("     ReadPixels %.2f ms/frame, PBO %.2f ms/frame\n", perFrame(syncTime), perFrame(asyncTime))
+	if !bytes.Equal(syncPixels, asyncPixels) {
+		return fmt.Errorf("PBO readback differs from ReadPixels")
+	}
+	return pendingGLError()
+}
@@ -0,0 +1,282 @@
+// Persisted user settings.
+//
+// The settings dialog (`/c` -> Settings) saves user choices as JSON in the
+// user config directory (`AuroraBorealisBliss/config.json`). Every mode loads
+// the file at start; command line flags are applied on top, so they always
+// win over saved settings. A missing or unreadable file means defaults.
+//
+// Export/import in the settings dialog use the same JSON format. Unknown keys
+// are ignored, so files from newer versions still load.
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	SETTINGS_FILE = "config.json"
+
+	// iScale range offered by the settings slider
+	SCALE_MIN     = 0.25
+	SCALE_MAX     = 4.0
+	SCALE_DEFAULT = 1.0
+
+	// Animation speed (iTime multiplier) range offered by the settings slider
+	SPEED_MIN     = 0.1
+	SPEED_MAX     = 4.0
+	SPEED_DEFAULT = 1.0
+
+	// Brightness (final color multiplier) range offered by the settings slider
+	BRIGHTNESS_MIN     = 0.1
+	BRIGHTNESS_MAX     = 2.0
+	BRIGHTNESS_DEFAULT = 1.0
+
+	// Longest iTime wrap period accepted from settings file (1 day)
+	TIME_WRAP_MAX = 86400.0
+
+	// Fixed timestep range in seconds (0 = off)
+	FIXED_STEP_MIN = 0.001
+	FIXED_STEP_MAX = MAX_TIME_DELTA
+)
+
+// Settings holds values saved by the settings dialog
+type Settings struct {
+	// Scale is the feature size multiplier passed to shaders as iScale
+	Scale float64 `json:"scale"`
+	// Speed multiplies iTime and iTimeDelta (1 = as authored)
+	Speed float64 `json:"speed"`
+	// Brightness multiplies the final color, next to iFade (1 = as rendered)
+	Brightness float64 `json:"brightness"`
+	// HideCursor hides the mouse cursor over the screensaver (never in -interactive)
+	HideCursor bool `json:"hide_cursor"`
+	// TimeWrap is iTime wrap period in seconds (0 = no wrapping)
+	TimeWrap float64 `json:"time_wrap"`
+	// Shader is the last chosen shader file (empty = built-in shader)
+	Shader string `json:"shader"`
+	// Palette is the bundled color palette name (see palette.go)
+	Palette string `json:"palette"`
+	// Vignette is the edge darkening strength (0 = off)
+	Vignette float64 `json:"vignette"`
+	// Params holds shader parameter values: shader key -> param name -> value (see params.go)
+	Params map[string]map[string]float64 `json:"params,omitempty"`
+	// Clock configures the clock overlay (see clock.go)
+	Clock ClockSettings `json:"clock"`
+	// Banner configures the message banner overlay (see banner.go); not in the dialog
+	Banner BannerSettings `json:"banner"`
+	// Exit screenshots for catalog imagery (see exit_screenshot.go); not in the dialog
+	ExitScreenshotDir     string `json:"exit_screenshot_dir,omitempty"`
+	ExitScreenshotOverlay bool   `json:"exit_screenshot_overlay,omitempty"`
+
+	// Advanced (map to Config fields of the same names)
+	NoFix       bool     `json:"no_fix"`
+	SkipFixes   []string `json:"skip_fixes"`
+	RenderScale float64  `json:"render_scale"`
+	MaxFPS      int      `json:"max_fps"`
+	ShaderRate  int      `json:"shader_rate"`
+	FixedStep   float64  `json:"fixed_step"`
+	SnapTime    bool     `json:"snap_time"`
+	VSync       bool     `json:"vsync"`
+	// Exit on mouse movement past threshold (window pixels of total travel)
+	ExitOnMove    bool `json:"exit_on_move"`
+	MoveThreshold int  `json:"move_threshold"`
+	// Power saving: animate FreezeAfter seconds, then hold the frame FreezeFor seconds (0 = always live)
+	FreezeAfter float64 `json:"freeze_after"`
+	FreezeFor   float64 `json:"freeze_for"`
+	// ShaderEditor shows the Edit... button of the shader editor (see shader_editor.go)
+	ShaderEditor bool `json:"shader_editor,omitempty"`
+}
+
+// defaultSettings returns settings used when nothing was saved yet
+func defaultSettings() Settings {
+	return Settings{
+		Scale:         SCALE_DEFAULT,
+		Speed:         SPEED_DEFAULT,
+		Brightness:    BRIGHTNESS_DEFAULT,
+		HideCursor:    HIDE_MOUSE_CURSOR,
+		Palette:       PALETTE_AURORA,
+		Clock:         defaultClockSettings(),
+		Banner:        defaultBannerSettings(),
+		RenderScale:   1.0,
+		VSync:         true,
+		MoveThreshold: MOVE_THRESHOLD_DEFAULT,
+		FreezeFor:     FREEZE_FOR_DEFAULT,
+	}
+}
+
+// clampFloat limits value to [min, max]
+func clampFloat(value, min, max float64) float64 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// normalize replaces out-of-range values (hand-edited file) with valid ones
+func (s *Settings) normalize() {
+	if s.Scale <= 0 {
+		s.Scale = SCALE_DEFAULT
+	}
+	s.Scale = clampFloat(s.Scale, SCALE_MIN, SCALE_MAX)
+	if s.Speed <= 0 {
+		s.Speed = SPEED_DEFAULT
+	}
+	s.Speed = clampFloat(s.Speed, SPEED_MIN, SPEED_MAX)
+	if s.Brightness <= 0 {
+		s.Brightness = BRIGHTNESS_DEFAULT
+	}
+	s.Brightness = clampFloat(s.Brightness, BRIGHTNESS_MIN, BRIGHTNESS_MAX)
+	s.TimeWrap = clampFloat(s.TimeWrap, 0, TIME_WRAP_MAX)
+	if validatePalette(s.Palette) != nil {
+		s.Palette = PALETTE_AURORA
+	}
+	s.Vignette = clampFloat(s.Vignette, 0, VIGNETTE_MAX)
+	if s.RenderScale <= 0 {
+		s.RenderScale = 1.0
+	}
+	s.RenderScale = clampFloat(s.RenderScale, RENDER_SCALE_MIN, RENDER_SCALE_MAX)
+	if s.MaxFPS < 0 {
+		s.MaxFPS = 0
+	}
+	s.ShaderRate = max(min(s.ShaderRate, SHADER_RATE_MAX), 0)
+	s.FreezeAfter = clampFloat(s.FreezeAfter, 0, FREEZE_MAX)
+	if s.FreezeFor <= 0 {
+		s.FreezeFor = FREEZE_FOR_DEFAULT
+	}
+	s.FreezeFor = clampFloat(s.FreezeFor, 1, FREEZE_MAX)
+	if s.FixedStep > 0 {
+		s.FixedStep = clampFloat(s.FixedStep, FIXED_STEP_MIN, FIXED_STEP_MAX)
+	} else {
+		s.FixedStep = 0
+	}
+	s.Clock.normalize()
+	s.Banner.normalize()
+	if s.MoveThreshold <= 0 {
+		s.MoveThreshold = MOVE_THRESHOLD_DEFAULT
+	}
+	s.MoveThreshold = min(s.MoveThreshold, MOVE_THRESHOLD_MAX)
+	// Drop fix names this version doesn't know (file from newer version)
+	var skipFixes []string
+	for _, name := range s.SkipFixes {
+		if isKnownFix(name) {
+			skipFixes = append(skipFixes, name)
+		}
+	}
+	s.SkipFixes = skipFixes
+}
+
+// apply copies settings into runtime configuration
+func (s Settings) apply(cfg *Config) {
+	cfg.Scale = s.Scale
+	cfg.Speed = s.Speed
+	cfg.Brightness = s.Brightness
+	cfg.HideCursor = s.HideCursor
+	cfg.TimeWrap = s.TimeWrap
+	cfg.Palette = s.Palette
+	cfg.Vignette = s.Vignette
+	cfg.NoFix = s.NoFix
+	cfg.SkipFixes = make(map[string]bool)
+	for _, name := range s.SkipFixes {
+		cfg.SkipFixes[name] = true
+	}
+	cfg.RenderScale = s.RenderScale
+	cfg.MaxFPS = s.MaxFPS
+	cfg.ShaderRate = s.ShaderRate
+	cfg.FixedStep = s.FixedStep
+	cfg.SnapTime = s.SnapTime
+	cfg.FreezeAfter = s.FreezeAfter
+	cfg.FreezeFor = s.FreezeFor
+	cfg.VSync = s.VSync
+	cfg.ExitOnMove = s.ExitOnMove
+	cfg.MoveThreshold = s.MoveThreshold
+	cfg.ShaderParams = s.Params
+	cfg.Clock = s.Clock
+	cfg.Banner = s.Banner
+	cfg.ExitScreenshotDir = s.ExitScreenshotDir
+	cfg.ExitScreenshotOverlay = s.ExitScreenshotOverlay
+	// Shader file may have been moved or deleted since it was chosen
+	if s.Shader != "" {
+		if isShaderURL(s.Shader) {
+			cfg.ShaderPath = s.Shader
+		} else if _, err := os.Stat(s.Shader); err == nil {
+			cfg.ShaderPath = s.Shader
+		} else {
+			log.Printf("Warning: saved shader unavailable, using built-in shader: %v", err)
+		}
+	}
+}
+
+// settingsPath returns path of the settings file (directory is not created)
+func settingsPath() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, APP_DIR_NAME, SETTINGS_FILE), nil
+}
+
+// loadSettings reads saved settings, falling back to defaults
+func loadSettings() Settings {
+	settings := defaultSettings()
+
+	path, err := settingsPath()
+	if err != nil {
+		if DEBUG_MODE {
+			log.Printf("Warning: settings directory unavailable: %v", err)
+		}
+		return settings
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if DEBUG_MODE && !os.IsNotExist(err) {
+			log.Printf("Warning: cannot read settings: %v", err)
+		}
+		return settings
+	}
+	settings, err = parseSettings(data)
+	if err != nil {
+		log.Printf("Warning: ignoring invalid settings file %s: %v", path, err)
+	}
+	return settings
+}
+
+// parseSettings decodes settings JSON; returns defaults and error if data is invalid
+func parseSettings(data []byte) (Settings, error) {
+	settings := defaultSettings()
+	// Unmarshal over defaults: keys missing in older files keep default values
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return defaultSettings(), err
+	}
+	settings.normalize()
+	return settings, nil
+}
+
+// marshalSettings encodes settings as indented JSON
+func marshalSettings(settings Settings) ([]byte, error) {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// saveSettings writes settings file, creating the directory if needed
+func saveSettings(settings Settings) error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := marshalSettings(settings)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,823 @@
+// Settings window opened from the About dialog.
+//
+// Edits Settings (see settings.go) and saves them on "Save"; running
+// screensaver instances pick the new values up on their next start.
+// Export/Import write and read the same JSON as the settings file, so users
+// can share presets; imported values show up in the dialog until saved.
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	SETTINGS_WINDOW_TITLE = "Settings"
+	SAVE_BUTTON_TEXT      = "Save"
+	CANCEL_BUTTON_TEXT    = "Cancel"
+	IMPORT_BUTTON_TEXT    = "Import..."
+	EXPORT_BUTTON_TEXT    = "Export..."
+	EXPORT_FILE_NAME      = "aurora-settings.json"
+	CHOOSE_SHADER_TEXT    = "Choose..."
+	BUILTIN_SHADER_TEXT   = "Built-in"
+	RESET_SAFE_MODE_TEXT  = "Reset safe mode"
+
+	// File dialogs are drawn inside the window and need more room than the settings window has
+	FILE_DIALOG_WIDTH  = 700
+	FILE_DIALOG_HEIGHT = 500
+)
+
+// timeWrapChoice is an entry of the iTime wrap period selector
+type timeWrapChoice struct {
+	label   string
+	seconds float64
+}
+
+// Periods are multiples of common animation frequencies, so most shaders loop seamlessly
+var timeWrapChoices = []timeWrapChoice{
+	{"Off", 0},
+	{"10 minutes", 600},
+	{"1 hour", 3600},
+	{"6 hours", 21600},
+}
+
+// settingsWindow is the open settings window (nil if closed)
+var settingsWindow fyne.Window
+
+// fpsChoices are frame rate caps offered in the advanced tab (0 = unlimited)
+var fpsChoices = []int{0, 30, 60, 120, 144}
+
+// fpsLabel formats frame rate cap for selector
+func fpsLabel(fps int) string {
+	if fps <= 0 {
+		return "Unlimited"
+	}
+	return fmt.Sprintf("%d FPS", fps)
+}
+
+// shaderRateChoices are shader update rates offered in the advanced tab (0 = every frame)
+var shaderRateChoices = []int{0, 15, 20, 30}
+
+// shaderRateLabel formats shader update rate for selector
+func shaderRateLabel(rate int) string {
+	if rate <= 0 {
+		return "Every frame"
+	}
+	return fmt.Sprintf("%d updates/s", rate)
+}
+
+// fixedStepRates are fixed timestep rates offered in the advanced tab, in steps per second (0 = off)
+var fixedStepRates = []int{0, 30, 60, 120}
+
+// fixedStepLabel formats fixed timestep for selector
+func fixedStepLabel(seconds float64) string {
+	if seconds <= 0 {
+		return "Off (per frame)"
+	}
+	return fmt.Sprintf("%.4g steps/s", 1/seconds)
+}
+
+// freezeAfterChoices and freezeForChoices are power saving periods offered in the advanced tab, in seconds
+var (
+	freezeAfterChoices = []float64{0, 60, 300, 900, 1800}
+	freezeForChoices   = []float64{60, 300, 900, 1800}
+)
+
+// periodLabel formats power saving period for selector (0 = off)
+func periodLabel(seconds float64) string {
+	switch {
+	case seconds <= 0:
+		return "Never"
+	case math.Mod(seconds, 60) == 0:
+		return fmt.Sprintf("%g min", seconds/60)
+	}
+	return fmt.Sprintf("%g s", seconds)
+}
+
+// newPeriodSelect builds a selector of periods writing to value; the returned function
+// reloads it from value, adding a custom period from a hand-edited or imported file
+func newPeriodSelect(choices []float64, value *float64) (*widget.Select, func()) {
+	periods := append([]float64(nil), choices...)
+	periodSelect := widget.NewSelect(nil, func(label string) {
+		for _, period := range periods {
+			if periodLabel(period) == label {
+				*value = period
+			}
+		}
+	})
+	refresh := func() {
+		known := false
+		for _, period := range periods {
+			known = known || periodLabel(period) == periodLabel(*value)
+		}
+		if !known {
+			periods = append(periods, *value)
+		}
+		var labels []string
+		for _, period := range periods {
+			labels = append(labels, periodLabel(period))
+		}
+		periodSelect.SetOptions(labels)
+		periodSelect.SetSelected(periodLabel(*value))
+	}
+	return periodSelect, refresh
+}
+
+// newAdvancedTab builds controls for settings mapped to Config power-user options.
+// Returns tab content and a function that reloads controls from settings (after import).
+func newAdvancedTab(settings *Settings, onShaderEditor func(enabled bool)) (fyne.CanvasObject, func()) {
+	// Shader repair passes: checked = pass runs
+	fixChecks := make([]*widget.Check, len(shaderFixes))
+	for i, fix := range shaderFixes {
+		name := fix.name
+		fixChecks[i] = widget.NewCheck(fix.description+" ("+name+")", func(enabled bool) {
+			var skip []string
+			for _, existing := range settings.SkipFixes {
+				if existing != name {
+					skip = append(skip, existing)
+				}
+			}
+			if !enabled {
+				skip = append(skip, name)
+			}
+			settings.SkipFixes = skip
+		})
+	}
+	noFixCheck := widget.NewCheck("Disable all shader repairs (-no-fix)", func(disabled bool) {
+		settings.NoFix = disabled
+		for _, check := range fixChecks {
+			if disabled {
+				check.Disable()
+			} else {
+				check.Enable()
+			}
+		}
+	})
+
+	// Render scale: fraction of screen resolution
+	renderScaleValue := widget.NewLabel("")
+	renderScaleSlider := widget.NewSlider(RENDER_SCALE_MIN, RENDER_SCALE_MAX)
+	renderScaleSlider.Step = 0.05
+	renderScaleSlider.OnChanged = func(value float64) {
+		settings.RenderScale = value
+		renderScaleValue.SetText(fmt.Sprintf("%.0f%%", value*100))
+	}
+	renderScaleRow := container.NewBorder(nil, nil, widget.NewLabel("Render scale"), renderScaleValue, renderScaleSlider)
+
+	// FPS cap
+	fpsValues := append([]int(nil), fpsChoices...)
+	fpsSelect := widget.NewSelect(nil, func(label string) {
+		for _, fps := range fpsValues {
+			if fpsLabel(fps) == label {
+				settings.MaxFPS = fps
+			}
+		}
+	})
+	fpsRow := container.NewBorder(nil, nil, widget.NewLabel("Frame rate cap"), nil, fpsSelect)
+
+	// Shader update rate: heavy shaders render less often, presentation stays smooth
+	shaderRateValues := append([]int(nil), shaderRateChoices...)
+	shaderRateSelect := widget.NewSelect(nil, func(label string) {
+		for _, rate := range shaderRateValues {
+			if shaderRateLabel(rate) == label {
+				settings.ShaderRate = rate
+			}
+		}
+	})
+	shaderRateRow := container.NewBorder(nil, nil, widget.NewLabel("Shader updates"), nil, shaderRateSelect)
+
+	// Fixed timestep for shaders integrating per frame
+	var stepValues []float64
+	for _, rate := range fixedStepRates {
+		step := 0.0
+		if rate > 0 {
+			step = 1 / float64(rate)
+		}
+		stepValues = append(stepValues, step)
+	}
+	fixedStepSelect := widget.NewSelect(nil, func(label string) {
+		for _, step := range stepValues {
+			if fixedStepLabel(step) == label {
+				settings.FixedStep = step
+			}
+		}
+	})
+	fixedStepRow := container.NewBorder(nil, nil, widget.NewLabel("Fixed timestep"), nil, fixedStepSelect)
+
+	vsyncCheck := widget.NewCheck("Vertical sync", func(enabled bool) {
+		settings.VSync = enabled
+	})
+	// Helps with vsync on fixed-rate displays, hurts on G-Sync/FreeSync ones
+	snapTimeCheck := widget.NewCheck("Snap animation to refresh rate (not for G-Sync/FreeSync)", func(enabled bool) {
+		settings.SnapTime = enabled
+	})
+
+	// Power saving: hold the frame periodically
+	freezeAfterSelect, refreshFreezeAfter := newPeriodSelect(freezeAfterChoices, &settings.FreezeAfter)
+	freezeForSelect, refreshFreezeFor := newPeriodSelect(freezeForChoices, &settings.FreezeFor)
+	freezeAfterRow := container.NewBorder(nil, nil, widget.NewLabel("Hold frame after"), nil, freezeAfterSelect)
+	freezeForRow := container.NewBorder(nil, nil, widget.NewLabel("Hold frame for"), nil, freezeForSelect)
+
+	// Exit on mouse movement: threshold helps with jittery touchpads
+	moveThresholdValue := widget.NewLabel("")
+	moveThresholdSlider := widget.NewSlider(MOVE_THRESHOLD_MIN, MOVE_THRESHOLD_MAX)
+	moveThresholdSlider.Step = 1
+	moveThresholdSlider.OnChanged = func(value float64) {
+		settings.MoveThreshold = int(value)
+		moveThresholdValue.SetText(fmt.Sprintf("%d px", int(value)))
+	}
+	moveThresholdRow := container.NewBorder(nil, nil, widget.NewLabel("Movement threshold"), moveThresholdValue, moveThresholdSlider)
+	exitOnMoveCheck := widget.NewCheck("Exit on mouse movement", func(enabled bool) {
+		settings.ExitOnMove = enabled
+		if enabled {
+			moveThresholdSlider.Enable()
+		} else {
+			moveThresholdSlider.Disable()
+		}
+	})
+
+	hideCursorCheck := widget.NewCheck("Hide mouse cursor", func(enabled bool) {
+		settings.HideCursor = enabled
+	})
+
+	// Shader editor for shader authors: shows the Edit... button in the Basic tab
+	shaderEditorCheck := widget.NewCheck("Shader editor (Edit... next to the shader)", func(enabled bool) {
+		settings.ShaderEditor = enabled
+		onShaderEditor(enabled)
+	})
+
+	// Safe mode after a crashed run; a screensaver user can't pass -reset-safe-mode.
+	// Resets at once, like the flag, not on Save.
+	safeModeLabel := widget.NewLabel("")
+	safeModeLabel.Wrapping = fyne.TextWrapWord
+	safeModeButton := widget.NewButton(RESET_SAFE_MODE_TEXT, nil)
+	refreshSafeMode := func() {
+		if isSafeModeActive() {
+			safeModeLabel.SetText("Safe mode is on after a crashed run (fallback shader, 30 FPS); it ends after the next clean run")
+			safeModeButton.Enable()
+			return
+		}
+		safeModeLabel.SetText("Safe mode is off")
+		safeModeButton.Disable()
+	}
+	safeModeButton.OnTapped = func() {
+		if err := resetSafeMode(); err != nil {
+			safeModeLabel.SetText("Could not reset safe mode: " + err.Error())
+			return
+		}
+		refreshSafeMode()
+	}
+
+	refresh := func() {
+		skipped := make(map[string]bool)
+		for _, name := range settings.SkipFixes {
+			skipped[name] = true
+		}
+		for i, fix := range shaderFixes {
+			fixChecks[i].SetChecked(!skipped[fix.name])
+		}
+		noFixCheck.SetChecked(settings.NoFix)
+		renderScaleSlider.SetValue(settings.RenderScale)
+		renderScaleValue.SetText(fmt.Sprintf("%.0f%%", settings.RenderScale*100))
+
+		known := false
+		for _, fps := range fpsValues {
+			known = known || fps == settings.MaxFPS
+		}
+		if !known {
+			// Custom cap from hand-edited or imported file: keep it selectable
+			fpsValues = append(fpsValues, settings.MaxFPS)
+		}
+		var labels []string
+		for _, fps := range fpsValues {
+			labels = append(labels, fpsLabel(fps))
+		}
+		fpsSelect.SetOptions(labels)
+		fpsSelect.SetSelected(fpsLabel(settings.MaxFPS))
+
+		known = false
+		for _, rate := range shaderRateValues {
+			known = known || rate == settings.ShaderRate
+		}
+		if !known {
+			shaderRateValues = append(shaderRateValues, settings.ShaderRate)
+		}
+		labels = nil
+		for _, rate := range shaderRateValues {
+			labels = append(labels, shaderRateLabel(rate))
+		}
+		shaderRateSelect.SetOptions(labels)
+		shaderRateSelect.SetSelected(shaderRateLabel(settings.ShaderRate))
+
+		known = false
+		for _, step := range stepValues {
+			known = known || fixedStepLabel(step) == fixedStepLabel(settings.FixedStep)
+		}
+		if !known {
+			stepValues = append(stepValues, settings.FixedStep)
+		}
+		labels = nil
+		for _, step := range stepValues {
+			labels = append(labels, fixedStepLabel(step))
+		}
+		fixedStepSelect.SetOptions(labels)
+		fixedStepSelect.SetSelected(fixedStepLabel(settings.FixedStep))
+
+		vsyncCheck.SetChecked(settings.VSync)
+		snapTimeCheck.SetChecked(settings.SnapTime)
+		refreshFreezeAfter()
+		refreshFreezeFor()
+		exitOnMoveCheck.SetChecked(settings.ExitOnMove)
+		moveThresholdSlider.SetValue(float64(settings.MoveThreshold))
+		moveThresholdValue.SetText(fmt.Sprintf("%d px", settings.MoveThreshold))
+		hideCursorCheck.SetChecked(settings.HideCursor)
+		shaderEditorCheck.SetChecked(settings.ShaderEditor)
+		refreshSafeMode()
+	}
+	refresh()
+
+	fixes := container.NewVBox(noFixCheck)
+	for _, check := range fixChecks {
+		fixes.Add(check)
+	}
+	hint := widget.NewLabel("Changes apply on next start")
+	content := container.NewVBox(
+		widget.NewLabelWithStyle("Shader repairs", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		fixes,
+		widget.NewSeparator(),
+		renderScaleRow,
+		fpsRow,
+		shaderRateRow,
+		fixedStepRow,
+		vsyncCheck,
+		snapTimeCheck,
+		freezeAfterRow,
+		freezeForRow,
+		widget.NewSeparator(),
+		exitOnMoveCheck,
+		moveThresholdRow,
+		hideCursorCheck,
+		hint,
+		widget.NewSeparator(),
+		shaderEditorCheck,
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, nil, safeModeButton, safeModeLabel),
+	)
+	return container.NewVScroll(content), refresh
+}
+
+// clockPositions are corners offered for the clock overlay
+var clockPositions = []string{WATERMARK_POS_TOP_LEFT, WATERMARK_POS_TOP_RIGHT, WATERMARK_POS_BOTTOM_LEFT, WATERMARK_POS_BOTTOM_RIGHT}
+
+// newClockTab builds controls for the clock overlay (see clock.go).
+// Returns tab content and a function that reloads controls from settings (after import).
+func newClockTab(settings *Settings) (fyne.CanvasObject, func()) {
+	enabledCheck := widget.NewCheck("Show clock", func(enabled bool) {
+		settings.Clock.Enabled = enabled
+	})
+	use24hCheck := widget.NewCheck("24-hour format", func(enabled bool) {
+		settings.Clock.Use24h = enabled
+	})
+	secondsCheck := widget.NewCheck("Show seconds", func(enabled bool) {
+		settings.Clock.Seconds = enabled
+	})
+	dateCheck := widget.NewCheck("Show date", func(enabled bool) {
+		settings.Clock.Date = enabled
+	})
+
+	positionSelect := widget.NewSelect(clockPositions, func(position string) {
+		settings.Clock.Position = position
+	})
+	positionRow := container.NewBorder(nil, nil, widget.NewLabel("Corner"), nil, positionSelect)
+
+	sizeValue := widget.NewLabel("")
+	sizeSlider := widget.NewSlider(CLOCK_SIZE_MIN, CLOCK_SIZE_MAX)
+	sizeSlider.Step = 0.5
+	sizeSlider.OnChanged = func(value float64) {
+		settings.Clock.Size = value
+		sizeValue.SetText(fmt.Sprintf("%.1fx", value))
+	}
+	sizeRow := container.NewBorder(nil, nil, widget.NewLabel("Size"), sizeValue, sizeSlider)
+
+	// Invalid colors are replaced with the default on save
+	colorEntry := widget.NewEntry()
+	colorEntry.SetPlaceHolder(CLOCK_COLOR)
+	colorEntry.OnChanged = func(value string) {
+		settings.Clock.Color = value
+	}
+	colorRow := container.NewBorder(nil, nil, widget.NewLabel("Color (#RRGGBB)"), nil, colorEntry)
+
+	refresh := func() {
+		clock := settings.Clock
+		enabledCheck.SetChecked(clock.Enabled)
+		use24hCheck.SetChecked(clock.Use24h)
+		secondsCheck.SetChecked(clock.Seconds)
+		dateCheck.SetChecked(clock.Date)
+		positionSelect.SetSelected(clock.Position)
+		sizeSlider.SetValue(clock.Size)
+		sizeValue.SetText(fmt.Sprintf("%.1fx", clock.Size))
+		colorEntry.SetText(clock.Color)
+	}
+	refresh()
+
+	content := container.NewVBox(
+		enabledCheck,
+		use24hCheck,
+		secondsCheck,
+		dateCheck,
+		positionRow,
+		sizeRow,
+		colorRow,
+		widget.NewLabel("Changes apply on next start"),
+	)
+	return container.NewVScroll(content), refresh
+}
+
+// newParamsTab builds controls for parameters of the chosen shader (see params.go).
+// Returns tab content and a function that rebuilds it (after shader change or import).
+func newParamsTab(settings *Settings) (fyne.CanvasObject, func()) {
+	content := container.NewVBox()
+
+	var refresh func()
+	refresh = func() {
+		content.RemoveAll()
+
+		var shaderData *ShaderData
+		var err error
+		if settings.Shader != "" {
+			shaderData, err = loadShaderFile(settings.Shader)
+		} else {
+			shaderData, err = loadEmbeddedShader()
+		}
+		if err != nil {
+			content.Add(widget.NewLabel(fmt.Sprintf("Shader can't be loaded: %v", err)))
+			return
+		}
+		params := shaderParams(shaderData)
+		if len(params) == 0 {
+			content.Add(widget.NewLabel("This shader has no parameters"))
+			return
+		}
+
+		key := shaderParamsKey(shaderData.Metadata)
+		setValue := func(name string, value float64) {
+			if settings.Params == nil {
+				settings.Params = make(map[string]map[string]float64)
+			}
+			if settings.Params[key] == nil {
+				settings.Params[key] = make(map[string]float64)
+			}
+			settings.Params[key][name] = value
+		}
+
+		for _, param := range params {
+			param := param
+			value := param.Default
+			if saved, ok := settings.Params[key][param.Name]; ok {
+				value = param.clamp(saved)
+			}
+
+			if param.Type == PARAM_BOOL {
+				check := widget.NewCheck(param.label(), func(enabled bool) {
+					if enabled {
+						setValue(param.Name, 1)
+					} else {
+						setValue(param.Name, 0)
+					}
+				})
+				check.Checked = value != 0
+				content.Add(check)
+				continue
+			}
+
+			format := "%.2f"
+			slider := widget.NewSlider(param.Min, param.Max)
+			slider.Step = (param.Max - param.Min) / 100
+			if param.Type == PARAM_INT {
+				format = "%.0f"
+				slider.Step = 1
+			}
+			slider.Value = value
+			valueLabel := widget.NewLabel(fmt.Sprintf(format, value))
+			slider.OnChanged = func(value float64) {
+				setValue(param.Name, param.clamp(value))
+				valueLabel.SetText(fmt.Sprintf(format, param.clamp(value)))
+			}
+			content.Add(container.NewBorder(nil, nil, widget.NewLabel(param.label()), valueLabel, slider))
+		}
+
+		resetButton := widget.NewButton("Reset to defaults", func() {
+			delete(settings.Params, key)
+			refresh()
+		})
+		content.Add(container.NewHBox(resetButton))
+		content.Add(widget.NewLabel("Changes apply on next start"))
+	}
+	refresh()
+
+	return container.NewVScroll(content), refresh
+}
+
+// showFileDialog enlarges window while file dialog is open, restores size afterwards
+func showFileDialog(w fyne.Window, d *dialog.FileDialog, extensions []string) {
+	size := w.Canvas().Size()
+	w.Resize(fyne.NewSize(FILE_DIALOG_WIDTH, FILE_DIALOG_HEIGHT))
+	d.SetFilter(storage.NewExtensionFileFilter(extensions))
+	d.SetOnClosed(func() {
+		w.Resize(size)
+	})
+	d.Resize(fyne.NewSize(FILE_DIALOG_WIDTH, FILE_DIALOG_HEIGHT))
+	d.Show()
+}
+
+// showSettingsWindow opens settings window, or focuses it if already open
+func showSettingsWindow(a fyne.App, icon fyne.Resource) {
+	if settingsWindow != nil {
+		settingsWindow.RequestFocus()
+		return
+	}
+	settings := loadSettings()
+
+	w := a.NewWindow(SETTINGS_WINDOW_TITLE)
+	if icon != nil {
+		w.SetIcon(icon)
+	}
+	settingsWindow = w
+	w.SetOnClosed(func() {
+		settingsWindow = nil
+	})
+
+	// Detail / zoom: iScale multiplier for shader feature size
+	scaleValue := widget.NewLabel("")
+	updateScaleLabel := func(value float64) {
+		scaleValue.SetText(fmt.Sprintf("%.2fx", value))
+	}
+	scaleSlider := widget.NewSlider(SCALE_MIN, SCALE_MAX)
+	scaleSlider.Step = 0.05
+	scaleSlider.Value = settings.Scale
+	scaleSlider.OnChanged = func(value float64) {
+		settings.Scale = value
+		updateScaleLabel(value)
+	}
+	updateScaleLabel(settings.Scale)
+
+	scaleRow := container.NewBorder(nil, nil, widget.NewLabel("Detail / zoom"), scaleValue, scaleSlider)
+	scaleHint := widget.NewLabel("Larger values make aurora features bigger (shaders using iScale only)")
+	scaleHint.Wrapping = fyne.TextWrapWord
+
+	// Color palette fed to iPalette
+	var paletteLabels []string
+	for _, p := range palettes {
+		paletteLabels = append(paletteLabels, p.label)
+	}
+	paletteSelect := widget.NewSelect(paletteLabels, func(label string) {
+		for _, p := range palettes {
+			if p.label == label {
+				settings.Palette = p.name
+			}
+		}
+	})
+	selectPalette := func(name string) {
+		p, _ := findPalette(name)
+		paletteSelect.SetSelected(p.label)
+	}
+	selectPalette(settings.Palette)
+	paletteRow := container.NewBorder(nil, nil, widget.NewLabel("Colors"), nil, paletteSelect)
+
+	// Vignette: edge darkening strength
+	vignetteValue := widget.NewLabel("")
+	updateVignetteLabel := func(value float64) {
+		if value == 0 {
+			vignetteValue.SetText("Off")
+			return
+		}
+		vignetteValue.SetText(fmt.Sprintf("%.0f%%", value*100))
+	}
+	vignetteSlider := widget.NewSlider(0, VIGNETTE_MAX)
+	vignetteSlider.Step = 0.05
+	vignetteSlider.Value = settings.Vignette
+	vignetteSlider.OnChanged = func(value float64) {
+		settings.Vignette = value
+		updateVignetteLabel(value)
+	}
+	updateVignetteLabel(settings.Vignette)
+	vignetteRow := container.NewBorder(nil, nil, widget.NewLabel("Vignette"), vignetteValue, vignetteSlider)
+
+	// Animation speed: iTime multiplier
+	speedValue := widget.NewLabel("")
+	updateSpeedLabel := func(value float64) {
+		speedValue.SetText(fmt.Sprintf("%.2fx", value))
+	}
+	speedSlider := widget.NewSlider(SPEED_MIN, SPEED_MAX)
+	speedSlider.Step = 0.05
+	speedSlider.Value = settings.Speed
+	speedSlider.OnChanged = func(value float64) {
+		settings.Speed = value
+		updateSpeedLabel(value)
+	}
+	updateSpeedLabel(settings.Speed)
+	speedRow := container.NewBorder(nil, nil, widget.NewLabel("Speed"), speedValue, speedSlider)
+
+	// Brightness: final color multiplier
+	brightnessValue := widget.NewLabel("")
+	updateBrightnessLabel := func(value float64) {
+		brightnessValue.SetText(fmt.Sprintf("%.0f%%", value*100))
+	}
+	brightnessSlider := widget.NewSlider(BRIGHTNESS_MIN, BRIGHTNESS_MAX)
+	brightnessSlider.Step = 0.05
+	brightnessSlider.Value = settings.Brightness
+	brightnessSlider.OnChanged = func(value float64) {
+		settings.Brightness = value
+		updateBrightnessLabel(value)
+	}
+	updateBrightnessLabel(settings.Brightness)
+	brightnessRow := container.NewBorder(nil, nil, widget.NewLabel("Brightness"), brightnessValue, brightnessSlider)
+
+	// Wrap iTime to keep float precision on long runs
+	choices := append([]timeWrapChoice(nil), timeWrapChoices...)
+	timeWrapSelect := widget.NewSelect(nil, func(label string) {
+		for _, choice := range choices {
+			if choice.label == label {
+				settings.TimeWrap = choice.seconds
+			}
+		}
+	})
+	selectTimeWrap := func(seconds float64) {
+		selected := ""
+		for _, choice := range choices {
+			if choice.seconds == seconds {
+				selected = choice.label
+			}
+		}
+		if selected == "" {
+			// Custom period from hand-edited or imported file: keep it selectable
+			custom := timeWrapChoice{fmt.Sprintf("%.0f seconds", seconds), seconds}
+			choices = append(choices, custom)
+			selected = custom.label
+		}
+		var labels []string
+		for _, choice := range choices {
+			labels = append(labels, choice.label)
+		}
+		timeWrapSelect.SetOptions(labels)
+		timeWrapSelect.SetSelected(selected)
+	}
+	selectTimeWrap(settings.TimeWrap)
+	timeWrapRow := container.NewBorder(nil, nil, widget.NewLabel("Loop time every"), nil, timeWrapSelect)
+
+	// Parameters tab follows the chosen shader
+	paramsTab, refreshParams := newParamsTab(&settings)
+
+	// Shader: built-in or a file (same formats as -shader)
+	shaderName := widget.NewLabel("")
+	updateShaderName := func() {
+		if settings.Shader == "" {
+			shaderName.SetText(BUILTIN_SHADER_TEXT)
+		} else {
+			shaderName.SetText(filepath.Base(settings.Shader))
+		}
+	}
+	updateShaderName()
+	chooseShaderButton := widget.NewButton(CHOOSE_SHADER_TEXT, func() {
+		showFileDialog(w, dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if reader == nil {
+				return // Cancelled
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			// Reject files the screensaver couldn't load anyway
+			if _, err := loadShaderFile(path); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			settings.Shader = path
+			updateShaderName()
+			refreshParams()
+		}, w), shaderFileExtensions)
+	})
+	builtinShaderButton := widget.NewButton(BUILTIN_SHADER_TEXT, func() {
+		settings.Shader = ""
+		updateShaderName()
+		refreshParams()
+	})
+	editShaderButton := widget.NewButton(EDIT_SHADER_BUTTON_TEXT, func() {
+		showShaderEditor(a, icon, w, settings.Shader, func(path string) {
+			settings.Shader = path
+			updateShaderName()
+			refreshParams()
+		})
+	})
+	showEditShaderButton := func(enabled bool) {
+		if enabled {
+			editShaderButton.Show()
+		} else {
+			editShaderButton.Hide()
+		}
+	}
+	showEditShaderButton(settings.ShaderEditor)
+	shaderRow := container.NewBorder(nil, nil, widget.NewLabel("Shader"),
+		container.NewHBox(chooseShaderButton, builtinShaderButton, editShaderButton), shaderName)
+
+	clockTab, refreshClock := newClockTab(&settings)
+	advancedTab, refreshAdvanced := newAdvancedTab(&settings, showEditShaderButton)
+
+	saveButton := widget.NewButton(SAVE_BUTTON_TEXT, func() {
+		settings.normalize()
+		if err := saveSettings(settings); err != nil {
+			log.Printf("Error saving settings: %v", err)
+			return
+		}
+		w.Close()
+	})
+	saveButton.Importance = widget.HighImportance
+	cancelButton := widget.NewButton(CANCEL_BUTTON_TEXT, func() {
+		w.Close()
+	})
+
+	importButton := widget.NewButton(IMPORT_BUTTON_TEXT, func() {
+		showFileDialog(w, dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if reader == nil {
+				return // Cancelled
+			}
+			defer reader.Close()
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			imported, err := parseSettings(data)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("%s is not a valid settings file: %v", reader.URI().Name(), err), w)
+				return
+			}
+			settings = imported
+			scaleSlider.SetValue(settings.Scale)
+			selectPalette(settings.Palette)
+			vignetteSlider.SetValue(settings.Vignette)
+			speedSlider.SetValue(settings.Speed)
+			brightnessSlider.SetValue(settings.Brightness)
+			selectTimeWrap(settings.TimeWrap)
+			updateShaderName()
+			refreshParams()
+			refreshClock()
+			refreshAdvanced()
+		}, w), []string{".json"})
+	})
+	exportButton := widget.NewButton(EXPORT_BUTTON_TEXT, func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if writer == nil {
+				return // Cancelled
+			}
+			defer writer.Close()
+			settings.normalize()
+			data, err := marshalSettings(settings)
+			if err == nil {
+				_, err = writer.Write(data)
+			}
+			if err != nil {
+				dialog.ShowError(err, w)
+			}
+		}, w)
+		saveDialog.SetFileName(EXPORT_FILE_NAME)
+		showFileDialog(w, saveDialog, []string{".json"})
+	})
+	buttons := container.NewHBox(importButton, exportButton, layout.NewSpacer(), cancelButton, saveButton)
+
+	// Basic tab stays short; power-user options live in Advanced
+	basicTab := container.NewVBox(shaderRow, scaleRow, scaleHint, speedRow, brightnessRow, paletteRow, vignetteRow, timeWrapRow)
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Basic", basicTab),
+		container.NewTabItem("Parameters", paramsTab),
+		container.NewTabItem("Clock", clockTab),
+		container.NewTabItem("Advanced", advancedTab),
+	)
+	content := container.NewBorder(nil, buttons, nil, nil, tabs)
+	background := canvas.NewRectangle(parseColor(WINDOW_BACKGROUND_COLOR))
+	w.SetContent(container.NewStack(background, container.NewPadded(content)))
+	w.Resize(fyne.NewSize(420, 480))
+	w.CenterOnScreen()
+	w.Show()
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSpeedBrightness checks saved speed and brightness: defaults without the
+// keys, clamping of hand-edited values and their effect on iTime and iBrightness
+func TestSpeedBrightness(t *testing.T) {
+	settings, err := parseSettings([]byte(`{"scale": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Speed != SPEED_DEFAULT || settings.Brightness != BRIGHTNESS_DEFAULT || settings.HideCursor != HIDE_MOUSE_CURSOR {
+		t.Errorf("file without the keys: speed %g, brightness %g, hide cursor %v", settings.Speed, settings.Brightness, settings.HideCursor)
+	}
+	if settings, err = parseSettings([]byte(`{"speed": 9, "brightness": -1, "hide_cursor": false}`)); err != nil {
+		t.Fatal(err)
+	}
+	if settings.Speed != SPEED_MAX || settings.Brightness != BRIGHTNESS_DEFAULT || settings.HideCursor {
+		t.Errorf("out-of-range file: speed %g, brightness %g, hide cursor %v", settings.Speed, settings.Brightness, settings.HideCursor)
+	}
+
+	state := fixedFrameState(90, 1.0/60, 1920, 1080)
+	if v := frameUniformValues(&state, frameUniforms{}); v.time != 1.5 || v.brightness != 1 {
+		t.Errorf("unset speed and brightness: iTime %g, iBrightness %g", v.time, v.brightness)
+	}
+	v := frameUniformValues(&state, frameUniforms{speed: 2, brightness: 1.5, timeWrap: 2})
+	if v.time != 1 || math.Abs(float64(v.timeDelta)-2.0/60) > 1e-6 || v.brightness != 1.5 {
+		t.Errorf("speed 2, brightness 1.5: iTime %g, iTimeDelta %g, iBrightness %g", v.time, v.timeDelta, v.brightness)
+	}
+	if dump := v.dumpLines(time.Now(), nil); !slices.Contains(dump, "iBrightness=1.5") {
+		t.Errorf("iBrightness missing in uniform dump:\n%s", strings.Join(dump, "\n"))
+	}
+}
@@ -0,0 +1,56 @@
+// Shader compile check (`-check-shader`).
+//
+// Loads and repairs the shader like the screensaver does, then compiles the
+// image pass and the buffer passes it reads in a hidden GL context and exits,
+// without rendering. Compile errors are logged with the source lines around
+// them (see shader_errors.go; `-dump-full-shader` for all of it). The shader
+// editor of the settings dialog runs it in a child process, since the
+// dialog's own GL context belongs to the UI toolkit.
+//
+// Shader faults exit with EXIT_SHADER; a missing GL context is no verdict on
+// the shader and exits with EXIT_GL (see exitCode).
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// runCheckShader compiles all passes of the configured shader, printing a summary on success
+func runCheckShader(cfg *Config) error {
+	shaderData, err := loadShader(cfg)
+	if err != nil {
+		return &shaderError{fmt.Errorf("loading shader: %v", err)}
+	}
+	vertexShader, fragmentShader, err := getMainShaderCode(shaderData, cfg)
+	if err != nil {
+		return &shaderError{fmt.Errorf("extracting shader code: %v", err)}
+	}
+
+	window, err := createOffscreenContext(1, 1)
+	defer glfw.Terminate()
+	if err != nil {
+		return err
+	}
+	defer window.Destroy()
+
+	program, err := buildProgram(vertexShader, fragmentShader)
+	if err != nil {
+		return &shaderError{err}
+	}
+	defer gl.DeleteProgram(program)
+	buffers, err := newPassChain(shaderData, cfg)
+	if err != nil {
+		return &shaderError{err}
+	}
+	defer buffers.delete()
+
+	count := 0
+	if buffers != nil {
+		count = len(buffers.buffers)
+	}
+	fmt.Printf("OK: shader compiles (image pass, %d buffer passes)\n", count)
+	return nil
+}
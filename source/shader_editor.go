@@ -0,0 +1,366 @@
+// Shader editor window (Settings -> Advanced -> "Shader editor").
+//
+// For shader authors, off by default: the "Edit..." button next to the
+// shader opens the image pass code of the chosen shader (built-in, file, or
+// the cached copy of a URL) in a text editor. "Compile & Preview" writes the
+// edited shader to a temporary file and runs this executable with
+// -check-shader on it in a child process (see shader_check.go), showing
+// compile errors below the code; when it compiles, the standalone preview
+// window (`/p` without a parent window) opens on it, replacing the previous
+// one. "Save" writes the shader file back in its format (gzip-compressed if it
+// was; JSON is rewritten indented, other fields kept). The built-in shader and
+// URLs can't be written, so they are saved to a new file, which becomes the
+// chosen shader in the settings window.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	SHADER_EDITOR_TITLE         = "Shader editor"
+	EDIT_SHADER_BUTTON_TEXT     = "Edit..."
+	COMPILE_PREVIEW_BUTTON_TEXT = "Compile & Preview"
+	CLOSE_BUTTON_TEXT           = "Close"
+
+	SHADER_CHECK_TIMEOUT = 30 * time.Second
+)
+
+// shaderEditorWindow is the open shader editor window (nil if closed)
+var shaderEditorWindow fyne.Window
+
+// shaderEditor is the file edited in the shader editor window
+type shaderEditor struct {
+	path     string // Written by Save ("" = save to a new file)
+	name     string // File name, for format detection
+	data     []byte // File contents as loaded or last saved
+	inputDir string // Directory relative input src paths are relative to
+	tempPath string // Copy compiled and previewed
+	preview  *exec.Cmd
+}
+
+// openShaderEditorSource loads the shader chosen in settings for editing
+func openShaderEditorSource(shader string) (*shaderEditor, error) {
+	if shader == "" {
+		return &shaderEditor{name: "shader.json", data: shaderJSONData}, nil
+	}
+	if isShaderURL(shader) {
+		u, err := url.Parse(shader)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shader URL: %v", err)
+		}
+		name := shaderURLName(u)
+		cachePath, err := shaderCachePath(shader, name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(cachePath)
+		if err != nil {
+			return nil, fmt.Errorf("no downloaded copy of %s yet (start the screensaver once): %v", shader, err)
+		}
+		return &shaderEditor{name: name, data: data}, nil
+	}
+	data, err := os.ReadFile(shader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading shader file: %v", err)
+	}
+	return &shaderEditor{path: shader, name: filepath.Base(shader), data: data, inputDir: filepath.Dir(shader)}, nil
+}
+
+// editorPassCode returns the image pass code of shader file data
+func editorPassCode(data []byte, name string) (string, error) {
+	shaderData, err := parseShaderFile(data, name)
+	if err != nil {
+		return "", err
+	}
+	return normalizeLineEndings(selectImagePass(shaderData).Code), nil
+}
+
+// replacePassCode returns shader file data with the image pass code replaced.
+// Relative input src paths are joined to inputDir unless it is empty (for a
+// copy written elsewhere). Gzip-compressed data is compressed again.
+func replacePassCode(data []byte, name, code, inputDir string) ([]byte, error) {
+	text, err := decompressShaderData(data)
+	if err == nil {
+		text, err = decodeShaderText(text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := []byte(code)
+	if strings.EqualFold(filepath.Ext(stripGzipExt(name)), ".json") {
+		if result, err = replaceJSONPassCode(text, code, inputDir); err != nil {
+			return nil, err
+		}
+	}
+	if !isGzipData(data) {
+		return result, nil
+	}
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(result); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// replaceJSONPassCode sets "code" of the image pass in shader JSON, keeping all other fields
+func replaceJSONPassCode(text []byte, code, inputDir string) ([]byte, error) {
+	shaderData, err := parseShaderJSON(text)
+	if err != nil {
+		return nil, err
+	}
+	image := selectImagePass(shaderData)
+	index := 0
+	for i := range shaderData.Passes {
+		if &shaderData.Passes[i] == image {
+			index = i
+		}
+	}
+
+	preprocessed, err := preprocessJSON(text)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(preprocessed))
+	decoder.UseNumber() // Keep numbers as written
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	passes, _ := doc["passes"].([]any)
+	if index >= len(passes) {
+		return nil, fmt.Errorf("shader file has no pass %d", index)
+	}
+	pass, ok := passes[index].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("pass %d is not an object", index)
+	}
+	pass["code"] = code
+
+	if inputDir != "" {
+		for _, p := range passes {
+			p, _ := p.(map[string]any)
+			inputs, _ := p["inputs"].([]any)
+			for _, input := range inputs {
+				input, _ := input.(map[string]any)
+				if src, _ := input["src"].(string); src != "" && !filepath.IsAbs(src) && !strings.Contains(src, "://") {
+					input["src"] = filepath.Join(inputDir, src)
+				}
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false) // GLSL is full of < > &
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// writeTempCopy writes the shader with code to the temporary copy
+func (e *shaderEditor) writeTempCopy(code string) error {
+	data, err := replacePassCode(e.data, e.name, code, e.inputDir)
+	if err != nil {
+		return err
+	}
+	if e.tempPath == "" {
+		file, err := os.CreateTemp("", "aurora-edit-*-"+e.name)
+		if err != nil {
+			return err
+		}
+		file.Close()
+		e.tempPath = file.Name()
+	}
+	return os.WriteFile(e.tempPath, data, 0644)
+}
+
+// checkShaderFile runs -check-shader on a shader file in a child process;
+// returns the output to show and whether it compiled
+func checkShaderFile(path string) (string, bool) {
+	exe, err := os.Executable()
+	if err != nil {
+		return err.Error(), false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), SHADER_CHECK_TIMEOUT)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, exe, "-check-shader", "-shader", path)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return strings.TrimSpace(stderr.String() + "\n" + err.Error()), false
+	}
+	return strings.TrimSpace(stdout.String()), true
+}
+
+// startPreview opens the preview window on the temporary copy, closing the previous one
+func (e *shaderEditor) startPreview() error {
+	e.stopPreview()
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "-shader", e.tempPath, "/p")
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	e.preview = cmd
+	return nil
+}
+
+// stopPreview closes the preview window (no-op if there is none)
+func (e *shaderEditor) stopPreview() {
+	if e.preview != nil {
+		e.preview.Process.Kill()
+		e.preview = nil
+	}
+}
+
+// save writes code to path in the shader's format
+func (e *shaderEditor) save(path, code string) error {
+	data, err := replacePassCode(e.data, e.name, code, "")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	e.path, e.name, e.data, e.inputDir = path, filepath.Base(path), data, filepath.Dir(path)
+	return nil
+}
+
+// showShaderEditor opens the editor on the shader chosen in settings, or focuses it
+// if already open; onSavedAs is called when the shader was saved to a new file
+func showShaderEditor(a fyne.App, icon fyne.Resource, parent fyne.Window, shader string, onSavedAs func(path string)) {
+	if shaderEditorWindow != nil {
+		shaderEditorWindow.RequestFocus()
+		return
+	}
+	editor, err := openShaderEditorSource(shader)
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+	code, err := editorPassCode(editor.data, editor.name)
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+
+	w := a.NewWindow(SHADER_EDITOR_TITLE + " - " + editor.name)
+	if icon != nil {
+		w.SetIcon(icon)
+	}
+	shaderEditorWindow = w
+	w.SetOnClosed(func() {
+		shaderEditorWindow = nil
+		editor.stopPreview()
+		if editor.tempPath != "" {
+			os.Remove(editor.tempPath)
+		}
+	})
+
+	codeEntry := widget.NewMultiLineEntry()
+	codeEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	codeEntry.Wrapping = fyne.TextWrapOff
+	codeEntry.SetText(code)
+
+	output := widget.NewLabel("")
+	output.TextStyle = fyne.TextStyle{Monospace: true}
+	output.Wrapping = fyne.TextWrapWord
+	outputScroll := container.NewVScroll(output)
+	outputScroll.SetMinSize(fyne.NewSize(0, 120))
+
+	var compileButton *widget.Button
+	compileButton = widget.NewButton(COMPILE_PREVIEW_BUTTON_TEXT, func() {
+		if err := editor.writeTempCopy(codeEntry.Text); err != nil {
+			output.SetText(err.Error())
+			return
+		}
+		compileButton.Disable()
+		output.SetText("Compiling...")
+		path := editor.tempPath
+		go func() {
+			result, ok := checkShaderFile(path)
+			fyne.Do(func() {
+				if shaderEditorWindow != w {
+					return // Closed while compiling
+				}
+				if ok {
+					if err := editor.startPreview(); err != nil {
+						result += "\nPreview failed: " + err.Error()
+					}
+				}
+				output.SetText(result)
+				compileButton.Enable()
+			})
+		}()
+	})
+	compileButton.Importance = widget.HighImportance
+
+	saveButton := widget.NewButton(SAVE_BUTTON_TEXT, func() {
+		if editor.path != "" {
+			if err := editor.save(editor.path, codeEntry.Text); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			output.SetText("Saved " + editor.path)
+			return
+		}
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if writer == nil {
+				return // Cancelled
+			}
+			writer.Close()
+			path := writer.URI().Path()
+			if err := editor.save(path, codeEntry.Text); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			w.SetTitle(SHADER_EDITOR_TITLE + " - " + editor.name)
+			output.SetText("Saved " + path + " (now the chosen shader; Save the settings to keep it)")
+			onSavedAs(path)
+		}, w)
+		saveDialog.SetFileName(editor.name)
+		showFileDialog(w, saveDialog, []string{filepath.Ext(editor.name)})
+	})
+	closeButton := widget.NewButton(CLOSE_BUTTON_TEXT, func() {
+		w.Close()
+	})
+
+	buttons := container.NewHBox(compileButton, layout.NewSpacer(), closeButton, saveButton)
+	content := container.NewBorder(nil, container.NewVBox(outputScroll, buttons), nil, nil, codeEntry)
+	background := canvas.NewRectangle(parseColor(WINDOW_BACKGROUND_COLOR))
+	w.SetContent(container.NewStack(background, container.NewPadded(content)))
+	w.Resize(fyne.NewSize(760, 620))
+	w.CenterOnScreen()
+	w.Show()
+}
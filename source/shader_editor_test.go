@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestShaderEditorFiles checks that the shader editor replaces only the image pass
+// code when saving: other fields, gzip compression and bare GLSL files survive
+func TestShaderEditorFiles(t *testing.T) {
+	original, err := parseShaderJSON(shaderJSONData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := editorPassCode(shaderJSONData, "shader.json")
+	if err != nil || code != normalizeLineEndings(selectImagePass(original).Code) {
+		t.Errorf("editor code differs from the image pass: %v", err)
+	}
+	edited := "void mainImage(out vec4 c, in vec2 p) {\n    c = vec4(p.x < 0.5 && p.y > 0.5 ? 1.0 : 0.0);\n}"
+	saved, err := replacePassCode(shaderJSONData, "shader.json", edited, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(saved, []byte(`\u003c`)) {
+		t.Errorf("saved JSON escapes < in code")
+	}
+	reloaded, err := parseShaderJSON(saved)
+	if err != nil {
+		t.Fatalf("saved JSON: %v", err)
+	}
+	if selectImagePass(reloaded).Code != edited {
+		t.Errorf("saved image pass code %q, expected %q", selectImagePass(reloaded).Code, edited)
+	}
+	selectImagePass(reloaded).Code = selectImagePass(original).Code
+	if !reflect.DeepEqual(reloaded, original) {
+		t.Errorf("saving changed fields other than the image pass code")
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(shaderJSONData)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if saved, err = replacePassCode(compressed.Bytes(), "aurora.json.gz", edited, ""); err != nil || !isGzipData(saved) {
+		t.Errorf("compressed shader saved uncompressed (%v)", err)
+	}
+	if saved, err = replacePassCode([]byte(constArraySample), "aurora.glsl", edited, ""); err != nil || string(saved) != edited {
+		t.Errorf("GLSL file saved as %q, %v", saved, err)
+	}
+
+	// Temporary copies point relative texture paths at the shader's directory
+	withInput := `{"passes": [{"type": "image", "code": "void mainImage(out vec4 c, in vec2 p) { c = texture(iChannel0, p); }",
+		"inputs": [{"channel": 0, "type": "texture", "src": "tex/rock.png"}, {"channel": 1, "src": "https://example.com/a.png"}]}]}`
+	if saved, err = replacePassCode([]byte(withInput), "x.json", edited, "shaders"); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err = parseShaderJSON(saved); err != nil {
+		t.Fatal(err)
+	}
+	inputs := reloaded.Passes[0].Inputs
+	if inputs[0].Src != filepath.Join("shaders", "tex/rock.png") || inputs[1].Src != "https://example.com/a.png" {
+		t.Errorf("copied input paths %q, %q", inputs[0].Src, inputs[1].Src)
+	}
+}
@@ -0,0 +1,38 @@
+// Shader files saved as UTF-16.
+//
+// Windows editors (Notepad's "Unicode", PowerShell's Out-File) save text as
+// UTF-16 with a byte order mark, which the JSON decoder rejects with an error
+// about an invalid character at offset 0. Shader data starting with a UTF-16
+// LE or BE BOM is transcoded to UTF-8 before parsing; a UTF-8 BOM is stripped.
+// Data without a BOM is taken as UTF-8, as before.
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+const (
+	utf16LEBOM = "\xff\xfe"
+	utf16BEBOM = "\xfe\xff"
+)
+
+// decodeShaderText returns shader data as UTF-8 without BOM
+func decodeShaderText(data []byte) ([]byte, error) {
+	var endianness unicode.Endianness
+	switch {
+	case bytes.HasPrefix(data, []byte(utf16LEBOM)):
+		endianness = unicode.LittleEndian
+	case bytes.HasPrefix(data, []byte(utf16BEBOM)):
+		endianness = unicode.BigEndian
+	default:
+		return stripBOM(data), nil
+	}
+	decoded, err := unicode.UTF16(endianness, unicode.ExpectBOM).NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding UTF-16 shader: %v", err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+// TestUTF16Shader checks that shader.json saved as UTF-16 (LE and BE, with BOM)
+// loads like the UTF-8 original
+func TestUTF16Shader(t *testing.T) {
+	data, err := decompressShaderData(shaderJSONData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := parseShaderJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, endianness := range map[string]unicode.Endianness{"LE": unicode.LittleEndian, "BE": unicode.BigEndian} {
+		encoded, err := unicode.UTF16(endianness, unicode.UseBOM).NewEncoder().Bytes(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromJSON, err := parseShaderJSON(encoded)
+		if err != nil {
+			t.Fatalf("UTF-16 %s: %v", name, err)
+		}
+		fromFile, err := parseShaderFile(encoded, "shader.json")
+		if err != nil {
+			t.Fatalf("UTF-16 %s shader.json: %v", name, err)
+		}
+		if !reflect.DeepEqual(fromJSON, plain) || !reflect.DeepEqual(fromFile, plain) {
+			t.Fatalf("UTF-16 %s shader differs from UTF-8 one", name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Readable shader compile error reports.
+//
+// Dumping the whole generated fragment shader on a compile error floods
+// the log (and long console output gets truncated). Instead we parse line
+// numbers from the driver's info log and print only the lines around each
+// error. `-dump-full-shader` still prints the complete source when needed.
+//
+// Driver log formats differ:
+//
+//	Mesa:         0:123(45): error: ...
+//	AMD / Intel:  ERROR: 0:123: ...
+//	NVIDIA:       0(123) : error C1008: ...
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SHADER_ERROR_CONTEXT_LINES is how many lines are shown before and after an error line
+const SHADER_ERROR_CONTEXT_LINES = 10
+
+// dumpFullShader prints complete shader source on compile errors (set by -dump-full-shader)
+var dumpFullShader = false
+
+var shaderErrorLinePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(?:ERROR|WARNING)?:?\s*\d+:(\d+)`), // Mesa, AMD, Intel
+	regexp.MustCompile(`^\d+\((\d+)\)`),                     // NVIDIA
+}
+
+// shaderErrorLines returns source line numbers (1-based) mentioned in compile log, in order, without duplicates
+func shaderErrorLines(errorLog string) []int {
+	var lines []int
+	seen := make(map[int]bool)
+	for _, logLine := range strings.Split(errorLog, "\n") {
+		logLine = strings.TrimSpace(logLine)
+		for _, pattern := range shaderErrorLinePatterns {
+			match := pattern.FindStringSubmatch(logLine)
+			if match == nil {
+				continue
+			}
+			line, err := strconv.Atoi(match[1])
+			if err == nil && line > 0 && !seen[line] {
+				seen[line] = true
+				lines = append(lines, line)
+			}
+			break
+		}
+	}
+	return lines
+}
+
+// shaderSourceExcerpt returns numbered source lines around errorLine; the error line is marked with ">"
+func shaderSourceExcerpt(sourceLines []string, errorLine int, context int) string {
+	start := errorLine - context
+	if start < 1 {
+		start = 1
+	}
+	end := errorLine + context
+	if end > len(sourceLines) {
+		end = len(sourceLines)
+	}
+
+	var result strings.Builder
+	for line := start; line <= end; line++ {
+		marker := " "
+		if line == errorLine {
+			marker = ">"
+		}
+		fmt.Fprintf(&result, "%s%5d| %s\n", marker, line, sourceLines[line-1])
+	}
+	return result.String()
+}
+
+// logShaderSource logs source context for a failed compilation:
+// full source with -dump-full-shader, otherwise excerpts around error lines (in DEBUG_MODE)
+func logShaderSource(source string, errorLog string) {
+	source = strings.TrimRight(source, "\x00")
+	sourceLines := strings.Split(source, "\n")
+
+	if dumpFullShader {
+		log.Printf("Full shader source code (%d lines, %d bytes):\n%s", len(sourceLines), len(source), source)
+		return
+	}
+	if !DEBUG_MODE {
+		return
+	}
+
+	errorLines := shaderErrorLines(errorLog)
+	log.Printf("Shader source: %d lines, %d bytes (use -dump-full-shader for complete source)", len(sourceLines), len(source))
+	if len(errorLines) == 0 {
+		log.Printf("No line numbers found in compile log")
+		return
+	}
+	for _, line := range errorLines {
+		if line > len(sourceLines) {
+			log.Printf("Error line %d is past end of source", line)
+			continue
+		}
+		log.Printf("Source around line %d:\n%s", line, shaderSourceExcerpt(sourceLines, line, SHADER_ERROR_CONTEXT_LINES))
+	}
+}
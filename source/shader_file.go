@@ -0,0 +1,141 @@
+// Loading shaders from files (`-shader <file>`).
+//
+// `.json` files use the same format as the embedded shader.json. Bare GLSL
+// files (`.glsl`, `.frag`, `.fs`) contain just a Shadertoy-style `mainImage`
+// (plus helper functions); they are wrapped into a single image pass so the
+// usual repair/compile pipeline applies unchanged.
+//
+// Files saved by Windows editors may start with a UTF-8 BOM and use CRLF line
+// endings; the BOM is stripped when parsing (UTF-16 files are transcoded, see
+// shader_encoding.go) and pass code is normalized to "\n" before the
+// line-based repair heuristics run (a trailing "\r" would otherwise hide e.g.
+// the "," of a multi-line declaration).
+//
+// Some exporters write a pass's `code` as an array of lines instead of one
+// string; the lines are joined with "\n".
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// shaderFileExtensions lists extensions loadShaderFile accepts (for file pickers)
+var shaderFileExtensions = []string{".json", ".glsl", ".frag", ".fs", ".gz"}
+
+// utf8BOM is the byte order mark some Windows editors prepend to UTF-8 files
+const utf8BOM = "\xef\xbb\xbf"
+
+// stripBOM removes leading UTF-8 byte order mark
+func stripBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, []byte(utf8BOM))
+}
+
+// UnmarshalJSON accepts pass code (and vertex code) as a string or as an array of lines
+func (p *ShaderPass) UnmarshalJSON(data []byte) error {
+	type plainPass ShaderPass
+	parsed := struct {
+		*plainPass
+		Code       json.RawMessage `json:"code"`
+		VertexCode json.RawMessage `json:"vertex_code"`
+	}{plainPass: (*plainPass)(p)}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	var err error
+	if p.Code, err = unmarshalCodeLines(parsed.Code); err != nil {
+		return fmt.Errorf("pass code must be a string or an array of strings")
+	}
+	if p.VertexCode, err = unmarshalCodeLines(parsed.VertexCode); err != nil {
+		return fmt.Errorf("pass vertex_code must be a string or an array of strings")
+	}
+	return nil
+}
+
+// unmarshalCodeLines decodes code given as a string or an array of lines ("" if missing)
+func unmarshalCodeLines(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var code string
+	if err := json.Unmarshal(raw, &code); err == nil {
+		return code, nil
+	}
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// normalizeLineEndings converts CRLF and lone CR line endings to LF and drops a leading BOM
+func normalizeLineEndings(code string) string {
+	code = strings.TrimPrefix(code, utf8BOM)
+	code = strings.ReplaceAll(code, "\r\n", "\n")
+	return strings.ReplaceAll(code, "\r", "\n")
+}
+
+// loadShaderFile loads shader from .json or bare GLSL file (chosen by extension)
+func loadShaderFile(path string) (*ShaderData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading shader file: %v", err)
+	}
+	shaderData, err := parseShaderFile(data, filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	resolveInputPaths(shaderData, filepath.Dir(path))
+	return shaderData, nil
+}
+
+// resolveInputPaths makes relative input src paths relative to the shader file's directory
+func resolveInputPaths(shaderData *ShaderData, dir string) {
+	for i := range shaderData.Passes {
+		inputs := shaderData.Passes[i].Inputs
+		for j := range inputs {
+			if src := inputs[j].Src; src != "" && !filepath.IsAbs(src) && !strings.Contains(src, "://") {
+				inputs[j].Src = filepath.Join(dir, src)
+			}
+		}
+	}
+}
+
+// parseShaderFile parses shader file contents; format is chosen by extension of name
+// (ignoring ".gz" of gzip-compressed files, see shader_gzip.go)
+func parseShaderFile(data []byte, name string) (*ShaderData, error) {
+	data, err := decompressShaderData(data)
+	if err != nil {
+		return nil, err
+	}
+	name = stripGzipExt(name)
+	data, err = decodeShaderText(data)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return parseShaderJSON(data)
+	case ".glsl", ".frag", ".fs":
+		return glslShaderData(string(data), strings.TrimSuffix(name, filepath.Ext(name)))
+	default:
+		return nil, fmt.Errorf("unsupported shader file %q (expected .json, .glsl, .frag or .fs)", name)
+	}
+}
+
+// glslShaderData wraps bare mainImage code into ShaderData with a single image pass
+func glslShaderData(code string, title string) (*ShaderData, error) {
+	if !strings.Contains(code, "mainImage") {
+		return nil, fmt.Errorf("GLSL shader has no mainImage function")
+	}
+	return &ShaderData{
+		Metadata: &ShaderMetadata{Title: title, NumPasses: 1},
+		Passes: []ShaderPass{
+			{Type: "image", Name: "Image", Code: code},
+		},
+	}, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadGLSLShaderFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "red.glsl")
+	code := "void mainImage(out vec4 fragColor, in vec2 fragCoord) {\n    fragColor = vec4(1.0, 0.0, 0.0, 1.0);\n}\n"
+	if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := loadShaderFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Passes) != 1 || selectImagePass(data).Type != "image" || data.Metadata.Title != "red" {
+		t.Fatalf("unexpected shader data for bare GLSL: %+v", data)
+	}
+
+	if _, err := parseShaderFile([]byte("float f() { return 1.0; }"), "helpers.glsl"); err == nil {
+		t.Errorf("GLSL without mainImage loaded")
+	}
+
+	cfg := defaultConfig()
+	const size = 16
+	pixels := renderShaderPixels(t, data, &cfg, size)
+	// Some slack for dithering and vignette
+	if center := pixels[(size/2*size+size/2)*4:][:3]; center[0] < 240 || center[1] > 16 || center[2] > 16 {
+		t.Errorf("bare GLSL renders %v at the center, want red", center)
+	}
+}
+
+// lineEndingSample has a multi-line declaration whose trailing "," the repair heuristics must see
+const lineEndingSample = `void mainImage(out vec4 fragColor, in vec2 fragCoord) {
+    float a,
+          b;
+    a = fragCoord.x;
+    b = fragCoord.y;
+    fragColor = vec4(a, b, 0.0, 1.0);
+}`
+
+// TestLineEndings checks CRLF/BOM shader code (Windows editors) is repaired exactly like LF code
+func TestLineEndings(t *testing.T) {
+	cfg := defaultConfig()
+	expected := processShaderCode(lineEndingSample, nil, &cfg)
+	crlf := utf8BOM + strings.ReplaceAll(lineEndingSample, "\n", "\r\n")
+	processed := processShaderCode(crlf, nil, &cfg)
+	if strings.Contains(processed, "\r") {
+		t.Errorf("carriage return left in processed code")
+	}
+	if processed != expected {
+		t.Errorf("CRLF code processed differently:\n%s\nexpected:\n%s", processed, expected)
+	}
+
+	sample := `{"passes":[{"type":"image","code":"void mainImage(out vec4 c, in vec2 p) {\n    c = vec4(1.0);\n}"}]}`
+	data := utf8BOM + strings.ReplaceAll(sample, `\n`, `\r\n`)
+	shaderData, err := parseShaderJSON([]byte(data))
+	if err != nil {
+		t.Fatalf("JSON with BOM: %v", err)
+	}
+	if code := processShaderCode(shaderData.Passes[0].Code, nil, &cfg); strings.Contains(code, "\r") {
+		t.Errorf("carriage return left in code from JSON")
+	}
+}
+
+// TestCodeLines checks that pass code is read from a string or an array of lines
+func TestCodeLines(t *testing.T) {
+	want := "void mainImage(out vec4 c, in vec2 p) {\n    c = vec4(1.0);\n}"
+	for name, sample := range map[string]string{
+		"string": `{"passes":[{"type":"image","code":"void mainImage(out vec4 c, in vec2 p) {\n    c = vec4(1.0);\n}"}]}`,
+		"lines":  `{"passes":[{"type":"image","code":["void mainImage(out vec4 c, in vec2 p) {","    c = vec4(1.0);","}"]}]}`,
+	} {
+		shaderData, err := parseShaderJSON([]byte(sample))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := shaderData.Passes[0].Code; got != want || shaderData.Passes[0].Type != "image" {
+			t.Errorf("%s: code %q, type %q", name, got, shaderData.Passes[0].Type)
+		}
+	}
+	if _, err := parseShaderJSON([]byte(`{"passes":[{"code":[1, 2]}]}`)); err == nil {
+		t.Errorf("numeric code array accepted")
+	}
+}
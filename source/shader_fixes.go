@@ -0,0 +1,170 @@
+// Selectable shader repair passes.
+//
+// fixShaderCode runs several independent heuristics. Each one can be turned
+// off (`-skip-fixes`, Settings -> Advanced) when it misfires on a particular
+// shader, and `-no-fix` turns all of them off. A shader known to break under
+// a pass can skip it itself with `"skip_fixes": ["orphans"]` in its metadata;
+// this adds to the configured list for that shader only. Comment stripping is
+// not a repair pass: later steps (minify, formatting) rely on comment-free
+// code, so it always runs.
+//
+// The passes add and remove whole lines, so they can drop a brace that
+// shared a line with a removed statement. fixShaderCode checks brace balance
+// afterwards: if the input was balanced and the output isn't, all repairs
+// are discarded with a warning naming the line, instead of handing the
+// driver code that fails with a confusing error far from the cause.
+package main
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"sort"
+	"strings"
+)
+
+const (
+	FIX_UNINIT    = "uninit"    // Initialize declared but uninitialized variables
+	FIX_ORPHANS   = "orphans"   // Remove assignments to undeclared variables
+	FIX_FRAGCOLOR = "fragcolor" // Remove duplicate fragColor declaration in mainImage
+	FIX_LOOPS     = "loops"     // Initialize variables before loops that use them
+)
+
+// shaderFix describes a repair pass for flags help and settings dialog
+type shaderFix struct {
+	name        string
+	description string
+}
+
+// shaderFixes lists repair passes in the order fixShaderCode runs them
+var shaderFixes = []shaderFix{
+	{FIX_UNINIT, "Initialize uninitialized variables"},
+	{FIX_ORPHANS, "Remove assignments to undeclared variables"},
+	{FIX_FRAGCOLOR, "Remove duplicate fragColor in mainImage"},
+	{FIX_LOOPS, "Initialize variables used in loops"},
+}
+
+// parseFixList parses comma-separated fix names ("uninit,loops") into a set
+func parseFixList(s string) (map[string]bool, error) {
+	result := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !isKnownFix(name) {
+			return nil, fmt.Errorf("unknown shader fix %q (expected %s)", name, knownFixNames())
+		}
+		result[name] = true
+	}
+	return result, nil
+}
+
+// fixListString formats fix set as sorted comma-separated list (inverse of parseFixList)
+func fixListString(fixes map[string]bool) string {
+	var names []string
+	for name, enabled := range fixes {
+		if enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func isKnownFix(name string) bool {
+	for _, fix := range shaderFixes {
+		if fix.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateShaderSkipFixes drops unknown pass names from metadata skip_fixes (logging them)
+func validateShaderSkipFixes(meta *ShaderMetadata) {
+	if meta == nil {
+		return
+	}
+	var names []string
+	for _, name := range meta.SkipFixes {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isKnownFix(name) {
+			log.Printf("Warning: ignoring unknown shader fix %q in skip_fixes (expected %s)", name, knownFixNames())
+			continue
+		}
+		names = append(names, name)
+	}
+	meta.SkipFixes = names
+}
+
+// shaderSkipFixes returns passes to skip for shader: configured ones plus its metadata skip_fixes
+func shaderSkipFixes(meta *ShaderMetadata, cfg *Config) map[string]bool {
+	if meta == nil || len(meta.SkipFixes) == 0 {
+		return cfg.SkipFixes
+	}
+	skip := make(map[string]bool)
+	for name, enabled := range cfg.SkipFixes {
+		skip[name] = enabled
+	}
+	for _, name := range meta.SkipFixes {
+		skip[name] = true
+	}
+	if DEBUG_MODE {
+		log.Printf("Shader metadata skips fixes: %s", strings.Join(meta.SkipFixes, ","))
+	}
+	return skip
+}
+
+// runShaderFix runs repair pass name on comment-free code; FIX_UNINIT records
+// the variables it initialized in uninitializedVars for FIX_LOOPS
+func runShaderFix(name string, code string, uninitializedVars map[string]string) string {
+	switch name {
+	case FIX_UNINIT:
+		code, initialized := fixUninitializedVars(code)
+		maps.Copy(uninitializedVars, initialized)
+		return code
+	case FIX_ORPHANS:
+		// Assignments without declarations that reference undeclared variables
+		// Example: "vec2 p = bpos.zx;" where bpos is not declared
+		return removeOrphanedAssignments(code)
+	case FIX_FRAGCOLOR:
+		// Duplicate fragColor declaration in mainImage
+		return fixMainImageFragColor(code)
+	case FIX_LOOPS:
+		// Second pass: ensure variables are initialized before use in loops
+		return initializeLoopVars(code, uninitializedVars)
+	}
+	return code
+}
+
+// checkBraceBalance returns error describing first brace mismatch in comment-free code, nil if balanced
+func checkBraceBalance(code string) error {
+	var open []int // Lines of unclosed "{", innermost last
+	for i, line := range strings.Split(code, "\n") {
+		for _, c := range line {
+			switch c {
+			case '{':
+				open = append(open, i+1)
+			case '}':
+				if len(open) == 0 {
+					return fmt.Errorf("unmatched '}' at line %d", i+1)
+				}
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("'{' at line %d is never closed", open[len(open)-1])
+	}
+	return nil
+}
+
+// knownFixNames returns comma-separated names of all repair passes
+func knownFixNames() string {
+	var names []string
+	for _, fix := range shaderFixes {
+		names = append(names, fix.name)
+	}
+	return strings.Join(names, ", ")
+}
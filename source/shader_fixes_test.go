@@ -0,0 +1,129 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// helpersAfterMainSample defines helper functions after mainImage; a helper's local
+// accumulator must be initialized even though mainImage comes first
+const helpersAfterMainSample = `vec3 shade(vec2 p);
+
+void mainImage(out vec4 fragColor, in vec2 fragCoord) {
+    vec2 uv = fragCoord / iResolution.xy;
+    vec3 col;
+    col = shade(uv);
+    fragColor = vec4(col, 1.0);
+}
+
+vec3 shade(vec2 p) {
+    float d;
+    vec3 col;
+    d = length(p - 0.5);
+    col = vec3(smoothstep(0.5, 0.0, d));
+    return col;
+}
+
+float helper(float x) {
+    float acc;
+    for (int i = 0; i < 4; i++) {
+        acc += x;
+    }
+    return acc;
+}`
+
+// TestHelpersAfterMain checks shader repair scopes functions defined after mainImage separately
+func TestHelpersAfterMain(t *testing.T) {
+	lines := strings.Split(helpersAfterMainSample, "\n")
+	if start, end := mainImageRange(lines); start != 2 || end != 8 {
+		t.Errorf("mainImage found at lines %d-%d, expected 2-8", start, end)
+	}
+	if _, inMain := findFunctionScope(lines, 19); inMain {
+		t.Errorf("helper after mainImage taken for mainImage")
+	}
+	fixed := fixShaderCode(helpersAfterMainSample, nil)
+	if !strings.Contains(fixed, "float acc = 0.0;") {
+		t.Errorf("accumulator of helper after mainImage not initialized:\n%s", fixed)
+	}
+}
+
+// braceRepairSample has an orphaned assignment sharing a line with "}": removing the
+// line would unbalance braces, so repair must be refused
+const braceRepairSample = `float f(float x) {
+    if (x > 0.0) {
+        y = z * 2.0; }
+    return x;
+}
+void mainImage(out vec4 fragColor, in vec2 fragCoord) {
+    fragColor = vec4(f(1.0));
+}`
+
+// TestBraceRepair checks brace diagnostics and that repair never emits unbalanced braces
+func TestBraceRepair(t *testing.T) {
+	if err := checkBraceBalance("void f() {\n    if (true) {\n}"); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("unclosed brace reported as %v", err)
+	}
+	if err := checkBraceBalance("}\nvoid f() {}"); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("unmatched brace reported as %v", err)
+	}
+	fixed := fixShaderCode(braceRepairSample, nil)
+	if err := checkBraceBalance(fixed); err != nil {
+		t.Errorf("repair emitted unbalanced code: %v", err)
+	}
+	if fixed != removeComments(braceRepairSample) {
+		t.Errorf("repair that unbalanced braces was kept:\n%s", fixed)
+	}
+}
+
+// constArraySample uses multi-line const arrays as lookup tables. glow is declared
+// together with col, so its assignment is checked for undeclared references.
+const constArraySample = `const vec3 palette[3] = vec3[](
+    vec3(0.1, 0.9, 0.4),
+    vec3(0.2, 0.6, 0.9),
+    vec3(0.7, 0.3, 0.9)
+);
+const float weights[3] = float[](0.2,
+                                 0.5,
+                                 0.3);
+void mainImage(out vec4 fragColor, in vec2 fragCoord) {
+    int i = int(fragCoord.x) % 3;
+    vec3 col, glow;
+    glow = palette[i] * weights[i];
+    fragColor = vec4(glow, 1.0);
+}`
+
+// TestConstArrays checks that const array declarations and their uses survive repair
+func TestConstArrays(t *testing.T) {
+	fixed := fixShaderCode(constArraySample, nil)
+	declarations, _, _ := strings.Cut(constArraySample, "void mainImage")
+	for _, line := range append(strings.Split(declarations, "\n"), "    glow = palette[i] * weights[i];") {
+		if !strings.Contains(fixed, line) {
+			t.Errorf("line %q changed by repair:\n%s", line, fixed)
+		}
+	}
+}
+
+// TestPatternCache checks that repairs don't depend on the state of the
+// pattern cache (empty, warm, or dropped halfway through a run)
+func TestPatternCache(t *testing.T) {
+	shaderData, err := loadEmbeddedShader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []string{normalizeLineEndings(shaderData.Passes[0].Code), constArraySample, braceRepairSample} {
+		clearPatternCache()
+		cold := fixShaderCode(code, nil)
+		if warm := fixShaderCode(code, nil); warm != cold {
+			t.Errorf("repair with warm cache differs:\n%s\nwant:\n%s", warm, cold)
+		}
+		clearPatternCache()
+		for i := 0; i < PATTERN_CACHE_MAX-1; i++ {
+			cachedPattern("filler" + strconv.Itoa(i))
+		}
+		if full := fixShaderCode(code, nil); full != cold {
+			t.Errorf("repair with full cache differs:\n%s\nwant:\n%s", full, cold)
+		}
+	}
+	clearPatternCache()
+}
@@ -0,0 +1,55 @@
+// Gzip-compressed shader data.
+//
+// Large multi-pass shaders (with screenshots) make shader.json, and with it
+// the binary, big. Shader data may therefore be gzip-compressed: the embedded
+// shader.json can be replaced by its gzip output under the same name, and
+// `-shader` files and URLs may be compressed too (e.g. `aurora.json.gz`). Data
+// is recognized by the gzip magic bytes, not by name, and decompressed before
+// JSON preprocessing; uncompressed data is used as is. Decompressed size is
+// limited to SHADER_GZIP_MAX_SIZE.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// SHADER_GZIP_MAX_SIZE caps decompressed shader data (64 MiB, room for screenshots)
+const SHADER_GZIP_MAX_SIZE = 64 << 20
+
+// isGzipData reports whether data starts with the gzip magic bytes
+func isGzipData(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// decompressShaderData returns data gunzipped if it is gzip-compressed, else unchanged
+func decompressShaderData(data []byte) ([]byte, error) {
+	if !isGzipData(data) {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip data: %v", err)
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(io.LimitReader(reader, SHADER_GZIP_MAX_SIZE+1))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing shader: %v", err)
+	}
+	if len(decompressed) > SHADER_GZIP_MAX_SIZE {
+		return nil, fmt.Errorf("decompressed shader is larger than %d MiB", SHADER_GZIP_MAX_SIZE>>20)
+	}
+	return decompressed, nil
+}
+
+// stripGzipExt removes a ".gz" suffix, so "aurora.json.gz" is detected as JSON
+func stripGzipExt(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".gz") {
+		return name[:len(name)-len(".gz")]
+	}
+	return name
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"testing"
+)
+
+// TestGzipShader checks that gzip-compressed shader data loads like the uncompressed JSON
+func TestGzipShader(t *testing.T) {
+	plain, err := parseShaderJSON(shaderJSONData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(shaderJSONData)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	fromGzip, err := parseShaderJSON(compressed.Bytes())
+	if err != nil {
+		t.Errorf("compressed JSON: %v", err)
+	}
+	fromFile, err := parseShaderFile(compressed.Bytes(), "aurora.json.gz")
+	if err != nil {
+		t.Errorf("aurora.json.gz: %v", err)
+	}
+	if !reflect.DeepEqual(fromGzip, plain) || !reflect.DeepEqual(fromFile, plain) {
+		t.Errorf("compressed shader differs from uncompressed one")
+	}
+	if _, err := parseShaderJSON(compressed.Bytes()[:compressed.Len()/2]); err == nil {
+		t.Errorf("truncated gzip data accepted")
+	}
+}
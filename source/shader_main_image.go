@@ -0,0 +1,174 @@
+// Duplicate mainImage definitions.
+//
+// A badly merged multi-pass export (or Common code included twice) can carry
+// more than one `void mainImage(...)` body, which fails to compile with a
+// redefinition error that doesn't say where the second copy came from. Before
+// compiling, getMainShaderCode keeps only the last definition: prepended code
+// (Common pass, helpers) comes first, so the last body is the image pass's own.
+// Dropped definitions are logged with their position.
+//
+// The wrapper's main() calls the remaining definition with arguments matched
+// to its parameters by type, so names (`out vec4 O, vec2 U`), qualifiers
+// (`in`, `const in`, precision), order (`vec2` first) and an `out vec3` color
+// (alpha 1) all work. Signatures it can't fill get the standard call and a
+// warning; the compile error then points at the signature.
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// Start of a mainImage definition up to its opening brace (prototypes end with ';' instead)
+var mainImageDefinitionPattern = regexp.MustCompile(`\bvoid\s+mainImage\s*\(([^)]*)\)\s*\{`)
+
+// Parameter words that don't change how the wrapper passes an argument
+var mainImageParamModifiers = map[string]bool{"const": true, "precise": true, "highp": true, "mediump": true, "lowp": true}
+
+// mainImageParam is one parameter of a mainImage definition
+type mainImageParam struct {
+	qualifier string // "in", "out" or "inout"
+	typ       string
+	name      string
+}
+
+// mainImageDefinitions returns [start, end) byte ranges of mainImage definitions in
+// comment-free code; an unclosed body runs to the end of code
+func mainImageDefinitions(code string) [][2]int {
+	var ranges [][2]int
+	for _, match := range mainImageDefinitionPattern.FindAllStringIndex(code, -1) {
+		if len(ranges) > 0 && match[0] < ranges[len(ranges)-1][1] {
+			continue // Inside the previous body (can't be a real definition)
+		}
+		end := len(code)
+		depth := 0
+		for i := match[1] - 1; i < len(code); i++ {
+			switch code[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			if depth == 0 {
+				end = i + 1
+				break
+			}
+		}
+		ranges = append(ranges, [2]int{match[0], end})
+	}
+	return ranges
+}
+
+// removeDuplicateMainImage keeps the last mainImage definition; returns code and line
+// numbers (1-based) of the dropped definitions
+func removeDuplicateMainImage(code string) (string, []int) {
+	ranges := mainImageDefinitions(code)
+	if len(ranges) < 2 {
+		return code, nil
+	}
+	var b strings.Builder
+	var dropped []int
+	last := 0
+	for _, r := range ranges[:len(ranges)-1] {
+		dropped = append(dropped, strings.Count(code[:r[0]], "\n")+1)
+		b.WriteString(code[last:r[0]])
+		last = r[1]
+	}
+	b.WriteString(code[last:])
+	return b.String(), dropped
+}
+
+// mainImageParams returns the parameters of the last mainImage definition in
+// comment-free code (false if there is none)
+func mainImageParams(code string) ([]mainImageParam, bool) {
+	ranges := mainImageDefinitions(code)
+	if len(ranges) == 0 {
+		return nil, false
+	}
+	match := mainImageDefinitionPattern.FindStringSubmatch(code[ranges[len(ranges)-1][0]:])
+	var params []mainImageParam
+	for _, text := range strings.Split(match[1], ",") {
+		param := mainImageParam{qualifier: "in"}
+		var words []string
+		for _, word := range strings.Fields(text) {
+			switch {
+			case word == "in" || word == "out" || word == "inout":
+				param.qualifier = word
+			case !mainImageParamModifiers[word]:
+				words = append(words, word)
+			}
+		}
+		if len(words) == 0 || (len(words) == 1 && words[0] == "void") {
+			continue // "()" or "(void)"
+		}
+		param.typ = words[0]
+		if len(words) > 1 {
+			param.name = words[1]
+		}
+		params = append(params, param)
+	}
+	return params, true
+}
+
+// mainImageColorName returns the name of the color output of mainImage in code
+// ("fragColor" if it can't be told)
+func mainImageColorName(code string) string {
+	params, _ := mainImageParams(code)
+	for _, param := range params {
+		if (param.typ == "vec4" || param.typ == "vec3") && param.qualifier != "in" && param.name != "" {
+			return param.name
+		}
+	}
+	return "fragColor"
+}
+
+// mainImageCall returns the statement calling mainImage in comment-free code,
+// passing color (vec4 output) and coord (vec2 pixel position) to the parameters
+// of matching type. Without a definition (a #define, say) it is the standard
+// call; so it is, with an error, for signatures that can't be filled.
+func mainImageCall(code, color, coord string) (string, error) {
+	standard := "mainImage(" + color + ", " + coord + ");"
+	params, ok := mainImageParams(code)
+	if !ok {
+		return standard, nil
+	}
+	var args []string
+	prefix := ""
+	colors, coords := 0, 0
+	for _, param := range params {
+		switch {
+		case (param.typ == "vec4" || param.typ == "vec3") && param.qualifier != "in":
+			colors++
+			if param.typ == "vec4" {
+				args = append(args, color)
+			} else {
+				prefix = color + ".a = 1.0; "
+				args = append(args, color+".rgb")
+			}
+		case param.typ == "vec2" && param.qualifier == "in":
+			coords++
+			args = append(args, coord)
+		case param.typ == "vec4" || param.typ == "vec3":
+			return standard, fmt.Errorf("mainImage color %s is not an out parameter", param.name)
+		default:
+			return standard, fmt.Errorf("mainImage parameter %s %s %s can't be passed", param.qualifier, param.typ, param.name)
+		}
+	}
+	if colors != 1 || coords > 1 {
+		return standard, fmt.Errorf("mainImage needs one out vec4 color and at most one vec2 coordinate, has %d and %d", colors, coords)
+	}
+	return prefix + "mainImage(" + strings.Join(args, ", ") + ");", nil
+}
+
+// mainImageCallStatement is mainImageCall logging why the standard call is used
+func mainImageCallStatement(code, color, coord string) string {
+	call, err := mainImageCall(code, color, coord)
+	if err != nil {
+		log.Printf("Warning: %v; calling %s", err, call)
+	} else if DEBUG_MODE {
+		log.Printf("mainImage call: %s", call)
+	}
+	return call
+}
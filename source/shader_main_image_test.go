@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// duplicateMainImageSample is an image pass with Common code merged in twice: the
+// first mainImage (with a nested block) is a leftover, the last one is the real pass
+const duplicateMainImageSample = `float wave(float x) { return sin(x) * 0.5 + 0.5; }
+void mainImage(out vec4 fragColor, in vec2 fragCoord) {
+    if (fragCoord.x > 0.0) { fragColor = vec4(1.0, 0.0, 0.0, 1.0); }
+}
+float wave2(float x) { return wave(x * 2.0); }
+void mainImage(out vec4 fragColor, in vec2 fragCoord) {
+    fragColor = vec4(wave2(fragCoord.x), 0.5, 0.2, 1.0);
+}`
+
+// TestDuplicateMainImage checks that only the last mainImage reaches the compiled source
+func TestDuplicateMainImage(t *testing.T) {
+	cfg := defaultConfig()
+	shaderData := &ShaderData{Passes: []ShaderPass{{Type: "image", Code: duplicateMainImageSample}}}
+	_, fragment, err := getMainShaderCode(shaderData, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(mainImageDefinitions(fragment)); n != 1 {
+		t.Errorf("%d mainImage definitions left:\n%s", n, fragment)
+	}
+	for _, want := range []string{"wave2(fragCoord.x)", "float wave2(", "float wave("} {
+		if !strings.Contains(fragment, want) {
+			t.Errorf("%q missing after removing duplicate mainImage:\n%s", want, fragment)
+		}
+	}
+	if strings.Contains(fragment, "vec4(1.0, 0.0, 0.0, 1.0)") || strings.Contains(fragment, "vec4(1.0,0.0,0.0,1.0)") {
+		t.Errorf("first mainImage kept:\n%s", fragment)
+	}
+	if code, dropped := removeDuplicateMainImage(constArraySample); code != constArraySample || dropped != nil {
+		t.Errorf("single mainImage changed")
+	}
+}
+
+// mainImageSignatureSamples are mainImage signatures seen in shared shaders and
+// the wrapper call expected for each ("" = standard call with a warning)
+var mainImageSignatureSamples = []struct{ code, call string }{
+	{"void mainImage( out vec4 fragColor, in vec2 fragCoord ) {", "mainImage(fragColor, fragCoordScreen);"},
+	{"void mainImage(out vec4 O, vec2 U) {", "mainImage(fragColor, fragCoordScreen);"},
+	{"void mainImage(out vec4 o, in highp vec2 u){", "mainImage(fragColor, fragCoordScreen);"},
+	{"void mainImage(inout vec4 c, const in vec2 p)\n{", "mainImage(fragColor, fragCoordScreen);"},
+	{"void mainImage(\n    out vec4 color,\n    in vec2 pixel\n) {", "mainImage(fragColor, fragCoordScreen);"},
+	{"void mainImage(in vec2 p, out vec4 c) {", "mainImage(fragCoordScreen, fragColor);"},
+	{"void mainImage(out vec3 col, in vec2 uv) {", "fragColor.a = 1.0; mainImage(fragColor.rgb, fragCoordScreen);"},
+	{"void mainImage(out vec4 o) {", "mainImage(fragColor);"},
+	{"#define mainImage(o, u) void mainImage(out vec4 o, vec2 u)\nmainImage(O, U) {", "mainImage(fragColor, fragCoordScreen);"},
+	{"void mainImage(vec4 o, vec2 u) {", ""},
+	{"void mainImage(out vec4 o, in vec2 u, float t) {", ""},
+	{"void mainImage(out vec4 a, out vec4 b) {", ""},
+}
+
+// TestMainImageSignatures checks that the wrapper calls mainImage the way it is declared
+func TestMainImageSignatures(t *testing.T) {
+	for _, sample := range mainImageSignatureSamples {
+		code := sample.code + " }"
+		call, err := mainImageCall(code, "fragColor", "fragCoordScreen")
+		want := sample.call
+		if want == "" {
+			want = "mainImage(fragColor, fragCoordScreen);"
+			if err == nil {
+				t.Errorf("%q: no error", sample.code)
+			}
+		} else if err != nil {
+			t.Errorf("%q: %v", sample.code, err)
+		}
+		if call != want {
+			t.Errorf("%q: call %q, expected %q", sample.code, call, want)
+		}
+	}
+
+	cfg := defaultConfig()
+	shaderData := &ShaderData{Passes: []ShaderPass{{Type: "image", Code: `void mainImage(in vec2 U, out vec4 O) {
+    vec4 O = vec4(U / iResolution.xy, 0.5, 1.0);
+}`}}}
+	_, fragment, err := getMainShaderCode(shaderData, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fragment, "mainImage(fragCoordScreen, fragColor);") {
+		t.Errorf("reversed signature not called by type:\n%s", fragment)
+	}
+	if strings.Contains(fragment, "vec4 O =") || strings.Contains(fragment, "vec4 O=") {
+		t.Errorf("output parameter O redeclared:\n%s", fragment)
+	}
+}
@@ -0,0 +1,84 @@
+// Reconciling output declarations in user shader code.
+//
+// The fragment template declares the only output (`out vec4 fragColor`) and
+// calls mainImage. Code ported from plain GLSL sometimes declares its own
+// outputs, which fails with a cryptic redefinition or link error (several
+// outputs without explicit locations). Top-level `out` declarations are
+// therefore rewritten before compiling:
+//
+//	out vec4 fragColor;  -> removed (the template already declares it)
+//	out vec4 color;      -> vec4 color; (plain global, writes are discarded)
+//
+// Both cases are logged, so the author knows only mainImage's fragColor is
+// displayed. Outputs with an explicit location 1..MRT_MAX_TARGETS-1
+// (`layout(location = N) out vec4 normal;`) are multiple render targets and
+// kept: the templates put fragColor at location 0, and a buffer pass gets a
+// color attachment for each location (see multipass.go). The image pass has
+// only the displayed attachment, so its extra targets are discarded with a
+// warning. Location 0 clashes with fragColor and is demoted like an output
+// without location. gl_FragDepth needs no declaration; writes to it are
+// harmless because depth testing is disabled.
+package main
+
+import (
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MRT_MAX_TARGETS is the number of color attachments of a buffer pass at most
+// (GL 3.3 guarantees 8 draw buffers)
+const MRT_MAX_TARGETS = 8
+
+// Top-level output declaration: optional layout qualifier, "out", type, name(s)
+var outputDeclPattern = regexp.MustCompile(`(?m)^[ \t]*(layout\s*\([^)]*\)\s*)?out\s+(\w+)\s+([\w\s,\[\]]+);`)
+
+// Explicit output location inside layout qualifier
+var outputLocationPattern = regexp.MustCompile(`location\s*=\s*(\d+)`)
+
+// reconcileOutputs removes or demotes output declarations clashing with the template
+func reconcileOutputs(code string) string {
+	code = outputDeclPattern.ReplaceAllStringFunc(code, func(decl string) string {
+		match := outputDeclPattern.FindStringSubmatch(decl)
+		layout, typeName, names := match[1], match[2], strings.TrimSpace(match[3])
+		if typeName == "vec4" && names == "fragColor" {
+			log.Printf("Warning: shader declares its own 'out vec4 fragColor'; using the template's declaration")
+			return ""
+		}
+		if location := outputLocationPattern.FindStringSubmatch(layout); location != nil {
+			n, _ := strconv.Atoi(location[1])
+			switch {
+			case n > 0 && n < MRT_MAX_TARGETS:
+				return decl
+			case n == 0:
+				log.Printf("Warning: shader writes render target 0 ('%s'), which is mainImage's fragColor; treating it as a global variable", names)
+			default:
+				log.Printf("Warning: shader writes render target %d ('%s'); only locations 1-%d are supported, output is discarded", n, names, MRT_MAX_TARGETS-1)
+			}
+			return typeName + " " + names + ";"
+		}
+		log.Printf("Warning: shader declares output 'out %s %s'; only mainImage's fragColor is displayed, treating it as a global variable", typeName, names)
+		return typeName + " " + names + ";"
+	})
+	if DEBUG_MODE && strings.Contains(code, "gl_FragDepth") {
+		log.Printf("Shader writes gl_FragDepth; ignored (depth test is disabled)")
+	}
+	return code
+}
+
+// outputLocations returns the sorted render target locations (> 0) that processed
+// code declares outputs for
+func outputLocations(code string) []int {
+	var locations []int
+	for _, match := range outputDeclPattern.FindAllStringSubmatch(code, -1) {
+		if location := outputLocationPattern.FindStringSubmatch(match[1]); location != nil {
+			if n, _ := strconv.Atoi(location[1]); !slices.Contains(locations, n) {
+				locations = append(locations, n)
+			}
+		}
+	}
+	slices.Sort(locations)
+	return locations
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestReconcileOutputs checks which output declarations are kept, demoted or removed
+func TestReconcileOutputs(t *testing.T) {
+	tests := []struct {
+		name string
+		decl string
+		want string
+	}{
+		{"template output", "out vec4 fragColor;", ""},
+		{"plain output", "out vec4 color;", "vec4 color;"},
+		{"render target", "layout(location = 1) out vec4 normal;", "layout(location = 1) out vec4 normal;"},
+		{"location 0", "layout(location = 0) out vec4 color;", "vec4 color;"},
+		{"location too high", "layout(location = 8) out vec4 extra;", "vec4 extra;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.TrimSpace(reconcileOutputs(tt.decl)); got != tt.want {
+				t.Errorf("reconcileOutputs(%q) = %q, want %q", tt.decl, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestOutputLocations checks render target locations survive shader processing
+func TestOutputLocations(t *testing.T) {
+	code := `layout(location = 2) out vec4 velocity;
+layout(location = 1) out vec4 normal;
+layout(location = 0) out vec4 color;
+out vec4 other;
+void mainImage(out vec4 fragColor, in vec2 fragCoord) {
+    normal = vec4(0.5);
+    velocity = vec4(0.25);
+    fragColor = vec4(1.0);
+}`
+	cfg := defaultConfig()
+	cfg.MinifyShader = true
+	if got := outputLocations(processShaderCode(code, nil, &cfg)); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("outputLocations = %v, want [1 2]", got)
+	}
+	if got := outputLocations("void mainImage(out vec4 c, in vec2 p) { c = vec4(1.0); }"); len(got) != 0 {
+		t.Errorf("outputLocations without render targets = %v, want none", got)
+	}
+}
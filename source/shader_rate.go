@@ -0,0 +1,47 @@
+// Reduced shader update rate (`-shader-rate`).
+//
+// Heavy shaders that can't keep up with the display often look fine updated
+// 30 times a second, but a plain FPS cap also lowers presentation to 30 Hz.
+// With a shader rate the shader renders into the offscreen render target only
+// at that rate; every display frame in between blits the last shader frame
+// again and draws the overlays, so presentation stays at the refresh rate.
+// iTime, iFrame and the fades advance per shader update, not per swap.
+package main
+
+import "time"
+
+// SHADER_RATE_MAX is the highest accepted -shader-rate in updates per second
+const SHADER_RATE_MAX = 240
+
+// shaderSchedule decides which display frames render the shader
+type shaderSchedule struct {
+	interval      time.Duration // Time between shader updates (0 = every frame)
+	next          time.Time
+	width, height int // Framebuffer size at the last update
+}
+
+// newShaderSchedule returns schedule for rate updates per second (0 = every frame)
+func newShaderSchedule(rate int) shaderSchedule {
+	if rate <= 0 {
+		return shaderSchedule{}
+	}
+	return shaderSchedule{interval: time.Second / time.Duration(rate)}
+}
+
+// due reports whether the frame at now renders the shader; a framebuffer size
+// change always does (the render target is reallocated)
+func (s *shaderSchedule) due(now time.Time, width, height int) bool {
+	if s.interval <= 0 {
+		return true
+	}
+	// A quarter interval of slack keeps vsync jitter from skipping a whole swap
+	if now.Add(s.interval/4).Before(s.next) && width == s.width && height == s.height {
+		return false
+	}
+	s.next = s.next.Add(s.interval)
+	if s.next.Before(now) {
+		s.next = now.Add(s.interval) // Fell behind (first frame, stall): no catching up
+	}
+	s.width, s.height = width, height
+	return true
+}
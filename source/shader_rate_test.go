@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestShaderSchedule checks that -shader-rate 30 renders every other frame of a 60 Hz display
+func TestShaderSchedule(t *testing.T) {
+	schedule := newShaderSchedule(30)
+	start := time.Now()
+	var updates []int
+	for frame := 0; frame < 8; frame++ {
+		// 60 Hz swaps with a little vsync jitter
+		at := start.Add(time.Duration(frame)*time.Second/60 + time.Duration(frame%3-1)*time.Millisecond)
+		if schedule.due(at, 1920, 1080) {
+			updates = append(updates, frame)
+		}
+	}
+	if want := []int{0, 2, 4, 6}; !reflect.DeepEqual(updates, want) {
+		t.Errorf("shader updated on frames %v, expected %v", updates, want)
+	}
+	if !schedule.due(start.Add(120*time.Millisecond), 1280, 720) {
+		t.Errorf("framebuffer resize didn't update the shader")
+	}
+	every := newShaderSchedule(0)
+	if !every.due(start, 1920, 1080) || !every.due(start, 1920, 1080) {
+		t.Errorf("shader rate 0 skipped a frame")
+	}
+}
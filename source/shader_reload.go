@@ -0,0 +1,109 @@
+// Live shader reload on request (SIGHUP, or a named event on Windows).
+//
+// A lighter alternative to watching files during development: pair an editor
+// save hook with `kill -HUP <pid>` (or `pkill -HUP -f AuroraBorealisBliss`)
+// and the running screensaver re-runs the load/repair/compile pipeline and
+// swaps in the new program. iTime keeps running (frame state is untouched);
+// if loading or compiling fails, the error is logged and the current program
+// stays on screen (a URL that fails to download is not replaced by the
+// embedded shader, unlike at startup). On Windows, set the event `Local\AuroraBorealisBlissReload`
+// instead, e.g. in PowerShell:
+//
+//	[Threading.EventWaitHandle]::OpenExisting('Local\AuroraBorealisBlissReload').Set()
+//
+// Only the fullscreen/windowed screensaver listens; the Windows preview does not.
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// reloadTrigger queues reload requests for the render loop of the current attempt
+type reloadTrigger struct {
+	requests chan struct{} // One value per request (merged while one is pending)
+	mu       sync.Mutex
+	attached bool // GLFW is initialized, requests wake the render loop
+}
+
+// newReloadTrigger starts listening for reload requests; the listener lives until exit
+func newReloadTrigger() *reloadTrigger {
+	r := &reloadTrigger{requests: make(chan struct{}, 1)}
+	if err := listenForReload(r.notify); err != nil {
+		log.Printf("Warning: live reload unavailable: %v", err)
+	}
+	return r
+}
+
+// notify queues a request
+func (r *reloadTrigger) notify() {
+	select {
+	case r.requests <- struct{}{}:
+	default:
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Wake the render loop if it waits for events (see limitFrameRate); GLFW
+	// panics when posting while not initialized
+	if r.attached {
+		glfw.PostEmptyEvent()
+	}
+}
+
+// attach lets requests wake the render loop until the returned function is
+// called, which must happen before glfw.Terminate
+func (r *reloadTrigger) attach() func() {
+	r.mu.Lock()
+	r.attached = true
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		r.attached = false
+		r.mu.Unlock()
+	}
+}
+
+// reloadShaderData loads the configured shader without the embedded fallback of loadShader
+func reloadShaderData(cfg *Config) (*ShaderData, error) {
+	switch {
+	case cfg.SafeMode:
+		return fallbackShaderData(), nil
+	case isShaderURL(cfg.ShaderPath):
+		return loadShaderURL(cfg.ShaderPath, cfg.AllowHTTP)
+	case cfg.ShaderPath != "":
+		return loadShaderFile(cfg.ShaderPath)
+	}
+	return loadEmbeddedShader()
+}
+
+// reloadShader loads, repairs and compiles the shader again. On success the old program,
+// channel textures and buffer passes are deleted and replaced; on failure everything is
+// left as it was (a new buffer pass that fails to compile only drops the buffers).
+func reloadShader(cfg *Config, program *uint32, shaderData **ShaderData, channels *[CHANNEL_COUNT]channelTexture, buffers **passChain, uniforms *shaderUniforms) error {
+	data, err := reloadShaderData(cfg)
+	if err != nil {
+		return fmt.Errorf("loading shader: %v", err)
+	}
+	vertexShader, fragmentShader, err := getMainShaderCode(data, cfg)
+	if err != nil {
+		return fmt.Errorf("extracting shader code: %v", err)
+	}
+	rebuilt, err := buildShaderProgram(vertexShader, fragmentShader, cfg)
+	if err != nil {
+		return err
+	}
+
+	gl.DeleteProgram(*program)
+	deleteChannelTextures(*channels)
+	(*buffers).delete()
+	*program, *shaderData = rebuilt, data
+	*channels = setupChannelTextures(rebuilt, selectImagePass(data))
+	*buffers = setupPassChain(data, cfg)
+	*uniforms = getShaderUniforms(rebuilt)
+	uniforms.params = getParamUniforms(rebuilt, data, cfg)
+	return nil
+}
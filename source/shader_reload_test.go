@@ -0,0 +1,24 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestReloadShaderData checks a failing reload source is an error, not the embedded shader
+func TestReloadShaderData(t *testing.T) {
+	for _, path := range []string{
+		filepath.Join(t.TempDir(), "missing.json"),
+		"http://example.com/aurora.json", // Refused without -allow-http, no download
+	} {
+		cfg := defaultConfig()
+		cfg.ShaderPath = path
+		if data, err := reloadShaderData(&cfg); err == nil {
+			t.Errorf("reloading %s: got %d passes, want an error", path, len(data.Passes))
+		}
+	}
+	cfg := defaultConfig()
+	if _, err := reloadShaderData(&cfg); err != nil {
+		t.Errorf("reloading the embedded shader: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+// Loading shaders over HTTP(S) (`-shader https://example.com/aurora.json`).
+//
+// Opt-in: only used when -shader is a URL. Downloads are limited in time and
+// size, and plain http:// needs `-allow-http`, also for redirect hops. Each
+// download that parses is cached in the user cache directory
+// (`AuroraBorealisBliss/shaders`), so a screensaver that starts without
+// network, or behind a captive portal answering with HTML, uses the last
+// copy. If there is no usable download and no cached copy, loadShader falls
+// back to the embedded shader.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	SHADER_URL_TIMEOUT  = 15 * time.Second
+	SHADER_URL_MAX_SIZE = 4 << 20 // 4 MiB, far above any real shader
+	SHADER_CACHE_DIR    = "shaders"
+)
+
+// isShaderURL reports whether -shader value is a URL rather than a file path
+func isShaderURL(s string) bool {
+	lower := strings.ToLower(s)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+}
+
+// shaderURLName returns file name used for format detection and caching.
+// URLs without a known extension are assumed to be JSON; ".gz" after the extension is kept.
+func shaderURLName(u *url.URL) string {
+	name := path.Base(u.Path)
+	switch strings.ToLower(path.Ext(stripGzipExt(name))) {
+	case ".json", ".glsl", ".frag", ".fs":
+		return name
+	}
+	return "shader.json"
+}
+
+// shaderCachePath returns cache file for URL (hash keeps distinct URLs apart)
+func shaderCachePath(rawURL, name string) (string, error) {
+	dir, err := appCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, SHADER_CACHE_DIR)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+path.Ext(name)), nil
+}
+
+// checkShaderURLScheme accepts https, and plain http only with allowHTTP
+func checkShaderURLScheme(u *url.URL, allowHTTP bool) error {
+	if u.Scheme != "https" && !(u.Scheme == "http" && allowHTTP) {
+		return fmt.Errorf("shader URL must use https (use -allow-http for plain http): %s", u)
+	}
+	return nil
+}
+
+// shaderRedirectPolicy applies the scheme check to every redirect hop, so an https
+// URL can't be redirected to plain http without allowHTTP
+func shaderRedirectPolicy(allowHTTP bool) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after %d redirects", len(via))
+		}
+		return checkShaderURLScheme(req.URL, allowHTTP)
+	}
+}
+
+// downloadShader fetches URL contents within timeout and size limit
+func downloadShader(rawURL string, allowHTTP bool) ([]byte, error) {
+	client := &http.Client{Timeout: SHADER_URL_TIMEOUT, CheckRedirect: shaderRedirectPolicy(allowHTTP)}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	if resp.ContentLength > SHADER_URL_MAX_SIZE {
+		return nil, fmt.Errorf("shader is too large (%d bytes, limit %d)", resp.ContentLength, SHADER_URL_MAX_SIZE)
+	}
+	// Read one byte past the limit to detect oversized bodies without Content-Length
+	data, err := io.ReadAll(io.LimitReader(resp.Body, SHADER_URL_MAX_SIZE+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > SHADER_URL_MAX_SIZE {
+		return nil, fmt.Errorf("shader is larger than %d bytes", SHADER_URL_MAX_SIZE)
+	}
+	return data, nil
+}
+
+// loadShaderURL downloads and parses shader, using cached copy when download fails
+// or doesn't parse
+func loadShaderURL(rawURL string, allowHTTP bool) (*ShaderData, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shader URL: %v", err)
+	}
+	if err := checkShaderURLScheme(u, allowHTTP); err != nil {
+		return nil, err
+	}
+	name := shaderURLName(u)
+	cachePath, cacheErr := shaderCachePath(rawURL, name)
+	if cacheErr != nil && DEBUG_MODE {
+		log.Printf("Warning: shader cache unavailable: %v", cacheErr)
+	}
+
+	data, err := downloadShader(rawURL, allowHTTP)
+	if err == nil {
+		shaderData, parseErr := parseShaderFile(data, name)
+		if parseErr == nil {
+			if cacheErr == nil {
+				if err := os.WriteFile(cachePath, data, 0644); err != nil {
+					log.Printf("Warning: cannot cache shader: %v", err)
+				}
+			}
+			if DEBUG_MODE {
+				log.Printf("Downloaded shader from %s (%d bytes)", rawURL, len(data))
+			}
+			return shaderData, nil
+		}
+		// Keep a good cached copy (and use it below) instead of a broken download,
+		// such as the HTML page of a captive portal or proxy
+		err = fmt.Errorf("download doesn't parse: %v", parseErr)
+	}
+
+	if cacheErr == nil && fileExists(cachePath) {
+		log.Printf("Warning: cannot download shader (%v), using cached copy", err)
+		cached, readErr := os.ReadFile(cachePath)
+		if readErr == nil {
+			return parseShaderFile(cached, name)
+		}
+	}
+	return nil, fmt.Errorf("cannot download shader from %s: %v", rawURL, err)
+}
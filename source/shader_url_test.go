@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestShaderRedirectPolicy(t *testing.T) {
+	tests := []struct {
+		target    string
+		allowHTTP bool
+		ok        bool
+	}{
+		{"https://example.com/b.json", false, true},
+		{"http://example.com/b.json", false, false},
+		{"http://example.com/b.json", true, true},
+		{"file:///etc/passwd", true, false},
+	}
+	via := []*http.Request{{URL: &url.URL{Scheme: "https", Host: "example.com", Path: "/a.json"}}}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = shaderRedirectPolicy(tt.allowHTTP)(&http.Request{URL: u}, via)
+		if (err == nil) != tt.ok {
+			t.Errorf("redirect to %s (allow-http %v): err = %v, want ok %v", tt.target, tt.allowHTTP, err, tt.ok)
+		}
+	}
+}
+
+// TestShaderURLCache checks that a download that doesn't parse (a captive portal's
+// HTML page) falls back to the cached copy and leaves it in place
+func TestShaderURLCache(t *testing.T) {
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache) // os.UserCacheDir on Linux
+	t.Setenv("HOME", cache)           // macOS
+	t.Setenv("LocalAppData", cache)   // Windows
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><body>Please log in to the network</body></html>")
+	}))
+	defer server.Close()
+	rawURL := server.URL + "/aurora.json"
+	cachePath, err := shaderCachePath(rawURL, "aurora.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := `{"passes":[{"type":"image","code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(1.0); }"}]}`
+	if err := os.WriteFile(cachePath, []byte(cached), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := loadShaderURL(rawURL, true)
+	if err != nil {
+		t.Fatalf("broken download with a cached copy: %v", err)
+	}
+	if len(data.Passes) != 1 || !strings.Contains(data.Passes[0].Code, "vec4(1.0)") {
+		t.Errorf("got %+v, want the cached shader", data.Passes)
+	}
+	if kept, err := os.ReadFile(cachePath); err != nil || string(kept) != cached {
+		t.Errorf("cached copy was replaced: %q (%v)", kept, err)
+	}
+
+	os.Remove(cachePath)
+	if _, err := loadShaderURL(rawURL, true); err == nil {
+		t.Error("broken download without a cached copy: want an error")
+	}
+}
@@ -0,0 +1,81 @@
+// Custom vertex shaders (`vertex_code` of a pass).
+//
+// By default the image pass is drawn as a fullscreen quad by a built-in vertex
+// shader. Shaders ported from desktop demos sometimes bring their own vertex
+// stage (to warp or tilt the quad, say), given as the pass's `vertex_code`:
+// a `void main()` that reads the quad attributes `aPos` and `aTexCoord` (both
+// 0..1 across the screen), may use the same uniforms as the image pass, and
+// writes `gl_Position` and `fragCoord` (0..1 across the screen; the fragment
+// stage scales it by iResolution). Like `code`, it may be an array of lines.
+// The version line, attributes, varying and uniforms are provided;
+// declarations of them in the code are dropped. Code that doesn't write both
+// outputs is rejected with a warning and the built-in vertex shader is used.
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// defaultVertexShader renders the fullscreen quad
+const defaultVertexShader = `#version 330 core
+layout(location = 0) in vec2 aPos;
+layout(location = 1) in vec2 aTexCoord;
+out vec2 fragCoord;
+
+void main() {
+    fragCoord = aTexCoord;
+    gl_Position = vec4(aPos * 2.0 - 1.0, 0.0, 1.0);
+}` + "\x00"
+
+var (
+	vertexMainPattern     = regexp.MustCompile(`\bvoid\s+main\s*\(\s*(void\s*)?\)`)
+	vertexPositionPattern = regexp.MustCompile(`\bgl_Position\s*(\.\s*\w+\s*)?=[^=]`)
+	vertexVaryingPattern  = regexp.MustCompile(`\bfragCoord\s*(\.\s*\w+\s*)?=[^=]`)
+
+	// Lines that declare something the wrapper provides
+	vertexProvidedPattern = regexp.MustCompile(`^\s*(#version\b.*|(layout\s*\([^)]*\)\s*)?(in|out)\s+vec2\s+(aPos|aTexCoord|fragCoord)\s*;\s*)$`)
+)
+
+// validateVertexCode checks that comment-free vertex code defines main and writes
+// gl_Position and fragCoord
+func validateVertexCode(code string) error {
+	switch {
+	case !vertexMainPattern.MatchString(code):
+		return fmt.Errorf("no void main()")
+	case !vertexPositionPattern.MatchString(code):
+		return fmt.Errorf("gl_Position is never written")
+	case !vertexVaryingPattern.MatchString(code):
+		return fmt.Errorf("fragCoord is never written")
+	}
+	return nil
+}
+
+// vertexShaderCode returns the vertex shader of pass: its vertex_code wrapped with
+// attributes and uniforms (including shader parameters), or the built-in quad shader
+func vertexShaderCode(shaderData *ShaderData, pass *ShaderPass) string {
+	if strings.TrimSpace(pass.VertexCode) == "" {
+		return defaultVertexShader
+	}
+	var lines []string
+	for _, line := range strings.Split(removeComments(normalizeLineEndings(pass.VertexCode)), "\n") {
+		if !vertexProvidedPattern.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	code := strings.Join(lines, "\n")
+	if err := validateVertexCode(code); err != nil {
+		log.Printf("Warning: ignoring vertex_code of pass %q: %v; using the fullscreen quad", pass.Name, err)
+		return defaultVertexShader
+	}
+	if DEBUG_MODE {
+		log.Printf("Using custom vertex shader of pass %q (%d bytes)", pass.Name, len(code))
+	}
+	return `#version 330 core
+layout(location = 0) in vec2 aPos;
+layout(location = 1) in vec2 aTexCoord;
+out vec2 fragCoord;
+` + removeComments(shaderUniformDeclarations) + shaderParamDeclarations(shaderParams(shaderData), code) + "\n" + code + "\n\x00"
+}
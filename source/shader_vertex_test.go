@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// vertexCodeSample shrinks the quad to the middle half of the screen around a white image
+const vertexCodeSample = `{"passes":[{"type":"image","name":"warp",
+ "code":"void mainImage(out vec4 c, in vec2 p) { c = vec4(1.0); }",
+ "vertex_code":["#version 330 core","layout(location = 0) in vec2 aPos;","out vec2 fragCoord; // provided","void main() {",
+  "    fragCoord = aTexCoord;","    gl_Position = vec4((aPos * 2.0 - 1.0) * 0.5, 0.0, 1.0);","}"]}]}`
+
+// TestVertexCode checks wrapping and validation of a pass's vertex_code
+func TestVertexCode(t *testing.T) {
+	data, err := parseShaderJSON([]byte(vertexCodeSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pass := data.Passes[0]
+	if !strings.Contains(pass.VertexCode, "void main() {\n    fragCoord") {
+		t.Errorf("vertex_code lines not joined: %q", pass.VertexCode)
+	}
+	code := vertexShaderCode(data, &pass)
+	for _, want := range []string{"uniform vec3 iResolution;", "in vec2 aTexCoord;", "* 0.5, 0.0, 1.0);"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("%q missing in vertex shader:\n%s", want, code)
+		}
+	}
+	for _, declared := range []string{"#version", "in vec2 aPos;", "out vec2 fragCoord;"} {
+		if strings.Count(code, declared) != 1 {
+			t.Errorf("%q declared %d times in vertex shader:\n%s", declared, strings.Count(code, declared), code)
+		}
+	}
+	for _, broken := range []string{
+		"void main() { fragCoord = aTexCoord; }",
+		"void main() { gl_Position = vec4(aPos, 0.0, 1.0); }",
+		"void main() { if (gl_Position == vec4(0.0)) fragCoord = aTexCoord; }",
+		"void warp() { fragCoord = aTexCoord; gl_Position = vec4(aPos, 0.0, 1.0); }",
+	} {
+		pass.VertexCode = broken
+		if vertexShaderCode(data, &pass) != defaultVertexShader {
+			t.Errorf("invalid vertex code accepted: %s", broken)
+		}
+	}
+	if pass.VertexCode = ""; vertexShaderCode(data, &pass) != defaultVertexShader {
+		t.Errorf("pass without vertex_code doesn't get the fullscreen quad")
+	}
+}
+
+// TestCustomVertexShader renders vertexCodeSample and checks the quad covers only the middle
+func TestCustomVertexShader(t *testing.T) {
+	data, err := parseShaderJSON([]byte(vertexCodeSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultConfig()
+	const size = 64
+	pixels := renderShaderPixels(t, data, &cfg, size)
+	at := func(x, y int) byte { return pixels[(y*size+x)*4] }
+	if corner, center := at(2, 2), at(size/2, size/2); corner > 8 || center < 247 {
+		t.Errorf("warped quad: corner %d, center %d (expected black and white)", corner, center)
+	}
+}
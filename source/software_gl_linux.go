@@ -0,0 +1,14 @@
+//go:build linux
+// +build linux
+
+package main
+
+import "os"
+
+// requestSoftwareRendering makes Mesa pick its software rasterizer (llvmpipe).
+// Must run before GLFW creates a context.
+func requestSoftwareRendering() bool {
+	os.Setenv("LIBGL_ALWAYS_SOFTWARE", "1")
+	os.Setenv("GALLIUM_DRIVER", "llvmpipe")
+	return true
+}
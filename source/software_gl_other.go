@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import "log"
+
+// requestSoftwareRendering can't switch drivers outside Linux/Mesa.
+func requestSoftwareRendering() bool {
+	log.Printf("Warning: -software is only supported with Mesa on Linux " +
+		"(on Windows, Mesa's opengl32.dll next to the executable has the same effect); using hardware rendering")
+	return false
+}
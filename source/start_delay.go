@@ -0,0 +1,42 @@
+// Startup delay (`-start-delay`).
+//
+// When the screensaver starts during a busy moment (login animation, another
+// app grabbing the GPU), compiling the shader and rendering the first frames
+// compete with it and the fade-in starts with a hitch. With a delay the
+// fullscreen window only shows black and handles events for that long, then
+// loads the shader and begins the fade-in as usual. Input during the delay
+// exits right away (the screen is black already, there is nothing to fade).
+package main
+
+import (
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// START_DELAY_MAX is the longest accepted -start-delay in milliseconds
+const START_DELAY_MAX = 60000
+
+// waitStartDelay shows black for delay while handling events; returns false
+// if input asked to exit meanwhile
+func waitStartDelay(window *glfw.Window, state *FrameState, delay time.Duration) bool {
+	end := time.Now().Add(delay)
+	for {
+		// Redraw on every wake-up: window refreshes and the other swap buffer need black too
+		fbWidth, fbHeight := window.GetFramebufferSize()
+		gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+		gl.ClearColor(0.0, 0.0, 0.0, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		window.SwapBuffers()
+
+		remaining := time.Until(end)
+		if remaining <= 0 {
+			return true
+		}
+		glfw.WaitEventsTimeout(remaining.Seconds())
+		if window.ShouldClose() || state.exiting() {
+			return false
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Raw frame streaming for external encoders (`-stream <file>`).
+//
+// Renders the shader offscreen at a fixed resolution (`-render-size`,
+// default 1280x720) and writes raw frames to a file, a named pipe (mkfifo on
+// Linux/macOS, `\\.\pipe\name` created by the encoder on Windows) or stdout
+// ("-"), until the reader closes the pipe or the process is interrupted.
+// Frame N is at iTime N / fps, and frames are paced to real time at
+// `-stream-fps` (default 30); a slow reader delays frames but never drops them.
+//
+// Byte format, no header or padding between frames:
+//   - rgba: width*height*4 bytes per frame, rows top to bottom, R G B A (A = 255)
+//   - nv12: width*height bytes of Y, then width*height/2 bytes of interleaved
+//     U V at half resolution (BT.601 limited range); width and height must be even
+//
+// E.g. `-stream - -render-size 1280x720 | ffmpeg -f rawvideo -pix_fmt rgba
+// -s 1280x720 -r 30 -i - out.mp4`. Pixels are read back asynchronously
+// (see readback.go), so the stream lags rendering by one frame.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+const (
+	STREAM_WIDTH       = 1280
+	STREAM_HEIGHT      = 720
+	STREAM_DEFAULT_FPS = 30
+	STREAM_FPS_MAX     = 240
+	STREAM_FORMAT_RGBA = "rgba"
+	STREAM_FORMAT_NV12 = "nv12"
+)
+
+// streamFrameSize returns bytes per frame of format
+func streamFrameSize(format string, width, height int) (int, error) {
+	switch format {
+	case STREAM_FORMAT_RGBA:
+		return width * height * 4, nil
+	case STREAM_FORMAT_NV12:
+		if width%2 != 0 || height%2 != 0 {
+			return 0, fmt.Errorf("nv12 needs even width and height, got %dx%d", width, height)
+		}
+		return width * height * 3 / 2, nil
+	default:
+		return 0, fmt.Errorf("unknown stream format %q (expected rgba or nv12)", format)
+	}
+}
+
+// convertStreamFrame converts GL pixels (RGBA, rows bottom to top) to stream format in dst
+func convertStreamFrame(dst, pixels []byte, format string, width, height int) {
+	stride := width * 4
+	if format == STREAM_FORMAT_RGBA {
+		for y := 0; y < height; y++ {
+			row := dst[y*stride : (y+1)*stride]
+			copy(row, pixels[(height-1-y)*stride:(height-y)*stride])
+			for x := 3; x < stride; x += 4 {
+				row[x] = 255
+			}
+		}
+		return
+	}
+
+	// NV12: full resolution luma, chroma averaged over 2x2 blocks
+	uv := dst[width*height:]
+	for y := 0; y < height; y++ {
+		src := pixels[(height-1-y)*stride:]
+		for x := 0; x < width; x++ {
+			r, g, b := int(src[x*4]), int(src[x*4+1]), int(src[x*4+2])
+			dst[y*width+x] = byte((66*r+129*g+25*b+128)>>8 + 16)
+		}
+	}
+	for y := 0; y < height; y += 2 {
+		top, bottom := pixels[(height-1-y)*stride:], pixels[(height-2-y)*stride:]
+		for x := 0; x < width; x += 2 {
+			var r, g, b int
+			for _, p := range [][]byte{top[x*4:], top[x*4+4:], bottom[x*4:], bottom[x*4+4:]} {
+				r, g, b = r+int(p[0]), g+int(p[1]), b+int(p[2])
+			}
+			r, g, b = r/4, g/4, b/4
+			i := (y/2)*width + x
+			uv[i] = byte((-38*r-74*g+112*b+128)>>8 + 128)
+			uv[i+1] = byte((112*r-94*g-18*b+128)>>8 + 128)
+		}
+	}
+}
+
+// openStreamOutput opens stream destination: stdout for "-", an existing file or pipe, or a new file
+func openStreamOutput(path string) (*os.File, error) {
+	if path == "-" {
+		return os.Stdout, nil
+	}
+	// Pipes must be opened, not created (Windows named pipes reject CREATE_ALWAYS)
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return os.Create(path)
+	}
+	return file, err
+}
+
+// runStream renders frames at fps and writes them in format to path until the reader goes away
+func runStream(cfg *Config, path, format string, fps int) error {
+	if fps <= 0 || fps > STREAM_FPS_MAX {
+		return fmt.Errorf("stream frame rate %d out of range (1-%d)", fps, STREAM_FPS_MAX)
+	}
+	width, height := STREAM_WIDTH, STREAM_HEIGHT
+	if cfg.hasRenderSize() {
+		width, height = cfg.RenderWidth, cfg.RenderHeight
+	}
+	frameSize, err := streamFrameSize(format, width, height)
+	if err != nil {
+		return err
+	}
+
+	shaderData, err := loadShader(cfg)
+	if err != nil {
+		return &shaderError{fmt.Errorf("loading shader: %v", err)}
+	}
+	vertexShader, fragmentShader, err := getMainShaderCode(shaderData, cfg)
+	if err != nil {
+		return &shaderError{fmt.Errorf("extracting shader code: %v", err)}
+	}
+
+	output, err := openStreamOutput(path)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	// Write to a closed pipe returns an error instead of killing the process
+	signal.Ignore(syscall.SIGPIPE)
+	info, err := output.Stat()
+	if err != nil {
+		return err
+	}
+	toPipe := !info.Mode().IsRegular()
+
+	// glfw.Terminate is safe to call even if Init failed
+	defer glfw.Terminate()
+	window, err := createOffscreenContext(width, height)
+	if err != nil {
+		return err
+	}
+	defer window.Destroy()
+
+	program, err := buildProgram(vertexShader, fragmentShader)
+	if err != nil {
+		return &shaderError{err}
+	}
+	defer gl.DeleteProgram(program)
+
+	renderer, err := newFrameRenderer(program, shaderData, cfg, width, height)
+	if err != nil {
+		return err
+	}
+	defer renderer.delete()
+	readback := newPixelReadback(width, height)
+	defer readback.delete()
+
+	log.Printf("Streaming %s %dx%d at %d fps to %s", format, width, height, fps, path)
+	frameData := make([]byte, frameSize)
+	timeStep := 1.0 / float64(fps)
+	frameDuration := time.Second / time.Duration(fps)
+	start := time.Now()
+	for frame := 1; ; frame++ {
+		renderer.renderFrame(frame, timeStep)
+		if err := pendingGLError(); err != nil {
+			return fmt.Errorf("frame %d: %v", frame, err)
+		}
+		err := readback.readbackFramebuffer(renderer.target.fbo, func(pixels []byte) error {
+			convertStreamFrame(frameData, pixels, format, width, height)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("frame %d: %v", frame, err)
+		}
+		if frame == 1 {
+			continue
+		}
+
+		// Frame N-1 is ready: wait for its time, then write it
+		time.Sleep(time.Until(start.Add(time.Duration(frame-2) * frameDuration)))
+		if _, err := output.Write(frameData); err != nil {
+			if toPipe {
+				// Reader closed the pipe: normal end of stream
+				log.Printf("Stream closed after %d frames: %v", frame-2, err)
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestStreamFormats checks raw stream conversion: rows flipped to top-down, BT.601 NV12 levels
+func TestStreamFormats(t *testing.T) {
+	// 2x2 GL pixels, bottom row first: bottom black, top white
+	pixels := []byte{0, 0, 0, 0, 0, 0, 0, 0, 255, 255, 255, 0, 255, 255, 255, 0}
+	rgba := make([]byte, 16)
+	convertStreamFrame(rgba, pixels, STREAM_FORMAT_RGBA, 2, 2)
+	if want := []byte{255, 255, 255, 255, 255, 255, 255, 255, 0, 0, 0, 255, 0, 0, 0, 255}; !bytes.Equal(rgba, want) {
+		t.Errorf("rgba frame %v, expected %v", rgba, want)
+	}
+	size, err := streamFrameSize(STREAM_FORMAT_NV12, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nv12 := make([]byte, size)
+	convertStreamFrame(nv12, pixels, STREAM_FORMAT_NV12, 2, 2)
+	if want := []byte{235, 235, 16, 16, 128, 128}; !bytes.Equal(nv12, want) {
+		t.Errorf("nv12 frame %v, expected %v", nv12, want)
+	}
+	if _, err := streamFrameSize(STREAM_FORMAT_NV12, 3, 2); err == nil {
+		t.Errorf("odd nv12 width accepted")
+	}
+}
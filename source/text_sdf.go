@@ -0,0 +1,286 @@
+// Signed distance field (SDF) text for overlays (clock, watermark, debug info).
+//
+// The plain TextRenderer path rasterizes text into a texture at font size and
+// stretches it, which is blurry at the scales used on 4K screens. At startup
+// every glyph of basicfont.Face7x13 is instead upscaled SDF_TEXT_UPSCALE
+// times and turned into a distance field (distance to the glyph edge, 0.5 on
+// the edge) in one atlas texture. Text is drawn as one quad per glyph and the
+// fragment shader thresholds the interpolated distance with a smoothstep one
+// screen pixel wide, so edges stay sharp at any scale. Lines with characters
+// outside the atlas, and all text with `-simple-text`, use the plain path.
+package main
+
+import (
+	"image"
+	"image/draw"
+	"log"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	SDF_TEXT_UPSCALE  = 4  // Atlas texels per font pixel
+	SDF_TEXT_SPREAD   = 8  // Encoded distance range in texels on each side of an edge (also cell padding)
+	SDF_TEXT_COLUMNS  = 16 // Glyph cells per atlas row
+	SDF_TEXT_BASELINE = 13 // Baseline of first line in font pixels, as in RenderLines
+)
+
+const sdfTextFragmentShaderSource = `
+#version 330 core
+in vec2 TexCoord;
+out vec4 FragColor;
+uniform sampler2D textTexture;
+uniform vec4 textColor;
+
+void main() {
+    float distance = texture(textTexture, TexCoord).r;
+    // Antialias over about one screen pixel whatever the scale
+    float width = max(fwidth(distance), 1e-4) * 0.7;
+    float alpha = smoothstep(0.5 - width, 0.5 + width, distance);
+    FragColor = vec4(textColor.rgb, textColor.a * alpha);
+}` + "\x00"
+
+// sdfAtlas is the distance field of all font glyphs, one cell per glyph
+type sdfAtlas struct {
+	image *image.Gray
+	cells map[rune]image.Point // Top-left texel of glyph cell
+	cellW int                  // Cell size in texels, padding included
+	cellH int
+}
+
+// buildSDFAtlas rasterizes every glyph of face and converts it to a distance field
+func buildSDFAtlas(face *basicfont.Face) *sdfAtlas {
+	var runes []rune
+	for _, r := range face.Ranges {
+		for c := r.Low; c < r.High; c++ {
+			runes = append(runes, c)
+		}
+	}
+	a := &sdfAtlas{
+		cells: make(map[rune]image.Point, len(runes)),
+		cellW: face.Advance*SDF_TEXT_UPSCALE + 2*SDF_TEXT_SPREAD,
+		cellH: face.Height*SDF_TEXT_UPSCALE + 2*SDF_TEXT_SPREAD,
+	}
+	rows := (len(runes) + SDF_TEXT_COLUMNS - 1) / SDF_TEXT_COLUMNS
+	a.image = image.NewGray(image.Rect(0, 0, SDF_TEXT_COLUMNS*a.cellW, rows*a.cellH))
+
+	glyph := image.NewAlpha(image.Rect(0, 0, face.Advance, face.Height))
+	inside := make([]bool, a.cellW*a.cellH)
+	outside := make([]bool, a.cellW*a.cellH)
+	for i, r := range runes {
+		draw.Draw(glyph, glyph.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		d := &font.Drawer{Dst: glyph, Src: image.White, Face: face, Dot: fixed.P(0, face.Ascent)}
+		d.DrawString(string(r))
+
+		// Nearest-neighbor upscale keeps the pixel font's square corners
+		for y := 0; y < a.cellH; y++ {
+			for x := 0; x < a.cellW; x++ {
+				fx := (x - SDF_TEXT_SPREAD) / SDF_TEXT_UPSCALE
+				fy := (y - SDF_TEXT_SPREAD) / SDF_TEXT_UPSCALE
+				in := x >= SDF_TEXT_SPREAD && y >= SDF_TEXT_SPREAD && glyph.AlphaAt(fx, fy).A >= 128
+				inside[y*a.cellW+x] = in
+				outside[y*a.cellW+x] = !in
+			}
+		}
+		toInside := squaredDistances(inside, a.cellW, a.cellH)
+		toOutside := squaredDistances(outside, a.cellW, a.cellH)
+
+		cell := image.Pt((i%SDF_TEXT_COLUMNS)*a.cellW, (i/SDF_TEXT_COLUMNS)*a.cellH)
+		a.cells[r] = cell
+		for y := 0; y < a.cellH; y++ {
+			for x := 0; x < a.cellW; x++ {
+				j := y*a.cellW + x
+				// Edge lies half a texel from texel centers; positive distance outside
+				distance := math.Sqrt(toInside[j]) - 0.5
+				if inside[j] {
+					distance = 0.5 - math.Sqrt(toOutside[j])
+				}
+				value := math.Max(0, math.Min(1, 0.5-distance/(2*SDF_TEXT_SPREAD)))
+				a.image.Pix[(cell.Y+y)*a.image.Stride+cell.X+x] = uint8(value*255 + 0.5)
+			}
+		}
+	}
+	return a
+}
+
+// squaredDistances returns squared distance from each texel to the nearest texel where feature is set
+func squaredDistances(feature []bool, width, height int) []float64 {
+	dist := make([]float64, width*height)
+	for i, set := range feature {
+		if !set {
+			dist[i] = math.Inf(1)
+		}
+	}
+	n := max(width, height)
+	f, d := make([]float64, n), make([]float64, n)
+	v, z := make([]int, n), make([]float64, n+1)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			f[y] = dist[y*width+x]
+		}
+		distance1D(f[:height], d, v, z)
+		for y := 0; y < height; y++ {
+			dist[y*width+x] = d[y]
+		}
+	}
+	for y := 0; y < height; y++ {
+		copy(f, dist[y*width:(y+1)*width])
+		distance1D(f[:width], d, v, z)
+		copy(dist[y*width:(y+1)*width], d[:width])
+	}
+	return dist
+}
+
+// distance1D is the 1D squared distance transform of f into d
+// (lower envelope of parabolas, Felzenszwalb and Huttenlocher); v and z are scratch
+func distance1D(f, d []float64, v []int, z []float64) {
+	// Skip leading texels without feature: parabolas at infinity don't intersect
+	first := 0
+	for first < len(f) && math.IsInf(f[first], 1) {
+		first++
+	}
+	if first == len(f) {
+		copy(d, f)
+		return
+	}
+	k := 0
+	v[0] = first
+	z[0], z[1] = math.Inf(-1), math.Inf(1)
+	for q := first + 1; q < len(f); q++ {
+		if math.IsInf(f[q], 1) {
+			continue
+		}
+		intersect := func(p int) float64 {
+			return ((f[q] + float64(q*q)) - (f[p] + float64(p*p))) / float64(2*(q-p))
+		}
+		s := intersect(v[k])
+		// z[0] is -Inf, so k stays >= 0
+		for s <= z[k] {
+			k--
+			s = intersect(v[k])
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.Inf(1)
+	}
+	k = 0
+	for q := range f {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		d[q] = float64((q-v[k])*(q-v[k])) + f[v[k]]
+	}
+}
+
+// covers reports whether every character of lines has a glyph in the atlas
+func (a *sdfAtlas) covers(lines []string) bool {
+	for _, line := range lines {
+		for _, r := range line {
+			if _, ok := a.cells[r]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// quads returns triangle vertices (x, y, u, v) of lines laid out like RenderLines:
+// top-left at x, y, spacing font pixels between baselines, scale screen pixels per font pixel
+func (a *sdfAtlas) quads(face *basicfont.Face, lines []string, x, y float32, spacing int, scale float32) []float32 {
+	atlasW, atlasH := float32(a.image.Rect.Dx()), float32(a.image.Rect.Dy())
+	pad := float32(SDF_TEXT_SPREAD) / SDF_TEXT_UPSCALE
+	w := float32(a.cellW) / SDF_TEXT_UPSCALE * scale
+	h := float32(a.cellH) / SDF_TEXT_UPSCALE * scale
+	var vertices []float32
+	for i, line := range lines {
+		top := y + (float32(SDF_TEXT_BASELINE-face.Ascent+i*spacing)-pad)*scale
+		pen := 0
+		for _, r := range line {
+			cell, ok := a.cells[r]
+			if ok && r != ' ' {
+				left := x + (float32(pen)-pad)*scale
+				u0, v0 := float32(cell.X)/atlasW, float32(cell.Y)/atlasH
+				u1, v1 := float32(cell.X+a.cellW)/atlasW, float32(cell.Y+a.cellH)/atlasH
+				vertices = append(vertices,
+					left, top+h, u0, v1,
+					left, top, u0, v0,
+					left+w, top, u1, v0,
+					left, top+h, u0, v1,
+					left+w, top, u1, v0,
+					left+w, top+h, u1, v1,
+				)
+			}
+			pen += face.Advance
+		}
+	}
+	return vertices
+}
+
+// sdfText is the GL side of SDF text: atlas texture and its shader program
+type sdfText struct {
+	atlas      *sdfAtlas
+	program    uint32
+	texture    uint32
+	projection int32
+	textColor  int32
+}
+
+// newSDFText builds the glyph atlas and uploads it
+func newSDFText() *sdfText {
+	atlas := buildSDFAtlas(basicfont.Face7x13)
+	s := &sdfText{atlas: atlas}
+	s.program = newProgram(textVertexShaderSource, sdfTextFragmentShaderSource)
+	s.projection = gl.GetUniformLocation(s.program, gl.Str("projection\x00"))
+	s.textColor = gl.GetUniformLocation(s.program, gl.Str("textColor\x00"))
+
+	gl.GenTextures(1, &s.texture)
+	gl.BindTexture(gl.TEXTURE_2D, s.texture)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R8, int32(atlas.image.Rect.Dx()), int32(atlas.image.Rect.Dy()), 0, gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(atlas.image.Pix))
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	checkGLError("SDF text atlas")
+	if DEBUG_MODE {
+		log.Printf("SDF text atlas: %d glyphs, %dx%d", len(atlas.cells), atlas.image.Rect.Dx(), atlas.image.Rect.Dy())
+	}
+	return s
+}
+
+// renderSDF draws fitted lines with the SDF atlas (see RenderLines for arguments)
+func (tr *TextRenderer) renderSDF(lines []string, x, y float32, spacing int, scale float32) {
+	vertices := tr.sdf.atlas.quads(basicfont.Face7x13, lines, x, y, spacing, scale)
+	if len(vertices) == 0 {
+		return
+	}
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	projection := tr.projectionMatrix()
+	gl.UseProgram(tr.sdf.program)
+	gl.UniformMatrix4fv(tr.sdf.projection, 1, false, &projection[0])
+	gl.Uniform4f(tr.sdf.textColor, tr.color[0], tr.color[1], tr.color[2], tr.color[3])
+
+	gl.BindVertexArray(tr.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, tr.vbo)
+	// Buffer is sized for the text; it never gets smaller than the single quad of the plain path
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.DYNAMIC_DRAW)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, tr.sdf.texture)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(vertices)/4))
+	checkGLError("SDF text draw")
+
+	gl.BindVertexArray(0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.Disable(gl.BLEND)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// TestSDFText checks the SDF glyph atlas: thresholding at 0.5 gives back the upscaled font
+// bitmap, distances match a brute force search, and spaces get no quads
+func TestSDFText(t *testing.T) {
+	face := basicfont.Face7x13
+	atlas := buildSDFAtlas(face)
+	for r, cell := range atlas.cells {
+		glyph := image.NewAlpha(image.Rect(0, 0, face.Advance, face.Height))
+		d := &font.Drawer{Dst: glyph, Src: image.White, Face: face, Dot: fixed.P(0, face.Ascent)}
+		d.DrawString(string(r))
+		for y := 0; y < face.Height*SDF_TEXT_UPSCALE; y++ {
+			for x := 0; x < face.Advance*SDF_TEXT_UPSCALE; x++ {
+				in := glyph.AlphaAt(x/SDF_TEXT_UPSCALE, y/SDF_TEXT_UPSCALE).A >= 128
+				value := atlas.image.GrayAt(cell.X+SDF_TEXT_SPREAD+x, cell.Y+SDF_TEXT_SPREAD+y).Y
+				if in != (value >= 128) {
+					t.Fatalf("glyph %q: texel %d,%d is %d, inside %v", r, x, y, value, in)
+				}
+			}
+		}
+	}
+
+	// Distance transform against brute force on a small shape
+	const w, h = 9, 7
+	feature := make([]bool, w*h)
+	feature[2*w+3], feature[5*w+7] = true, true
+	got := squaredDistances(feature, w, h)
+	for i := range got {
+		want := math.Inf(1)
+		for j, set := range feature {
+			if set {
+				dx, dy := float64(i%w-j%w), float64(i/w-j/w)
+				want = math.Min(want, dx*dx+dy*dy)
+			}
+		}
+		if got[i] != want {
+			t.Fatalf("squared distance at %d,%d = %g, expected %g", i%w, i/w, got[i], want)
+		}
+	}
+
+	if n := len(atlas.quads(face, []string{"12:34", "a b"}, 0, 0, 20, 2)); n != 7*24 {
+		t.Errorf("%d quad vertices floats for 7 visible glyphs, expected %d", n, 7*24)
+	}
+	if atlas.covers([]string{"\u4e16"}) {
+		t.Errorf("atlas claims to cover CJK text")
+	}
+}
@@ -0,0 +1,48 @@
+// Aspect ratio of loaded channel textures (sampler `fit` and `aspect`).
+//
+// Channel textures are sampled over [0,1] whatever their size, so a shader
+// written for a square image shows a substitute 16:9 image squeezed. The
+// sampler object may therefore name a target aspect (width / height) and how
+// to reach it: "stretch" (default, the image is used as is), "pad" (also
+// "letterbox": the image is centered on transparent black borders) or "crop"
+// (the middle of the image is cut out). This is not part of the Shadertoy
+// format. It applies to images loaded from files (image textures and animated GIF
+// frames), not to the generated noise texture; iChannelResolution reports the fitted size.
+package main
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+const (
+	SAMPLER_FIT_STRETCH = "stretch"
+	SAMPLER_FIT_PAD     = "pad"
+	SAMPLER_FIT_CROP    = "crop"
+
+	SAMPLER_ASPECT_MAX = 16.0 // Target aspects beyond 16:1 (or 1:16) are ignored
+)
+
+// fitAspect returns img padded or cropped to aspect (width / height) as
+// fit says; img itself for stretch or when it already has that aspect
+func fitAspect(img *image.RGBA, fit string, aspect float64) *image.RGBA {
+	if (fit != SAMPLER_FIT_PAD && fit != SAMPLER_FIT_CROP) || aspect <= 0 {
+		return img
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	wider := float64(width) > float64(height)*aspect
+	if (fit == SAMPLER_FIT_PAD) == wider {
+		height = max(int(math.Round(float64(width)/aspect)), 1)
+	} else {
+		width = max(int(math.Round(float64(height)*aspect)), 1)
+	}
+	if width == img.Bounds().Dx() && height == img.Bounds().Dy() {
+		return img
+	}
+	fitted := image.NewRGBA(image.Rect(0, 0, width, height))
+	// Center img on the new size: a positive offset pads, a negative one crops
+	offset := image.Pt((width-img.Bounds().Dx())/2, (height-img.Bounds().Dy())/2)
+	draw.Draw(fitted, img.Bounds().Sub(img.Bounds().Min).Add(offset), img, img.Bounds().Min, draw.Src)
+	return fitted
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+// TestTextureFit checks padding and cropping of channel images to a sampler aspect
+func TestTextureFit(t *testing.T) {
+	// 4x2 image, each pixel's red channel is its index
+	wide := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for i := 0; i < 8; i++ {
+		wide.Pix[i*4] = uint8(i + 1)
+		wide.Pix[i*4+3] = 255
+	}
+	red := func(img *image.RGBA) []uint8 {
+		var values []uint8
+		for i := 0; i < len(img.Pix); i += 4 {
+			values = append(values, img.Pix[i])
+		}
+		return values
+	}
+	for _, test := range []struct {
+		fit    string
+		aspect float64
+		size   image.Point
+		red    []uint8
+	}{
+		{SAMPLER_FIT_STRETCH, 1, image.Pt(4, 2), []uint8{1, 2, 3, 4, 5, 6, 7, 8}},
+		{SAMPLER_FIT_PAD, 2, image.Pt(4, 2), []uint8{1, 2, 3, 4, 5, 6, 7, 8}},
+		{SAMPLER_FIT_PAD, 1, image.Pt(4, 4), []uint8{0, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8, 0, 0, 0, 0}},
+		{SAMPLER_FIT_PAD, 4, image.Pt(8, 2), []uint8{0, 0, 1, 2, 3, 4, 0, 0, 0, 0, 5, 6, 7, 8, 0, 0}},
+		{SAMPLER_FIT_CROP, 1, image.Pt(2, 2), []uint8{2, 3, 6, 7}},
+		{SAMPLER_FIT_CROP, 4, image.Pt(4, 1), []uint8{1, 2, 3, 4}},
+	} {
+		fitted := fitAspect(wide, test.fit, test.aspect)
+		if size := fitted.Bounds().Size(); size != test.size || !reflect.DeepEqual(red(fitted), test.red) {
+			t.Errorf("%s to %g: %v %v, expected %v %v", test.fit, test.aspect, size, red(fitted), test.size, test.red)
+		}
+		if test.fit == SAMPLER_FIT_PAD && test.size.X*test.size.Y > 8 && fitted.Pix[3] != 0 {
+			t.Errorf("%s to %g: border not transparent", test.fit, test.aspect)
+		}
+	}
+
+	shaderData, err := parseShaderJSON([]byte(`{"passes":[{"type":"image","code":"void mainImage(out vec4 c, in vec2 p) { c = texture(iChannel0, p); }","inputs":[
+ {"channel":0,"src":"a.gif","sampler":{"fit":"letterbox","aspect":1.5}},
+ {"channel":1,"src":"b.gif","sampler":{"fit":"crop","aspect":100}},
+ {"channel":2,"src":"c.gif","sampler":{"fit":"stretch","aspect":2}}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	samplers := channelSamplers(&shaderData.Passes[0])
+	if samplers[0].Fit != SAMPLER_FIT_PAD || samplers[0].Aspect != 1.5 {
+		t.Errorf("letterbox sampler %+v", samplers[0])
+	}
+	if samplers[1] != defaultSampler() || samplers[2] != defaultSampler() {
+		t.Errorf("out of range or stretch samplers %+v %+v, expected defaults", samplers[1], samplers[2])
+	}
+
+	// Padding frames to 4x4 leaves room for only two of three in 160 bytes
+	anim := &channelAnimation{frames: []*image.RGBA{wide, wide, wide}, ends: []float64{0.1, 0.2, 0.3}}
+	anim.fit(SAMPLER_FIT_PAD, 1, 160)
+	if len(anim.frames) != 2 || len(anim.ends) != 2 || anim.frames[1].Bounds().Size() != image.Pt(4, 4) {
+		t.Errorf("padded animation kept %d frames of %v", len(anim.frames), anim.frames[len(anim.frames)-1].Bounds().Size())
+	}
+}
@@ -0,0 +1,228 @@
+// Channel texture helpers.
+//
+// Shader passes sample `iChannel0`..`iChannel3`. Many aurora shaders expect a
+// tiling noise texture in one of them, so we generate one procedurally at
+// startup and bind it wherever a pass asks for noise (or samples a channel
+// that has no source at all).
+//
+// Every other channel gets a 1x1 black texture, so sampling a channel without
+// a source is defined (vec4(0), like Shadertoy) instead of depending on what
+// the driver does with an unbound sampler.
+//
+// Inputs may carry a Shadertoy sampler object (see sampler.go) that sets
+// filtering, wrapping, vertical flip and sRGB decoding of their texture.
+// Image file inputs get the decoded file (see image_texture.go), animated GIF
+// inputs a texture updated every frame (see animated_texture.go).
+package main
+
+import (
+	"image"
+	"log"
+	"math/rand"
+	"regexp"
+	"strconv"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+const (
+	// Size of the generated noise texture (square, power of two so it tiles).
+	NOISE_TEXTURE_SIZE = 256
+
+	// Number of sampler channels exposed to shaders.
+	CHANNEL_COUNT = 4
+)
+
+var channelRefPattern = regexp.MustCompile(`\biChannel([0-3])\b`)
+
+// channelTexture is a texture bound to a channel with its size for iChannelResolution.
+// Zero size marks a placeholder: iChannelResolution reports framebuffer size for it.
+type channelTexture struct {
+	texture   uint32
+	width     int
+	height    int
+	animation *channelAnimation // Frames of an animated GIF input (nil for static textures)
+}
+
+// noiseSeed makes generated noise reproducible when non-zero (-framedump)
+var noiseSeed int64
+
+// generateNoiseImage creates an RGBA white-noise image.
+// Uses the global RNG (pattern differs between runs) unless noiseSeed is set.
+func generateNoiseImage(size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	if noiseSeed != 0 {
+		rand.New(rand.NewSource(noiseSeed)).Read(img.Pix)
+	} else {
+		rand.Read(img.Pix)
+	}
+	return img
+}
+
+// flipVertical returns a copy of img with rows in reverse order
+func flipVertical(img *image.RGBA) *image.RGBA {
+	bounds := img.Bounds()
+	flipped := image.NewRGBA(bounds)
+	rowSize := bounds.Dx() * 4
+	for y := 0; y < bounds.Dy(); y++ {
+		src := img.Pix[y*img.Stride : y*img.Stride+rowSize]
+		dst := (bounds.Dy() - 1 - y) * flipped.Stride
+		copy(flipped.Pix[dst:dst+rowSize], src)
+	}
+	return flipped
+}
+
+// uploadTexture uploads RGBA image as a 2D texture with wrap, filtering, flip and color space of a normalized sampler
+func uploadTexture(img *image.RGBA, sampler ShaderSampler) uint32 {
+	if sampler.VFlip {
+		img = flipVertical(img)
+	}
+	minFilter, magFilter, wrap, mipmap := sampler.glParams()
+
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, wrap)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, wrap)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, minFilter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, magFilter)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, sampler.internalFormat(), int32(img.Bounds().Dx()), int32(img.Bounds().Dy()), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	if mipmap {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	checkGLError("channel TexImage2D")
+	return texture
+}
+
+// channelSamplers returns normalized sampler of each channel's input (defaults for channels without one)
+func channelSamplers(pass *ShaderPass) [CHANNEL_COUNT]ShaderSampler {
+	var result [CHANNEL_COUNT]ShaderSampler
+	for channel := range result {
+		result[channel] = defaultSampler()
+	}
+	for _, input := range pass.Inputs {
+		if input.Channel >= 0 && input.Channel < CHANNEL_COUNT {
+			result[input.Channel] = input.Sampler.normalized()
+		}
+	}
+	return result
+}
+
+// channelInput returns the pass input bound to channel (nil if there is none)
+func channelInput(pass *ShaderPass, channel int) *ShaderInput {
+	for i := range pass.Inputs {
+		if pass.Inputs[i].Channel == channel {
+			return &pass.Inputs[i]
+		}
+	}
+	return nil
+}
+
+// channelsNeedingNoise returns which channels of the pass should get the noise texture:
+// inputs with type "noise", and channels referenced in code without any input.
+func channelsNeedingNoise(pass *ShaderPass) [CHANNEL_COUNT]bool {
+	var result [CHANNEL_COUNT]bool
+	var hasInput [CHANNEL_COUNT]bool
+
+	for _, input := range pass.Inputs {
+		if input.Channel < 0 || input.Channel >= CHANNEL_COUNT {
+			continue
+		}
+		hasInput[input.Channel] = true
+		if input.Type == "noise" {
+			result[input.Channel] = true
+		}
+	}
+
+	for _, match := range channelRefPattern.FindAllStringSubmatch(pass.Code, -1) {
+		channel, _ := strconv.Atoi(match[1])
+		if !hasInput[channel] {
+			result[channel] = true
+		}
+	}
+
+	return result
+}
+
+// setupChannelTextures creates channel textures for the pass and points
+// `iChannelN` samplers at texture unit N.
+// Returns texture per channel; channels without a source get a shared black texture.
+func setupChannelTextures(program uint32, pass *ShaderPass) [CHANNEL_COUNT]channelTexture {
+	var textures [CHANNEL_COUNT]channelTexture
+
+	needsNoise := channelsNeedingNoise(pass)
+	samplers := channelSamplers(pass)
+	var noise *image.RGBA
+	noiseTextures := make(map[ShaderSampler]uint32)
+	var blackTexture uint32
+	for channel, needed := range needsNoise {
+		if input := channelInput(pass, channel); !needed && input != nil && isAnimatedInput(*input) {
+			if texture, ok := setupAnimatedChannel(channel, *input, samplers[channel]); ok {
+				textures[channel] = texture
+				continue
+			}
+		}
+		if input := channelInput(pass, channel); !needed && input != nil && isImageInput(*input) {
+			if texture, ok := setupImageChannel(channel, *input, samplers[channel]); ok {
+				textures[channel] = texture
+				continue
+			}
+		}
+		if !needed {
+			// Channels without a loadable source (missing files, keyboard, cubemaps)
+			if blackTexture == 0 {
+				blackTexture = uploadTexture(image.NewRGBA(image.Rect(0, 0, 1, 1)), samplers[channel])
+			}
+			textures[channel] = channelTexture{texture: blackTexture}
+			continue
+		}
+		// Generate noise once; channels with the same sampler share a texture
+		if noise == nil {
+			noise = generateNoiseImage(NOISE_TEXTURE_SIZE)
+		}
+		texture, ok := noiseTextures[samplers[channel]]
+		if !ok {
+			texture = uploadTexture(noise, samplers[channel])
+			noiseTextures[samplers[channel]] = texture
+			if DEBUG_MODE && samplers[channel] != defaultSampler() {
+				log.Printf("iChannel%d sampler: %+v", channel, samplers[channel])
+			}
+		}
+		textures[channel] = channelTexture{texture: texture, width: NOISE_TEXTURE_SIZE, height: NOISE_TEXTURE_SIZE}
+	}
+
+	gl.UseProgram(program)
+	for channel := 0; channel < CHANNEL_COUNT; channel++ {
+		loc := gl.GetUniformLocation(program, gl.Str("iChannel"+strconv.Itoa(channel)+"\x00"))
+		if loc >= 0 {
+			gl.Uniform1i(loc, int32(channel))
+		}
+	}
+
+	return textures
+}
+
+// deleteChannelTextures deletes textures returned by setupChannelTextures (shared ones once)
+func deleteChannelTextures(textures [CHANNEL_COUNT]channelTexture) {
+	deleted := make(map[uint32]bool)
+	for _, texture := range textures {
+		if texture.texture != 0 && !deleted[texture.texture] {
+			gl.DeleteTextures(1, &texture.texture)
+			deleted[texture.texture] = true
+		}
+	}
+}
+
+// bindChannelTextures binds channel textures to their texture units.
+// Must be called every frame: text overlay rendering rebinds unit 0.
+func bindChannelTextures(textures [CHANNEL_COUNT]channelTexture) {
+	for channel, texture := range textures {
+		if texture.texture == 0 {
+			continue
+		}
+		gl.ActiveTexture(gl.TEXTURE0 + uint32(channel))
+		gl.BindTexture(gl.TEXTURE_2D, texture.texture)
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+}
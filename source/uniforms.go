@@ -0,0 +1,465 @@
+// Shadertoy-style uniform upload shared by preview and fullscreen render loops.
+//
+// Locations are looked up once after linking; uniforms the shader doesn't use
+// are optimized out by the driver (location -1) and skipped on upload, as are
+// uniforms whose value didn't change since the previous frame.
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// MAX_TIME_DELTA caps iTimeDelta in seconds
+const MAX_TIME_DELTA = 0.25
+
+// SAMPLE_RATE is iSampleRate (standard sample rate; there is no sound input)
+const SAMPLE_RATE = 44100.0
+
+// fragCoord modes (-fragcoord)
+const (
+	FRAGCOORD_PIXEL  = "pixel"  // Shadertoy: pixels of the whole viewport
+	FRAGCOORD_SQUARE = "square" // Centered square with uniform scale, for shaders assuming square output
+)
+
+// Dithering of the final color (iDither), against banding of dark gradients on 8-bit displays
+const (
+	DITHER_OFF    = "off"    // Never (default)
+	DITHER_FADE   = "fade"   // While iFade is between 0 and 1
+	DITHER_ALWAYS = "always" // Every frame
+)
+
+// Vignette darkening the screen edges (iVignette)
+const (
+	VIGNETTE_MAX            = 1.0 // Strength: 0 = off, 1 = black corners
+	VIGNETTE_RADIUS_DEFAULT = 0.5 // Distance from center where darkening starts (1 = corners)
+	VIGNETTE_RADIUS_MAX     = 0.95
+)
+
+// shaderUniforms holds uniform locations of the main shader program
+type shaderUniforms struct {
+	resolution        int32
+	time              int32
+	timeDelta         int32
+	frame             int32
+	frameRate         int32
+	mouse             int32
+	date              int32
+	sampleRate        int32
+	channelResolution int32
+	channelTime       int32
+	fade              int32
+	pixelSize         int32 // Non-Shadertoy: 1.0 / iResolution.xy
+	scale             int32 // Non-Shadertoy: feature size multiplier from settings
+	fragCoordMode     int32 // Non-Shadertoy: 1 = aspect-corrected fragCoord
+	fragCoordOffset   int32
+	fragCoordFlip     int32 // Non-Shadertoy: 1 = fragCoord.y measured from the top
+	dither            int32 // Non-Shadertoy: 0 = off, 1 = during fades, 2 = always
+	palette           int32 // Non-Shadertoy: cosine gradient terms (see palette.go)
+	vignette          int32 // Non-Shadertoy: strength, radius
+	brightness        int32 // Non-Shadertoy: final color multiplier from settings
+
+	params []paramUniform // Shader parameters from metadata (see params.go)
+	last   uniformValues  // Values of the latest upload (uniform dump)
+	sent   []uniformSlot  // Slots of the latest upload, to skip unchanged values (nil for a new program)
+}
+
+// frameUniforms holds per-frame values uploaded to shader besides FrameState timing
+type frameUniforms struct {
+	mouse    [4]float32 // iMouse in framebuffer pixels (noMouse without input)
+	scale    float64    // iScale
+	timeWrap float64    // Wrap iTime modulo this period in seconds (0 = off)
+	speed    float64    // iTime and iTimeDelta multiplier (0 = 1)
+
+	brightness float64 // iBrightness (0 = 1)
+
+	channels [CHANNEL_COUNT]channelTexture // Bound channel textures for iChannelResolution
+	date     time.Time                     // iDate (zero = current time)
+
+	squareCoords bool // FRAGCOORD_SQUARE: iResolution, fragCoord and iMouse use centered square
+	flipCoords   bool // -flip-coord: fragCoord.y and iMouse.y measured from the top
+
+	dither  string // DITHER_OFF, DITHER_FADE or DITHER_ALWAYS
+	palette string // Bundled palette name (unknown = default)
+
+	vignette       float64 // iVignette.x strength (0 = off)
+	vignetteRadius float64 // iVignette.y
+}
+
+// getShaderUniforms looks up uniform locations in linked program
+func getShaderUniforms(program uint32) shaderUniforms {
+	u := shaderUniforms{
+		resolution:        gl.GetUniformLocation(program, gl.Str("iResolution\x00")),
+		time:              gl.GetUniformLocation(program, gl.Str("iTime\x00")),
+		timeDelta:         gl.GetUniformLocation(program, gl.Str("iTimeDelta\x00")),
+		frame:             gl.GetUniformLocation(program, gl.Str("iFrame\x00")),
+		frameRate:         gl.GetUniformLocation(program, gl.Str("iFrameRate\x00")),
+		mouse:             gl.GetUniformLocation(program, gl.Str("iMouse\x00")),
+		date:              gl.GetUniformLocation(program, gl.Str("iDate\x00")),
+		sampleRate:        gl.GetUniformLocation(program, gl.Str("iSampleRate\x00")),
+		channelResolution: gl.GetUniformLocation(program, gl.Str("iChannelResolution\x00")),
+		channelTime:       gl.GetUniformLocation(program, gl.Str("iChannelTime\x00")),
+		fade:              gl.GetUniformLocation(program, gl.Str("iFade\x00")),
+		pixelSize:         gl.GetUniformLocation(program, gl.Str("iPixelSize\x00")),
+		scale:             gl.GetUniformLocation(program, gl.Str("iScale\x00")),
+		fragCoordMode:     gl.GetUniformLocation(program, gl.Str("iFragCoordMode\x00")),
+		fragCoordOffset:   gl.GetUniformLocation(program, gl.Str("iFragCoordOffset\x00")),
+		fragCoordFlip:     gl.GetUniformLocation(program, gl.Str("iFragCoordFlip\x00")),
+		dither:            gl.GetUniformLocation(program, gl.Str("iDither\x00")),
+		palette:           gl.GetUniformLocation(program, gl.Str("iPalette\x00")),
+		vignette:          gl.GetUniformLocation(program, gl.Str("iVignette\x00")),
+		brightness:        gl.GetUniformLocation(program, gl.Str("iBrightness\x00")),
+	}
+
+	// Debug: check for main uniforms
+	if DEBUG_MODE {
+		log.Printf("Uniform locations: iResolution=%d, iTime=%d, iTimeDelta=%d, iFrame=%d",
+			u.resolution, u.time, u.timeDelta, u.frame)
+		if u.resolution < 0 {
+			log.Println("WARNING: iResolution uniform not found in shader!")
+		}
+		if u.time < 0 {
+			log.Println("WARNING: iTime uniform not found in shader!")
+		}
+	}
+
+	return u
+}
+
+// wrapTime returns seconds modulo period (period <= 0 disables wrapping)
+func wrapTime(seconds, period float64) float64 {
+	if period <= 0 {
+		return seconds
+	}
+	return math.Mod(seconds, period)
+}
+
+// validateFragCoordMode checks -fragcoord value
+func validateFragCoordMode(mode string) error {
+	switch mode {
+	case FRAGCOORD_PIXEL, FRAGCOORD_SQUARE:
+		return nil
+	}
+	return fmt.Errorf("unknown fragcoord mode %q (expected pixel or square)", mode)
+}
+
+// validateDitherMode checks -dither value
+func validateDitherMode(mode string) error {
+	switch mode {
+	case DITHER_OFF, DITHER_FADE, DITHER_ALWAYS:
+		return nil
+	}
+	return fmt.Errorf("unknown dither mode %q (expected off, fade or always)", mode)
+}
+
+// squareView returns side and bottom-left offset of the centered square in width x height
+func squareView(width, height float32) (float32, float32, float32) {
+	side := width
+	if height < side {
+		side = height
+	}
+	return side, (width - side) / 2, (height - side) / 2
+}
+
+// offsetMouse moves iMouse into square coordinates, keeping signs of .zw (button state)
+func offsetMouse(mouse [4]float32, offsetX, offsetY float32) [4]float32 {
+	shift := func(value, offset float32) float32 {
+		if value < 0 {
+			return -(-value - offset)
+		}
+		return value - offset
+	}
+	if mouse == noMouse {
+		return mouse
+	}
+	return [4]float32{mouse[0] - offsetX, mouse[1] - offsetY, shift(mouse[2], offsetX), shift(mouse[3], offsetY)}
+}
+
+// flipMouse measures iMouse y from the top of height, keeping sign of .w (button state)
+func flipMouse(mouse [4]float32, height float32) [4]float32 {
+	if mouse == noMouse {
+		return mouse
+	}
+	flip := func(value float32) float32 {
+		if value < 0 {
+			return -(height + value)
+		}
+		return height - value
+	}
+	return [4]float32{mouse[0], height - mouse[1], mouse[2], flip(mouse[3])}
+}
+
+// uniformValues are the values uploaded for one frame (also written by the uniform dump)
+type uniformValues struct {
+	resolution        [3]float32
+	time              float32
+	timeDelta         float32
+	frame             int32
+	frameRate         float32
+	mouse             [4]float32
+	date              [4]float32
+	channelResolution [CHANNEL_COUNT * 3]float32
+	channelTime       [CHANNEL_COUNT]float32
+	fade              float32
+	pixelSize         [2]float32
+	scale             float32
+	fragCoordMode     int32
+	fragCoordOffset   [2]float32
+	fragCoordFlip     int32
+	dither            int32
+	palette           palette
+	vignette          [2]float32
+	brightness        float32
+}
+
+// frameUniformValues derives uniform values for frame s
+func frameUniformValues(s *FrameState, f frameUniforms) uniformValues {
+	var v uniformValues
+	// At least 1x1, so a degenerate frame can't make iResolution.z Inf or NaN (see drawableSize)
+	fbWidth := float32(max(s.width, 1))
+	fbHeight := float32(max(s.height, 1))
+	if f.squareCoords {
+		// Shader sees a square viewport; template shifts fragCoord by the offset
+		fbWidth, v.fragCoordOffset[0], v.fragCoordOffset[1] = squareView(fbWidth, fbHeight)
+		fbHeight = fbWidth
+		f.mouse = offsetMouse(f.mouse, v.fragCoordOffset[0], v.fragCoordOffset[1])
+		v.fragCoordMode = 1
+	}
+	if f.flipCoords {
+		f.mouse = flipMouse(f.mouse, fbHeight)
+		v.fragCoordFlip = 1
+	}
+	speed := f.speed
+	if speed <= 0 {
+		speed = 1
+	}
+	// Wrapped time keeps float32 precision after hours of runtime; speed scales
+	// shader time only, so fades and overlays keep real time
+	elapsed := float32(wrapTime(s.shaderElapsed*speed, f.timeWrap))
+
+	// iResolution: .xy = viewport size, .z = aspect ratio (width/height)
+	// Use framebuffer size for correct resolution
+	v.resolution = [3]float32{fbWidth, fbHeight, fbWidth / fbHeight}
+	if fbWidth > 0 && fbHeight > 0 {
+		v.pixelSize = [2]float32{1.0 / fbWidth, 1.0 / fbHeight}
+	}
+	switch f.dither {
+	case DITHER_FADE:
+		v.dither = 1
+	case DITHER_ALWAYS:
+		v.dither = 2
+	}
+	v.palette, _ = findPalette(f.palette)
+	v.vignette = [2]float32{float32(f.vignette), float32(f.vignetteRadius)}
+	v.time = elapsed
+	// Clamp so a stall (window drag, GPU hiccup) doesn't make time-integrating shaders jump
+	v.timeDelta = float32(clampFloat(s.shaderDelta*speed, 0, MAX_TIME_DELTA))
+	v.frame = int32(s.frame)
+	// Averaged FPS is stable; instantaneous 1/deltaTime jitters every frame
+	v.frameRate = float32(s.fps)
+	if s.fps <= 0 {
+		// First second: no average yet
+		v.frameRate = 60.0 // fallback
+		if s.deltaTime > 0 {
+			v.frameRate = float32(1.0 / s.deltaTime)
+		}
+	}
+	// Mouse (real input only in interactive mode, see input.go)
+	// iMouse.xy = current position, iMouse.zw = click position (should be < 0 if not pressed)
+	v.mouse = f.mouse
+	// Mock date
+	now := f.date
+	if now.IsZero() {
+		now = time.Now()
+	}
+	v.date = [4]float32{float32(now.Year()), float32(now.Month()), float32(now.Day()), elapsed}
+	// Channel resolution from texture sizes (framebuffer size for placeholders), mock channel time
+	for channel, texture := range f.channels {
+		width, height, depth := fbWidth, fbHeight, float32(0.0)
+		if texture.width > 0 && texture.height > 0 {
+			// .z = pixel aspect ratio, like Shadertoy
+			width, height, depth = float32(texture.width), float32(texture.height), 1.0
+		}
+		v.channelResolution[channel*3] = width
+		v.channelResolution[channel*3+1] = height
+		v.channelResolution[channel*3+2] = depth
+		v.channelTime[channel] = elapsed
+	}
+	v.scale = float32(f.scale)
+	v.fade = s.fade
+	v.brightness = float32(f.brightness)
+	if f.brightness <= 0 {
+		v.brightness = 1
+	}
+	return v
+}
+
+// uniformSlot is one uniform of a frame: location and values (ints, or floats
+// in elements of size components)
+type uniformSlot struct {
+	location int32
+	ints     []int32
+	floats   []float32
+	size     int
+	always   bool // Time-varying: uploaded every frame without comparing
+}
+
+// slots lists the uniforms of v in upload order (location -1 = not uploaded)
+func (u *shaderUniforms) slots(v *uniformValues) []uniformSlot {
+	floats := func(location int32, size int, always bool, values ...float32) uniformSlot {
+		return uniformSlot{location: location, floats: values, size: size, always: always}
+	}
+	integer := func(location int32, always bool, value int32) uniformSlot {
+		return uniformSlot{location: location, ints: []int32{value}, always: always}
+	}
+	pixelSize := u.pixelSize
+	if v.pixelSize[0] <= 0 {
+		pixelSize = -1 // Zero-sized viewport
+	}
+	var paletteColors []float32
+	for _, color := range v.palette.colors {
+		paletteColors = append(paletteColors, color[:]...)
+	}
+	return []uniformSlot{
+		floats(u.resolution, 3, false, v.resolution[:]...),
+		integer(u.fragCoordMode, false, v.fragCoordMode),
+		floats(u.fragCoordOffset, 2, false, v.fragCoordOffset[:]...),
+		integer(u.fragCoordFlip, false, v.fragCoordFlip),
+		integer(u.dither, false, v.dither),
+		floats(u.palette, 3, false, paletteColors...),
+		floats(u.vignette, 2, false, v.vignette[:]...),
+		floats(u.brightness, 1, false, v.brightness),
+		floats(pixelSize, 2, false, v.pixelSize[:]...),
+		floats(u.time, 1, true, v.time),
+		floats(u.timeDelta, 1, true, v.timeDelta),
+		integer(u.frame, true, v.frame),
+		floats(u.frameRate, 1, true, v.frameRate),
+		floats(u.mouse, 4, false, v.mouse[:]...),
+		floats(u.date, 4, true, v.date[:]...),
+		floats(u.sampleRate, 1, false, SAMPLE_RATE),
+		floats(u.channelResolution, 3, false, v.channelResolution[:]...),
+		floats(u.channelTime, 1, true, v.channelTime[:]...),
+		floats(u.scale, 1, false, v.scale),
+		floats(u.fade, 1, true, v.fade),
+	}
+}
+
+// changedSlots returns the slots of next that differ from previous (all used
+// ones without previous); time-varying ones are always included
+func changedSlots(previous, next []uniformSlot) []uniformSlot {
+	var changed []uniformSlot
+	for i, slot := range next {
+		if slot.location < 0 {
+			continue
+		}
+		if previous == nil || slot.always || previous[i].location < 0 ||
+			!slices.Equal(slot.ints, previous[i].ints) || !slices.Equal(slot.floats, previous[i].floats) {
+			changed = append(changed, slot)
+		}
+	}
+	return changed
+}
+
+// apply uploads the slot (program must be in use)
+func (s uniformSlot) apply() {
+	if len(s.ints) > 0 {
+		gl.Uniform1i(s.location, s.ints[0])
+		return
+	}
+	count := int32(len(s.floats) / s.size)
+	switch s.size {
+	case 1:
+		gl.Uniform1fv(s.location, count, &s.floats[0])
+	case 2:
+		gl.Uniform2fv(s.location, count, &s.floats[0])
+	case 3:
+		gl.Uniform3fv(s.location, count, &s.floats[0])
+	case 4:
+		gl.Uniform4fv(s.location, count, &s.floats[0])
+	}
+}
+
+// upload sets uniforms for frame s (program must be in use). Uniform values
+// stay with the program, so values equal to the previous upload are skipped;
+// iTime, iFrame, iFade and the other time-varying ones are set every frame.
+func (u *shaderUniforms) upload(s *FrameState, f frameUniforms) {
+	v := frameUniformValues(s, f)
+	next := u.slots(&v)
+	for _, slot := range changedSlots(u.sent, next) {
+		slot.apply()
+	}
+	if u.sent == nil {
+		// Parameter values don't change for a program
+		uploadParamUniforms(u.params)
+	}
+	u.last, u.sent = v, next
+
+	if DEBUG_MODE && s.frame == 1 {
+		log.Printf("Setting iResolution to: %.0f x %.0f (aspect: %.3f)", v.resolution[0], v.resolution[1], v.resolution[2])
+		log.Printf("Setting iTime to: %.2f", v.time)
+	}
+}
+
+// dumpLines formats values as key=value lines headed by a timestamp; params are the
+// shader parameters uploaded with them
+func (v uniformValues) dumpLines(at time.Time, params []paramUniform) []string {
+	lines := []string{
+		"# uniforms at " + at.Format("2006-01-02 15:04:05.000"),
+		fmt.Sprintf("iResolution=%g,%g,%g", v.resolution[0], v.resolution[1], v.resolution[2]),
+		fmt.Sprintf("iTime=%g", v.time),
+		fmt.Sprintf("iTimeDelta=%g", v.timeDelta),
+		fmt.Sprintf("iFrame=%d", v.frame),
+		fmt.Sprintf("iFrameRate=%g", v.frameRate),
+		fmt.Sprintf("iMouse=%g,%g,%g,%g", v.mouse[0], v.mouse[1], v.mouse[2], v.mouse[3]),
+		fmt.Sprintf("iDate=%g,%g,%g,%g", v.date[0], v.date[1], v.date[2], v.date[3]),
+		fmt.Sprintf("iSampleRate=%g", SAMPLE_RATE),
+	}
+	for channel := 0; channel < CHANNEL_COUNT; channel++ {
+		r := v.channelResolution[channel*3 : channel*3+3]
+		lines = append(lines, fmt.Sprintf("iChannelResolution[%d]=%g,%g,%g", channel, r[0], r[1], r[2]))
+	}
+	lines = append(lines,
+		fmt.Sprintf("iChannelTime=%g", v.channelTime[0]),
+		fmt.Sprintf("iFade=%g", v.fade),
+		fmt.Sprintf("iPixelSize=%g,%g", v.pixelSize[0], v.pixelSize[1]),
+		fmt.Sprintf("iScale=%g", v.scale),
+		fmt.Sprintf("iFragCoordMode=%d", v.fragCoordMode),
+		fmt.Sprintf("iFragCoordOffset=%g,%g", v.fragCoordOffset[0], v.fragCoordOffset[1]),
+		fmt.Sprintf("iFragCoordFlip=%d", v.fragCoordFlip),
+		fmt.Sprintf("iDither=%d", v.dither),
+		fmt.Sprintf("iPalette=%s", v.palette.name),
+		fmt.Sprintf("iVignette=%g,%g", v.vignette[0], v.vignette[1]),
+		fmt.Sprintf("iBrightness=%g", v.brightness),
+	)
+	for _, param := range params {
+		lines = append(lines, fmt.Sprintf("%s=%g", param.name, param.value))
+	}
+	return lines
+}
+
+// dumpUniforms writes values of the latest upload to path (appending), or to the log if path is empty
+func (u *shaderUniforms) dumpUniforms(path string) error {
+	lines := u.last.dumpLines(time.Now(), u.params)
+	if path == "" {
+		for _, line := range lines {
+			log.Print(line)
+		}
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(strings.Join(lines, "\n") + "\n\n"); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestUniformDump checks uniform values and their dump lines for a square-mode frame
+func TestUniformDump(t *testing.T) {
+	state := fixedFrameState(90, 1.0/60, 1920, 1080)
+	state.fade = 0.5
+	v := frameUniformValues(&state, frameUniforms{
+		mouse:        [4]float32{1000, 600, 1000, -600},
+		scale:        2,
+		date:         time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC),
+		squareCoords: true,
+		dither:       DITHER_FADE,
+		palette:      PALETTE_POLAR,
+
+		vignette:       0.4,
+		vignetteRadius: VIGNETTE_RADIUS_DEFAULT,
+	})
+	params := []paramUniform{{name: "uIntensity", paramType: PARAM_FLOAT, value: 0.75}}
+	dump := strings.Join(v.dumpLines(time.Now(), params), "\n")
+	for _, want := range []string{
+		"iResolution=1080,1080,1", "iTime=1.5", "iFrame=90", "iMouse=580,600,580,-600",
+		"iDate=2026,3,14,1.5", "iFade=0.5", "iScale=2", "iFragCoordMode=1", "iFragCoordOffset=420,0",
+		"iDither=1", "iPalette=polar", "iVignette=0.4,0.5", "uIntensity=0.75",
+	} {
+		if !strings.Contains(dump, "\n"+want+"\n") && !strings.HasSuffix(dump, "\n"+want) {
+			t.Errorf("%q missing in uniform dump:\n%s", want, dump)
+		}
+	}
+}
+
+// TestUniformUploads checks that of unchanged uniforms only time-varying ones
+// are uploaded again, and logs the uniform calls saved over a minute at 60 fps
+func TestUniformUploads(t *testing.T) {
+	var u shaderUniforms // All locations 0: every uniform in use
+	f := frameUniforms{scale: 1, date: time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC), palette: PALETTE_AURORA}
+	frameSlots := func(frame, width int) []uniformSlot {
+		state := fixedFrameState(frame, SELFTEST_TIME_STEP, width, 1080)
+		v := frameUniformValues(&state, f)
+		return u.slots(&v)
+	}
+	countAlways := func(slots []uniformSlot) int {
+		count := 0
+		for _, slot := range slots {
+			if slot.always {
+				count++
+			}
+		}
+		return count
+	}
+
+	first := frameSlots(1, 1920)
+	if changed := changedSlots(nil, first); len(changed) != len(first) {
+		t.Errorf("first upload sets %d of %d uniforms", len(changed), len(first))
+	}
+	second := frameSlots(2, 1920)
+	changed := changedSlots(first, second)
+	if len(changed) != countAlways(second) || countAlways(changed) != len(changed) {
+		t.Errorf("unchanged frame uploads %d uniforms, expected the %d time-varying ones", len(changed), countAlways(second))
+	}
+	// iResolution, iPixelSize and iChannelResolution (placeholders report the framebuffer size)
+	if resized := changedSlots(second, frameSlots(3, 1280)); len(resized) != countAlways(second)+3 {
+		t.Errorf("resized frame uploads %d uniforms, expected %d", len(resized), countAlways(second)+3)
+	}
+
+	frames, calls := 3600, 0
+	var previous []uniformSlot
+	for frame := 1; frame <= frames; frame++ {
+		next := frameSlots(frame, 1920)
+		calls += len(changedSlots(previous, next))
+		previous = next
+	}
+	t.Logf("%d uniform calls in %d frames, %d without skipping unchanged ones", calls, frames, frames*len(first))
+}
+
+// TestDegenerateSize checks that a frame with zero width or height yields finite uniforms
+func TestDegenerateSize(t *testing.T) {
+	for _, size := range [][2]int{{1920, 0}, {0, 1080}, {0, 0}} {
+		if drawableSize(size[0], size[1]) {
+			t.Errorf("%dx%d reported as drawable", size[0], size[1])
+		}
+		for _, square := range []bool{false, true} {
+			state := fixedFrameState(1, SELFTEST_TIME_STEP, size[0], size[1])
+			v := frameUniformValues(&state, frameUniforms{squareCoords: square})
+			values := append(append(v.resolution[:], v.pixelSize[:]...), v.channelResolution[:]...)
+			values = append(values, v.fragCoordOffset[:]...)
+			for _, value := range values {
+				if math.IsInf(float64(value), 0) || math.IsNaN(float64(value)) {
+					t.Errorf("%dx%d (square %v): iResolution %v, iPixelSize %v", size[0], size[1], square, v.resolution, v.pixelSize)
+				}
+			}
+		}
+	}
+}
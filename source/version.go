@@ -0,0 +1,35 @@
+// Build/version information.
+//
+// Release builds set these via linker flags, e.g.:
+//
+//	go build -ldflags "-X main.version=2.0.0 -X main.commit=abc1234 -X main.buildDate=2026-01-01"
+//
+// Local builds without ldflags report "dev".
+package main
+
+import "strings"
+
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// versionString returns human readable version, e.g. "2.0.0 (abc1234, 2026-01-01)"
+func versionString() string {
+	v := version
+	if v == "" {
+		v = "dev"
+	}
+	var details []string
+	if commit != "" {
+		details = append(details, commit)
+	}
+	if buildDate != "" {
+		details = append(details, buildDate)
+	}
+	if len(details) > 0 {
+		v += " (" + strings.Join(details, ", ") + ")"
+	}
+	return v
+}
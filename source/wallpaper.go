@@ -0,0 +1,26 @@
+// Live wallpaper mode (`-wallpaper`).
+//
+// The preview renderer is embedded into the desktop window behind the icons
+// instead of the screensaver panel, so the aurora replaces the wallpaper while
+// clicks and focus go to the desktop. It runs until `-wallpaper-stop` (or
+// Ctrl+C in the console it was started from) and then redraws the configured
+// wallpaper. Windows only (see wallpaper_windows.go).
+package main
+
+import "log"
+
+// runWallpaperMode renders behind desktop icons until stopped
+func runWallpaperMode(cfg *Config) error {
+	host, err := findWallpaperHost()
+	if err != nil {
+		return err
+	}
+	// Like preview: honor safe mode, but a desktop session isn't a screensaver run
+	if isSafeModeActive() {
+		cfg.applySafeMode()
+	}
+	log.Printf("Wallpaper mode started (stop with -wallpaper-stop)")
+	runPreviewMode(host, cfg)
+	restoreWallpaper()
+	return nil
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+// +build !windows
+
+// Stubs for live wallpaper mode, which needs the Windows Explorer desktop
+// (see wallpaper_windows.go).
+package main
+
+import (
+	"errors"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+var errWallpaperUnsupported = errors.New("wallpaper mode is only supported on Windows")
+
+// findWallpaperHost is not available without Explorer
+func findWallpaperHost() (uintptr, error) {
+	return 0, errWallpaperUnsupported
+}
+
+// enableInputPassthrough is a no-op without wallpaper support
+func enableInputPassthrough(window *glfw.Window, windowTitle string) {}
+
+// listenForWallpaperStop is not available without wallpaper support
+func listenForWallpaperStop(stop func()) error {
+	return errWallpaperUnsupported
+}
+
+// stopWallpaper is not available without wallpaper support
+func stopWallpaper() error {
+	return errWallpaperUnsupported
+}
+
+// restoreWallpaper is a no-op without wallpaper support
+func restoreWallpaper() {}
@@ -0,0 +1,138 @@
+//go:build windows
+// +build windows
+
+// Live wallpaper (`-wallpaper`) host lookup on Windows.
+//
+// Explorer draws the desktop wallpaper into a WorkerW window behind the
+// SHELLDLL_DefView that holds the icons. Sending Progman the undocumented
+// 0x052C message creates that WorkerW; the preview window is then embedded
+// into it like into the screensaver panel (see windows_embed.go).
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// WALLPAPER_STOP_EVENT_NAME is the named event set by -wallpaper-stop
+const WALLPAPER_STOP_EVENT_NAME = `Local\AuroraBorealisBlissWallpaperStop`
+
+const (
+	PROGMAN_SPAWN_WORKERW = 0x052C
+	SPI_GETDESKWALLPAPER  = 0x0073
+	SPI_SETDESKWALLPAPER  = 0x0014
+)
+
+var (
+	procFindWindowEx         = user32.NewProc("FindWindowExW")
+	procSendMessageTimeout   = user32.NewProc("SendMessageTimeoutW")
+	procSystemParametersInfo = user32.NewProc("SystemParametersInfoW")
+	procOpenEventW           = syscall.NewLazyDLL("kernel32.dll").NewProc("OpenEventW")
+	procSetEvent             = syscall.NewLazyDLL("kernel32.dll").NewProc("SetEvent")
+)
+
+// findWindowEx finds the first child of parent after after with className (parent 0 = top-level)
+func findWindowEx(parent, after uintptr, className string) uintptr {
+	class, _ := syscall.UTF16PtrFromString(className)
+	hwnd, _, _ := procFindWindowEx.Call(parent, after, uintptr(unsafe.Pointer(class)), 0)
+	return hwnd
+}
+
+// findWallpaperHost returns the window that sits between the wallpaper and desktop icons
+func findWallpaperHost() (uintptr, error) {
+	progman := findWindowEx(0, 0, "Progman")
+	if progman == 0 {
+		return 0, fmt.Errorf("desktop window (Progman) not found, is Explorer running?")
+	}
+	// Ask Explorer to create the WorkerW behind the icons (no-op if it already exists)
+	var result uintptr
+	procSendMessageTimeout.Call(progman, PROGMAN_SPAWN_WORKERW, 0, 0, 0, 1000, uintptr(unsafe.Pointer(&result)))
+
+	// The WorkerW we want is the top-level sibling following the one holding SHELLDLL_DefView
+	var host uintptr
+	callback := syscall.NewCallback(func(hwnd, lparam uintptr) uintptr {
+		if findWindowEx(hwnd, 0, "SHELLDLL_DefView") != 0 {
+			host = findWindowEx(0, hwnd, "WorkerW")
+			return 0
+		}
+		return 1
+	})
+	procEnumWindows.Call(callback, 0)
+
+	if host == 0 {
+		// Windows 11 24H2+: WorkerW is a child of Progman
+		host = findWindowEx(progman, 0, "WorkerW")
+	}
+	if host == 0 {
+		return 0, fmt.Errorf("wallpaper window (WorkerW) not found")
+	}
+	return host, nil
+}
+
+// enableInputPassthrough keeps the embedded window from taking clicks and focus
+// from the desktop
+func enableInputPassthrough(window *glfw.Window, windowTitle string) {
+	var gwlExStyle int32 = -20
+	const WS_EX_TRANSPARENT = uintptr(0x00000020)
+	const WS_EX_NOACTIVATE = uintptr(0x08000000)
+
+	hwnd := getWindowHWND(windowTitle)
+	if hwnd == 0 {
+		return
+	}
+	exStyle, _, _ := procGetWindowLongPtr.Call(hwnd, uintptr(gwlExStyle))
+	procSetWindowLongPtr.Call(hwnd, uintptr(gwlExStyle), exStyle|WS_EX_TRANSPARENT|WS_EX_NOACTIVATE)
+}
+
+// listenForWallpaperStop calls stop once WALLPAPER_STOP_EVENT_NAME is set or on Ctrl+C
+func listenForWallpaperStop(stop func()) error {
+	name, err := syscall.UTF16PtrFromString(WALLPAPER_STOP_EVENT_NAME)
+	if err != nil {
+		return err
+	}
+	handle, _, callErr := procCreateEventW.Call(0, 1, 0, uintptr(unsafe.Pointer(name)))
+	if handle == 0 {
+		return callErr
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		procSetEvent.Call(handle)
+	}()
+	go func() {
+		if _, err := syscall.WaitForSingleObject(syscall.Handle(handle), syscall.INFINITE); err == nil {
+			stop()
+		}
+	}()
+	return nil
+}
+
+// stopWallpaper signals a running -wallpaper instance to exit
+func stopWallpaper() error {
+	const EVENT_MODIFY_STATE = 0x0002
+	name, err := syscall.UTF16PtrFromString(WALLPAPER_STOP_EVENT_NAME)
+	if err != nil {
+		return err
+	}
+	handle, _, callErr := procOpenEventW.Call(EVENT_MODIFY_STATE, 0, uintptr(unsafe.Pointer(name)))
+	if handle == 0 {
+		return fmt.Errorf("no running wallpaper instance: %v", callErr)
+	}
+	defer syscall.CloseHandle(syscall.Handle(handle))
+	procSetEvent.Call(handle)
+	return nil
+}
+
+// restoreWallpaper makes Explorer repaint the configured wallpaper over the last frame
+func restoreWallpaper() {
+	var path [syscall.MAX_PATH]uint16
+	procSystemParametersInfo.Call(SPI_GETDESKWALLPAPER, uintptr(len(path)), uintptr(unsafe.Pointer(&path[0])), 0)
+	// Setting the same path without SPIF_UPDATEINIFILE only redraws
+	procSystemParametersInfo.Call(SPI_SETDESKWALLPAPER, 0, uintptr(unsafe.Pointer(&path[0])), 0)
+}
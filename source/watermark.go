@@ -0,0 +1,92 @@
+// Shader credit watermark.
+//
+// With `-watermark`, the shader metadata title, author and URL are drawn in a
+// screen corner for a few seconds after start and then fade out. The text is
+// drawn with TextRenderer after the shader quad, in fullscreen mode only (the
+// preview window is too small for readable text).
+package main
+
+import "fmt"
+
+const (
+	WATERMARK_POS_TOP_LEFT     = "top-left"
+	WATERMARK_POS_TOP_RIGHT    = "top-right"
+	WATERMARK_POS_BOTTOM_LEFT  = "bottom-left"
+	WATERMARK_POS_BOTTOM_RIGHT = "bottom-right"
+
+	WATERMARK_DEFAULT_DURATION = 6.0 // Seconds the watermark stays on screen, including fade-out
+	WATERMARK_FADE_DURATION    = 1.5 // Seconds of fade-out at the end
+	WATERMARK_MARGIN           = 16  // Distance from screen edges in pixels (before scaling)
+	WATERMARK_LINE_HEIGHT      = 15  // Line step in pixels (basicfont.Face7x13 + spacing)
+	WATERMARK_MAX_ALPHA        = 0.8 // Watermark is never fully opaque
+)
+
+// validateWatermarkPosition reports an error for unknown corner names
+func validateWatermarkPosition(position string) error {
+	switch position {
+	case WATERMARK_POS_TOP_LEFT, WATERMARK_POS_TOP_RIGHT, WATERMARK_POS_BOTTOM_LEFT, WATERMARK_POS_BOTTOM_RIGHT:
+		return nil
+	}
+	return fmt.Errorf("unknown watermark position %q (expected top-left, top-right, bottom-left or bottom-right)", position)
+}
+
+// watermarkLines returns text lines credited by the watermark (nil if metadata has no title)
+func watermarkLines(meta *ShaderMetadata) []string {
+	if meta == nil || meta.Title == "" {
+		return nil
+	}
+	lines := []string{meta.Title}
+	if meta.Author != "" {
+		lines = append(lines, "by "+meta.Author)
+	}
+	if meta.URL != "" {
+		lines = append(lines, meta.URL)
+	}
+	return lines
+}
+
+// watermarkAlpha returns watermark opacity at elapsed seconds since start.
+// Full opacity until the fade-out window, then linear fade to 0 at duration.
+func watermarkAlpha(elapsed, duration float64) float32 {
+	if elapsed >= duration {
+		return 0.0
+	}
+	fadeStart := duration - WATERMARK_FADE_DURATION
+	if fadeStart < 0 {
+		fadeStart = 0
+	}
+	if elapsed <= fadeStart {
+		return WATERMARK_MAX_ALPHA
+	}
+	return float32(WATERMARK_MAX_ALPHA * (duration - elapsed) / (duration - fadeStart))
+}
+
+// drawWatermark renders lines in the configured corner.
+// scale is framebuffer/window ratio so text keeps its size on HiDPI screens.
+func drawWatermark(tr *TextRenderer, lines []string, position string, alpha float32, scale float32) {
+	if len(lines) == 0 || alpha <= 0 {
+		return
+	}
+
+	margin := WATERMARK_MARGIN * scale
+	lineHeight := WATERMARK_LINE_HEIGHT * scale
+	blockHeight := lineHeight * float32(len(lines))
+
+	y := margin
+	if position == WATERMARK_POS_BOTTOM_LEFT || position == WATERMARK_POS_BOTTOM_RIGHT {
+		y = float32(tr.height) - margin - blockHeight
+	}
+
+	tr.SetColor(1.0, 1.0, 1.0, alpha)
+	if position == WATERMARK_POS_TOP_RIGHT || position == WATERMARK_POS_BOTTOM_RIGHT {
+		// Right-aligned lines start at different x, one draw each
+		for _, line := range lines {
+			x := float32(tr.width) - margin - tr.TextWidth(line, scale)
+			tr.Render(line, x, y, scale)
+			y += lineHeight
+		}
+	} else {
+		tr.RenderLines(lines, margin, y, lineHeight, scale)
+	}
+	tr.SetColor(1.0, 1.0, 1.0, 1.0)
+}
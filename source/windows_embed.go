@@ -34,6 +34,9 @@ var (
 	procEnumWindows      = user32.NewProc("EnumWindows")
 )
 
+// previewEmbeddingSupported reports whether embedWindowIntoParent is implemented
+const previewEmbeddingSupported = true
+
 // getWindowHWND gets HWND of a GLFW window by finding the window with matching title
 // Returns HWND or 0 if not found
 func getWindowHWND(windowTitle string) uintptr {
@@ -1,27 +1,32 @@
-//go:build !windows
+//go:build !windows && (!linux || wayland)
 // +build !windows
+// +build !linux wayland
 
-// Non-Windows stubs for preview embedding APIs.
-// These functions keep build targets portable while preview embedding remains
-// implemented only through Win32 calls in `windows_embed.go`.
+// Stubs for preview embedding APIs on platforms without embedding support.
+// These functions keep build targets portable while preview embedding is
+// implemented through Win32 calls in `windows_embed.go` and Xlib calls in
+// `linux_embed.go`.
 package main
 
 import (
 	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
-// hideWindow is a no-op on non-Windows platforms
+// previewEmbeddingSupported reports whether embedWindowIntoParent is implemented
+const previewEmbeddingSupported = false
+
+// hideWindow is a no-op on platforms without embedding
 func hideWindow(window *glfw.Window, windowTitle string) {
-	// No-op on non-Windows
+	// No-op without embedding support
 }
 
-// showWindow is a no-op on non-Windows platforms
+// showWindow is a no-op on platforms without embedding
 func showWindow(window *glfw.Window, windowTitle string) {
-	// No-op on non-Windows
+	// No-op without embedding support
 }
 
-// embedWindowIntoParent is a stub for non-Windows platforms
+// embedWindowIntoParent is a stub for platforms without embedding
 func embedWindowIntoParent(window *glfw.Window, parentHWND uintptr, windowTitle string) (int, int) {
-	// Not implemented on non-Windows platforms
+	// Not implemented on this platform
 	return 320, 240 // Default size
 }
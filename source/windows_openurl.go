@@ -23,6 +23,11 @@ func openURL(url string) error {
 	// lpFile = URL (UTF-16)
 	// nShowCmd = SW_SHOWNORMAL = 1
 	
+	url, err := checkOpenURL(url)
+	if err != nil {
+		return err
+	}
+
 	// "open" verb asks ShellExecute to use default action for the URL scheme.
 	operationUTF16, _ := syscall.UTF16FromString("open")
 	urlUTF16, _ := syscall.UTF16FromString(url)
@@ -13,6 +13,10 @@ import (
 
 // openURL opens URL in default browser on non-Windows platforms
 func openURL(url string) error {
+	url, err := checkOpenURL(url)
+	if err != nil {
+		return err
+	}
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "darwin":